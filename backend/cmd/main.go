@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 
+	"backend/internal/config"
 	"backend/internal/handler"
 	"backend/internal/middlewares"
+	"backend/internal/repository"
 	"backend/internal/routes"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/blobstore"
 	"backend/pkg/db/sqlite"
 )
 
@@ -22,16 +28,39 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// Register all routes (handlers)
-	routes.RegisterRoutes(db)
+	cfg := config.Load(os.Getenv)
 
-	go handler.HandleMessages(db)
+	uploads, err := blobstore.ServerFromEnv(os.Getenv, utils.UploadsPath)
+	if err != nil {
+		log.Fatalf("Failed to configure uploads: %v", err)
+	}
+	utils.Uploads = uploads
+	if moved, err := blobstore.Import(context.Background(), uploads.Store, utils.LegacyUploadsDir); err != nil {
+		log.Printf("Failed to move uploads from %s: %v", utils.LegacyUploadsDir, err)
+	} else if moved > 0 {
+		log.Printf("Moved %d uploads from %s into the store", moved, utils.LegacyUploadsDir)
+	}
 
-	// Serve uploaded files from the /uploads/ directory
-	// This allows accessing files at http://localhost:8080/uploads/<filename>
-	http.Handle("/uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./uploads"))))
+	admins := service.NewAdminService(repository.NewAdminRepository(db))
+	if promoted, err := admins.EnsureAdmins(cfg.AdminEmails); err != nil {
+		log.Printf("Failed to promote admins: %v", err)
+	} else if promoted > 0 {
+		log.Printf("Promoted %d users to admin", promoted)
+	}
+
+	// Register all routes (handlers), uploads are served at http://localhost:8080/uploads/<key>
+	routes.RegisterRoutes(db, cfg)
+
+	go handler.HandleMessages(db)
+	go handler.HandlePollResults(db)
+	go handler.RunSessionCleanup(db, sqlite.SessionCleanupPeriod)
+	go handler.RunAccountMaintenance(db, service.AccountMaintenancePeriod)
+	go handler.RunFeedRanking(db, service.FeedRankingPeriod)
+	go handler.RunTrendingTags(db, service.TrendingPeriod)
+	go handler.RunScheduledPosts(db, service.ScheduledPostsPeriod)
 
-	handlersWithCors := middlewares.EnableCors(http.DefaultServeMux)
+	// every state-changing route needs a CSRF token unless it is called with a bearer token
+	handlersWithCors := middlewares.EnableCors(cfg, middlewares.CSRF(cfg, http.DefaultServeMux))
 
 	// Start the HTTP server
 	addr := ":8080"
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultAllowedOrigin is the Next.js development server
+const DefaultAllowedOrigin = "http://localhost:3000"
+
+// Config holds the settings read from the environment at startup
+type Config struct {
+	// AllowedOrigins are the browser origins allowed to call the API with cookies and to open
+	// WebSocket connections, from ALLOWED_ORIGINS (comma separated)
+	AllowedOrigins []string
+	// FrontendURL is where browsers are sent after redirect based flows such as provider
+	// sign-in, from FRONTEND_URL. Defaults to the first allowed origin.
+	FrontendURL string
+	// AdminEmails are made site admins at startup, from ADMIN_EMAILS (comma separated),
+	// so a new install has someone who can hand out roles
+	AdminEmails []string
+}
+
+// Load reads the configuration. getenv is usually os.Getenv.
+func Load(getenv func(string) string) *Config {
+	cfg := &Config{}
+	for _, origin := range strings.Split(getenv("ALLOWED_ORIGINS"), ",") {
+		if origin = normalizeOrigin(origin); origin != "" {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+		}
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		cfg.AllowedOrigins = []string{DefaultAllowedOrigin}
+	}
+
+	cfg.FrontendURL = strings.TrimSuffix(strings.TrimSpace(getenv("FRONTEND_URL")), "/")
+	if cfg.FrontendURL == "" {
+		cfg.FrontendURL = cfg.AllowedOrigins[0]
+	}
+
+	for _, email := range strings.Split(getenv("ADMIN_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			cfg.AdminEmails = append(cfg.AdminEmails, email)
+		}
+	}
+	return cfg
+}
+
+// OriginAllowed reports whether origin, as sent in an Origin header, is one of the allowed origins.
+func (c *Config) OriginAllowed(origin string) bool {
+	origin = normalizeOrigin(origin)
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range c.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeOrigin reduces a URL to its scheme://host[:port] origin in lower case, or "" if it has none
+func normalizeOrigin(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
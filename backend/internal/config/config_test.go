@@ -0,0 +1,46 @@
+package config
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	cfg := Load(func(string) string { return "" })
+	if len(cfg.AllowedOrigins) != 1 || cfg.AllowedOrigins[0] != DefaultAllowedOrigin || cfg.FrontendURL != DefaultAllowedOrigin {
+		t.Errorf("Unexpected defaults: %+v", cfg)
+	}
+
+	env := map[string]string{
+		"ALLOWED_ORIGINS": " https://Social.Example.com/ , not-an-origin, http://localhost:3000",
+		"ADMIN_EMAILS":    "admin@example.com, ,ops@example.com",
+	}
+	cfg = Load(func(key string) string { return env[key] })
+	if len(cfg.AllowedOrigins) != 2 || cfg.FrontendURL != "https://social.example.com" {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+	if len(cfg.AdminEmails) != 2 || cfg.AdminEmails[1] != "ops@example.com" {
+		t.Errorf("Unexpected admin emails: %v", cfg.AdminEmails)
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	cfg := &Config{AllowedOrigins: []string{"https://social.example.com", "http://localhost:3000"}}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://social.example.com", true},
+		{"HTTPS://SOCIAL.EXAMPLE.COM", true},
+		{"https://social.example.com/posts/1", true}, // referer style URLs reduce to their origin
+		{"http://social.example.com", false},
+		{"https://social.example.com.evil.com", false},
+		{"http://localhost:3001", false},
+		{"null", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.OriginAllowed(tt.origin); got != tt.want {
+			t.Errorf("OriginAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
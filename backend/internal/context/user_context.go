@@ -13,6 +13,10 @@ const (
 	UserContextKey ContextKey = "user"
 	// SessionIDContextKey is the key used to store session ID in request context
 	SessionIDContextKey ContextKey = "session_id"
+	// ScopesContextKey is the key used to store the scopes of the API token a request was made with
+	ScopesContextKey ContextKey = "scopes"
+	// CSRFTokenContextKey is the key used to store the request's CSRF token
+	CSRFTokenContextKey ContextKey = "csrf_token"
 )
 
 // WithUser adds a user to the context
@@ -35,6 +39,13 @@ func MustGetUser(ctx context.Context) *model.User {
 	return user
 }
 
+// GetUser retrieves the user from context, or nil for anonymous requests
+// such as the ones let through by OptionalAuth
+func GetUser(ctx context.Context) *model.User {
+	user, _ := ctx.Value(UserContextKey).(*model.User)
+	return user
+}
+
 // MustGetSessionID retrieves the session ID from context and panics if not found
 // This should only be used in handlers that are protected by auth middleware
 func MustGetSessionID(ctx context.Context) string {
@@ -44,3 +55,40 @@ func MustGetSessionID(ctx context.Context) string {
 	}
 	return sessionID
 }
+
+// WithScopes marks the request as authenticated by an API token granting the given scopes
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, ScopesContextKey, scopes)
+}
+
+// IsTokenRequest reports whether the request was authenticated with an API token rather than a session
+func IsTokenRequest(ctx context.Context) bool {
+	_, ok := ctx.Value(ScopesContextKey).([]string)
+	return ok
+}
+
+// HasScope reports whether the request may act within the given scope.
+// Session requests are not restricted by scopes.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(ScopesContextKey).([]string)
+	if !ok {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// WithCSRFToken adds the request's CSRF token to the context
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, CSRFTokenContextKey, token)
+}
+
+// GetCSRFToken retrieves the CSRF token, or "" if the CSRF middleware did not run
+func GetCSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(CSRFTokenContextKey).(string)
+	return token
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/extractid"
+)
+
+// AccountHandler lets users delete their account and download a copy of their data
+type AccountHandler struct {
+	Service *service.AccountService
+	Audit   *service.AuditService
+}
+
+// Deletion handles /api/account/deletion: GET shows the scheduled deletion, POST schedules one
+// and DELETE cancels it during the grace period.
+func (h *AccountHandler) Deletion(w http.ResponseWriter, r *http.Request) {
+	user := context.MustGetUser(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		deletion, err := h.Service.Deletion(user.ID)
+		if err != nil {
+			log.Printf("Failed to get account deletion: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get account deletion")
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"scheduled": deletion != nil,
+			"deletion":  deletion,
+		})
+
+	case http.MethodPost:
+		deletion, err := h.Service.ScheduleDeletion(user.ID)
+		if err != nil {
+			if err == service.ErrDeletionAlreadyScheduled {
+				utils.RespondWithError(w, http.StatusConflict, err.Error())
+				return
+			}
+			log.Printf("Failed to schedule account deletion: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to schedule account deletion")
+			return
+		}
+		recordAudit(h.Audit, auditEvent(r, user.ID, model.AuditAccountDeletionRequest, model.AuditTargetUser, user.ID),
+			nil, map[string]any{"purge_after": deletion.PurgeAfter})
+		utils.RespondWithJSON(w, http.StatusAccepted, deletion)
+
+	case http.MethodDelete:
+		if err := h.Service.CancelDeletion(user.ID); err != nil {
+			if err == service.ErrDeletionNotScheduled {
+				utils.RespondWithError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			log.Printf("Failed to cancel account deletion: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to cancel account deletion")
+			return
+		}
+		recordAudit(h.Audit, auditEvent(r, user.ID, model.AuditAccountDeletionCancel, model.AuditTargetUser, user.ID),
+			map[string]any{"deletion_scheduled": true}, map[string]any{"deletion_scheduled": false})
+		utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Account deletion cancelled"})
+
+	default:
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// Exports handles GET /api/account/exports to list exports and POST /api/account/exports to start one.
+func (h *AccountHandler) Exports(w http.ResponseWriter, r *http.Request) {
+	user := context.MustGetUser(r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		exports, err := h.Service.Exports(user.ID)
+		if err != nil {
+			log.Printf("Failed to list data exports: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list exports")
+			return
+		}
+		if exports == nil {
+			exports = []model.DataExport{}
+		}
+		utils.RespondWithJSON(w, http.StatusOK, exports)
+
+	case http.MethodPost:
+		export, err := h.Service.RequestExport(user.ID)
+		if err != nil {
+			if err == service.ErrExportInProgress {
+				utils.RespondWithError(w, http.StatusConflict, err.Error())
+				return
+			}
+			log.Printf("Failed to request data export: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to request export")
+			return
+		}
+
+		// start right away instead of waiting for the next maintenance run
+		go func() {
+			if err := h.Service.RunExport(export.ID); err != nil {
+				log.Printf("Failed to build data export: %v", err)
+			}
+		}()
+		utils.RespondWithJSON(w, http.StatusAccepted, export)
+
+	default:
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// DownloadExport handles GET /api/account/exports/:id and sends the finished ZIP archive.
+func (h *AccountHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	exportID := extractid.ExtractUserIDFromPath(r.URL.Path, "exports")
+	if exportID == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid export ID")
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	export, err := h.Service.ReadyExport(user.ID, exportID)
+	if err != nil {
+		switch err {
+		case service.ErrExportNotFound:
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		case service.ErrExportNotReady:
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("Failed to get data export: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get export")
+		}
+		return
+	}
+
+	f, err := os.Open(export.FilePath)
+	if err != nil {
+		log.Printf("Failed to open data export archive: %v", err)
+		utils.RespondWithError(w, http.StatusNotFound, service.ErrExportNotFound.Error())
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="social-network-export.zip"`)
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, "", *export.CompletedAt, f)
+}
+
+// RunAccountMaintenance periodically builds queued data exports, removes expired archives and
+// purges accounts whose deletion grace period has ended. It is meant to run in its own goroutine.
+func RunAccountMaintenance(db *sql.DB, interval time.Duration) {
+	accounts := service.NewAccountService(repository.NewAccountRepository(db))
+	accounts.Audit = service.NewAuditService(repository.NewAuditRepository(db))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := accounts.RunPendingExports(); err != nil {
+			log.Println("Failed to build data exports:", err)
+		}
+		if removed, err := accounts.PurgeExpiredExports(); err != nil {
+			log.Println("Failed to remove expired data exports:", err)
+		} else if removed > 0 {
+			log.Printf("Removed %d expired data exports", removed)
+		}
+		purged, err := accounts.PurgeDueAccounts()
+		if err != nil {
+			log.Println("Failed to purge deleted accounts:", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted accounts", purged)
+		}
+		<-ticker.C
+	}
+}
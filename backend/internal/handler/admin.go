@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/extractid"
+)
+
+// SuspendUserRequest is the payload for suspending a user
+type SuspendUserRequest struct {
+	Reason string `json:"reason"`
+	Days   int    `json:"days"` // 0 suspends the user until they are reinstated
+}
+
+// SetRoleRequest is the payload for changing a user's site-wide role
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// AdminHandler serves the site administration API used by moderators and admins
+type AdminHandler struct {
+	Service *service.AdminService
+	Audit   *service.AuditService
+}
+
+// auditedUserFields are the fields of a user whose changes go into the audit log
+func auditedUserFields(user *model.AdminUser) map[string]any {
+	if user == nil {
+		return nil
+	}
+	return map[string]any{"role": user.Role, "suspension": user.Suspension}
+}
+
+// Users handles GET /api/admin/users?q=&role=&suspended=&limit=&offset= to list and search users.
+func (h *AdminHandler) Users(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.UserFilter{
+		Query: query.Get("q"),
+		Role:  query.Get("role"),
+	}
+	if suspended := query.Get("suspended"); suspended != "" {
+		value, err := strconv.ParseBool(suspended)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "suspended must be true or false")
+			return
+		}
+		filter.Suspended = &value
+	}
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	users, err := h.Service.ListUsers(filter)
+	if err != nil {
+		if err == service.ErrInvalidRole {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Failed to list users: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+	if users == nil {
+		users = []model.AdminUser{}
+	}
+	utils.RespondWithJSON(w, http.StatusOK, users)
+}
+
+// User handles the actions on one user:
+//
+//	GET  /api/admin/users/:id            show the user
+//	POST /api/admin/users/:id/suspend    suspend the user and end their sessions
+//	POST /api/admin/users/:id/reinstate  lift a suspension
+//	POST /api/admin/users/:id/logout     end all of the user's sessions
+//	PUT  /api/admin/users/:id/role       change the user's role (admins only)
+func (h *AdminHandler) User(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/users/"), "/"), "/")
+	userID, action := parts[0], ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+	if userID == "" || len(parts) > 2 {
+		utils.RespondWithError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	actor := context.MustGetUser(r.Context())
+	var user, before *model.AdminUser
+	var auditAction string
+	var err error
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		user, err = h.Service.User(userID)
+
+	case action == "suspend" && r.Method == http.MethodPost:
+		var req SuspendUserRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+decodeErr.Error())
+			return
+		}
+		before, _ = h.Service.User(userID)
+		user, err = h.Service.Suspend(actor, userID, req.Reason, time.Duration(req.Days)*24*time.Hour)
+		auditAction = model.AuditUserSuspended
+
+	case action == "reinstate" && r.Method == http.MethodPost:
+		before, _ = h.Service.User(userID)
+		user, err = h.Service.Reinstate(actor, userID)
+		auditAction = model.AuditUserReinstated
+
+	case action == "logout" && r.Method == http.MethodPost:
+		revoked, logoutErr := h.Service.ForceLogout(actor, userID)
+		if logoutErr != nil {
+			respondAdminError(w, logoutErr)
+			return
+		}
+		recordAudit(h.Audit, auditEvent(r, actor.ID, model.AuditUserLoggedOut, model.AuditTargetUser, userID),
+			map[string]any{"sessions": revoked}, map[string]any{"sessions": 0})
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"message": "Sessions revoked",
+			"revoked": revoked,
+		})
+		return
+
+	case action == "role" && r.Method == http.MethodPut:
+		var req SetRoleRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+decodeErr.Error())
+			return
+		}
+		before, _ = h.Service.User(userID)
+		user, err = h.Service.SetRole(actor, userID, req.Role)
+		auditAction = model.AuditRoleChanged
+
+	case action == "" || action == "suspend" || action == "reinstate" || action == "logout" || action == "role":
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+
+	default:
+		utils.RespondWithError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if err != nil {
+		respondAdminError(w, err)
+		return
+	}
+	if auditAction != "" {
+		recordAudit(h.Audit, auditEvent(r, actor.ID, auditAction, model.AuditTargetUser, userID),
+			auditedUserFields(before), auditedUserFields(user))
+	}
+	utils.RespondWithJSON(w, http.StatusOK, user)
+}
+
+// DeletePost handles DELETE /api/admin/posts/:id.
+func (h *AdminHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	postID := extractid.ExtractUserIDFromPath(r.URL.Path, "posts")
+	if postID == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+	if err := h.Service.DeletePost(postID); err != nil {
+		respondAdminError(w, err)
+		return
+	}
+	recordAudit(h.Audit, auditEvent(r, context.MustGetUser(r.Context()).ID, model.AuditPostDeleted, model.AuditTargetPost, postID),
+		map[string]any{"deleted": false}, map[string]any{"deleted": true})
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Post deleted"})
+}
+
+// DeleteComment handles DELETE /api/admin/comments/:id.
+func (h *AdminHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	commentID := extractid.ExtractUserIDFromPath(r.URL.Path, "comments")
+	if commentID == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid comment ID")
+		return
+	}
+	if err := h.Service.DeleteComment(commentID); err != nil {
+		respondAdminError(w, err)
+		return
+	}
+	recordAudit(h.Audit, auditEvent(r, context.MustGetUser(r.Context()).ID, model.AuditCommentDeleted, model.AuditTargetComment, commentID),
+		map[string]any{"deleted": false}, map[string]any{"deleted": true})
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Comment deleted"})
+}
+
+func respondAdminError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrAdminUserNotFound, service.ErrPostNotFound, service.ErrCommentNotFound:
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case service.ErrInvalidRole, service.ErrInvalidSuspension:
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case service.ErrInsufficientRole, service.ErrCannotManageUser:
+		utils.RespondWithError(w, http.StatusForbidden, err.Error())
+	default:
+		log.Printf("Admin action failed: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/extractid"
+)
+
+// CreateAPITokenRequest is the payload for creating a personal access token
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 creates a token that never expires
+}
+
+// APITokenHandler manages the current user's personal access tokens
+type APITokenHandler struct {
+	Service *service.APITokenService
+	Audit   *service.AuditService
+}
+
+// Tokens handles GET /api/tokens to list tokens and POST /api/tokens to create one.
+func (h *APITokenHandler) Tokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, r)
+	case http.MethodPost:
+		h.create(w, r)
+	default:
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *APITokenHandler) list(w http.ResponseWriter, r *http.Request) {
+	user := context.MustGetUser(r.Context())
+	tokens, err := h.Service.List(user.ID)
+	if err != nil {
+		log.Printf("Failed to list API tokens: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list tokens")
+		return
+	}
+	if tokens == nil {
+		tokens = []model.APIToken{}
+	}
+	utils.RespondWithJSON(w, http.StatusOK, tokens)
+}
+
+func (h *APITokenHandler) create(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	raw, token, err := h.Service.Create(user.ID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidAPITokenName, service.ErrInvalidAPITokenScope, service.ErrInvalidAPITokenTTL:
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		case service.ErrTooManyAPITokens:
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("Failed to create API token: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create token")
+		}
+		return
+	}
+
+	recordAudit(h.Audit, auditEvent(r, user.ID, model.AuditAPITokenCreated, model.AuditTargetAPIToken, token.ID),
+		nil, map[string]any{"name": token.Name, "scopes": token.Scopes, "expires_at": token.ExpiresAt})
+
+	// the plaintext token is only ever returned here
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"token":   raw,
+		"details": token,
+	})
+}
+
+// Revoke handles DELETE /api/tokens/:id.
+func (h *APITokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	tokenID := extractid.ExtractUserIDFromPath(r.URL.Path, "tokens")
+	if tokenID == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	if err := h.Service.Revoke(user.ID, tokenID); err != nil {
+		if err == service.ErrAPITokenNotFound {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("Failed to revoke API token: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+
+	recordAudit(h.Audit, auditEvent(r, user.ID, model.AuditAPITokenRevoked, model.AuditTargetAPIToken, tokenID),
+		map[string]any{"revoked": false}, map[string]any{"revoked": true})
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Token revoked"})
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// BookmarkHandler lets users save posts for later and group them in collections
+type BookmarkHandler struct {
+	Service *service.BookmarkService
+}
+
+// Bookmarks handles /api/bookmarks.
+//
+// GET ?collection=&cursor=&limit= returns a page of the user's saved posts, most recently saved first, or of
+// the posts in a collection, which may be another user's unless it is private. As with /api/feeds,
+// next_cursor fetches the following page and is empty after the last one.
+//
+// POST with {"post_id": "...", "collection_id": "..."} saves a post, in one of the user's collections if
+// collection_id is set.
+func (h *BookmarkHandler) Bookmarks(w http.ResponseWriter, r *http.Request) {
+	userID := context.MustGetUser(r.Context()).ID
+	switch r.Method {
+	case http.MethodGet:
+		limit, ok := feedPageSize(w, r)
+		if !ok {
+			return
+		}
+		var after *repository.FeedCursor
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			parsed, err := repository.ParseFeedCursor(cursor)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			after = parsed
+		}
+
+		posts, next, err := h.Service.Saved(userID, r.URL.Query().Get("collection"), after, limit)
+		if err != nil {
+			respondBookmarkError(w, err)
+			return
+		}
+		if posts == nil {
+			posts = []model.Post{}
+		}
+		nextCursor := ""
+		if next != nil {
+			nextCursor = next.String()
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"posts":       posts,
+				"next_cursor": nextCursor,
+			},
+		})
+	case http.MethodPost:
+		var req struct {
+			PostID       string `json:"post_id"`
+			CollectionID string `json:"collection_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PostID == "" {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := h.Service.Save(userID, req.PostID, req.CollectionID); err != nil {
+			respondBookmarkError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Bookmark handles DELETE /api/bookmarks/:postID?collection= and removes the user's bookmark of a post,
+// or only takes the post out of one of the user's collections when collection is set.
+func (h *BookmarkHandler) Bookmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	postID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/bookmarks/"), "/")
+	if postID == "" || strings.Contains(postID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Service.Remove(context.MustGetUser(r.Context()).ID, postID, r.URL.Query().Get("collection")); err != nil {
+		respondBookmarkError(w, err)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// Collections handles /api/collections.
+//
+// GET ?user= lists the collections of a user, by default the current one. Other users' private
+// collections are left out. POST with {"name": "...", "private": true} creates a collection.
+func (h *BookmarkHandler) Collections(w http.ResponseWriter, r *http.Request) {
+	userID := context.MustGetUser(r.Context()).ID
+	switch r.Method {
+	case http.MethodGet:
+		ownerID := r.URL.Query().Get("user")
+		if ownerID == "" {
+			ownerID = userID
+		}
+		collections, err := h.Service.Collections(userID, ownerID)
+		if err != nil {
+			respondBookmarkError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"collections": collections})
+	case http.MethodPost:
+		var req struct {
+			Name    string `json:"name"`
+			Private bool   `json:"private"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		collection, err := h.Service.CreateCollection(userID, req.Name, req.Private)
+		if err != nil {
+			respondBookmarkError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusCreated, collection)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Collection handles PATCH and DELETE /api/collections/:id for the owner of the collection.
+// PATCH takes {"name": "...", "private": false}, either of which may be left out. DELETE keeps
+// the posts in the collection saved.
+func (h *BookmarkHandler) Collection(w http.ResponseWriter, r *http.Request) {
+	collectionID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/collections/"), "/")
+	if collectionID == "" || strings.Contains(collectionID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID := context.MustGetUser(r.Context()).ID
+	switch r.Method {
+	case http.MethodPatch:
+		var req struct {
+			Name    *string `json:"name"`
+			Private *bool   `json:"private"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		collection, err := h.Service.UpdateCollection(userID, collectionID, req.Name, req.Private)
+		if err != nil {
+			respondBookmarkError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, collection)
+	case http.MethodDelete:
+		if err := h.Service.DeleteCollection(userID, collectionID); err != nil {
+			respondBookmarkError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func respondBookmarkError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrPostNotFound, service.ErrBookmarkNotFound, service.ErrCollectionNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrInvalidCollectionName:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case service.ErrCollectionNameTaken:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Bookmark request failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -3,9 +3,12 @@ package handler
 import (
 	"backend/internal/context"
 	"backend/internal/model"
+	"backend/internal/policy"
+	"backend/internal/repository"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -68,9 +71,8 @@ func createComment(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		return
 	}
 
-	// Check if post exists
-	var postExists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM posts WHERE id = ?)", postId).Scan(&postExists)
+	// Posts the user may not comment on are treated as gone
+	postExists, err := policy.New(db).CanComment(currentUser.ID, postId)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -83,7 +85,7 @@ func createComment(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	// If this is a reply, validate parent comment exists
 	if req.ParentId != "" {
 		var parentExists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM comments WHERE id = ? AND post_id = ?)", req.ParentId, postId).Scan(&parentExists)
+		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM comments WHERE id = ? AND post_id = ? AND hidden_at IS NULL)", req.ParentId, postId).Scan(&parentExists)
 		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
 			return
@@ -103,18 +105,42 @@ func createComment(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		parentId = sql.NullString{String: req.ParentId, Valid: true}
 	}
 
-	_, err = db.Exec(`
+	// the comment and its hashtags are saved together
+	tx, err := db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
 		INSERT INTO comments (id, post_id, user_id, content, parent_id, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)`,
 		commentId, postId, currentUser.ID, req.Content, parentId, now, now)
+	if err == nil {
+		err = repository.InsertCommentTags(tx, postId, commentId, req.Content, now)
+	}
+	if err == nil {
+		err = repository.InsertCommentMentions(tx, postId, commentId, currentUser.ID, req.Content, now)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 
 	if err != nil {
 		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
 		return
 	}
 
+	if err := newMentionService(db).NotifyComment(commentId); err != nil {
+		log.Printf("Failed to notify mentions in comment %s: %v", commentId, err)
+	}
+
 	// Get the created comment with user info
 	comment, err := getCommentWithUserInfo(db, commentId)
+	if err == nil {
+		err = attachCommentMentions(db, []*model.CommentWithUserInfo{comment})
+	}
 	if err != nil {
 		http.Error(w, "Failed to retrieve comment", http.StatusInternalServerError)
 		return
@@ -138,9 +164,8 @@ func getComments(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 	}
 	postId := pathParts[3] // /posts/:id/comments
 
-	// Check if post exists
-	var postExists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM posts WHERE id = ?)", postId).Scan(&postExists)
+	// Posts the user cannot see are treated as gone
+	postExists, err := policy.New(db).CanViewPost(context.MustGetUser(r.Context()).ID, postId)
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
@@ -156,7 +181,7 @@ func getComments(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 			   u.fname, u.lname, u.nickname, u.imgurl
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = ? AND c.parent_id IS NULL
+		WHERE c.post_id = ? AND c.parent_id IS NULL AND c.hidden_at IS NULL
 		ORDER BY c.created_at ASC`,
 		postId)
 	if err != nil {
@@ -197,6 +222,11 @@ func getComments(w http.ResponseWriter, r *http.Request, db *sql.DB) {
 		comments = append(comments, comment)
 	}
 
+	if err := attachCommentMentions(db, commentPointers(comments)); err != nil {
+		http.Error(w, "Failed to retrieve comments", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":  true,
@@ -240,6 +270,32 @@ func buildRepliesTree(db *sql.DB, parentCommentId string) ([]model.CommentWithUs
 	return nestedReplies, nil
 }
 
+// commentPointers returns pointers to the comments and all their replies, to fill them in place
+func commentPointers(comments []model.CommentWithUserInfo) []*model.CommentWithUserInfo {
+	var pointers []*model.CommentWithUserInfo
+	for i := range comments {
+		pointers = append(pointers, &comments[i])
+		pointers = append(pointers, commentPointers(comments[i].Replies)...)
+	}
+	return pointers
+}
+
+// attachCommentMentions fills in the users mentioned in each comment
+func attachCommentMentions(db *sql.DB, comments []*model.CommentWithUserInfo) error {
+	ids := make([]string, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.Id
+	}
+	mentions, err := repository.FindCommentMentions(db, ids)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		comment.Mentions = mentions[comment.Id]
+	}
+	return nil
+}
+
 func validateComment(content string) error {
 	if len(strings.TrimSpace(content)) < MinCommentLength {
 		return fmt.Errorf("Comment must be at least %d character long", MinCommentLength)
@@ -288,7 +344,7 @@ func getRepliesForComment(db *sql.DB, commentId string) ([]model.Comment, error)
 			   u.fname, u.lname, u.nickname, u.imgurl
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.parent_id = ?
+		WHERE c.parent_id = ? AND c.hidden_at IS NULL
 		ORDER BY c.created_at ASC`,
 		commentId)
 	if err != nil {
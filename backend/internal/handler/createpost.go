@@ -3,11 +3,17 @@ package handler
 import (
 	"backend/internal/context"
 	"backend/internal/model"
+	"backend/internal/policy"
+	"backend/internal/repository"
+	"backend/internal/service"
 	"backend/internal/utils"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,6 +26,7 @@ type PostCreationErrors struct {
 	PostPrivacy      string `json:"privacyerror,omitempty"`
 	PostImage        string `json:"imageerror,omitempty"`
 	AllowedFollowers string `json:"followerserror,omitempty"`
+	Poll             string `json:"pollerror,omitempty"`
 }
 
 const (
@@ -27,6 +34,7 @@ const (
 	MaxTitleLength   = 77
 	MinContentLength = 21
 	MaxContentLength = 777
+	MaxAltTextLength = 1000
 	maxUploadSize    = 20 * 1024 * 1024 // 20MB
 )
 
@@ -51,6 +59,20 @@ func CreatePost(db *sql.DB) http.HandlerFunc {
 
 		// Check for group_id and update the post model
 		if groupID := r.FormValue("group_id"); groupID != "" {
+			id, err := strconv.ParseUint(groupID, 10, 32)
+			if err != nil {
+				http.Error(w, "Invalid group ID", http.StatusBadRequest)
+				return
+			}
+			allowed, err := policy.New(db).CanPostInGroup(currentUserID, uint(id))
+			if err != nil {
+				http.Error(w, "Failed to check group membership", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Only members of the group can post in it", http.StatusForbidden)
+				return
+			}
 			post.GroupId = sql.NullString{String: groupID, Valid: true}
 		}
 
@@ -58,6 +80,9 @@ func CreatePost(db *sql.DB) http.HandlerFunc {
 			post.Visibility = "public"
 		}
 
+		// a quote shares another post below its own title and content
+		post.QuoteOf = r.FormValue("quoteOf")
+
 		if allowedJSON := r.FormValue("allowedFollowers"); allowedJSON != "" {
 			err := json.Unmarshal([]byte(allowedJSON), &post.AllowedFollowers)
 			if err != nil {
@@ -66,43 +91,57 @@ func CreatePost(db *sql.DB) http.HandlerFunc {
 			}
 		}
 
-		//  handle image upload
-		imageUrl, err := utils.HandlePostImageUpload(r, maxUploadSize, "postImage")
+		// the poll is read before the uploads so that a bad one does not leave blobs behind
+		var err error
+		if post.Poll, _, err = readPollForm(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		//  handle image uploads, postImage may be repeated with an altText for each
+		images, err := utils.HandlePostImageUploads(r, maxUploadSize, "postImage", model.MaxPostMedia)
 		if err != nil {
 			postErrors.PostImage = err.Error()
 			http.Error(w, postErrors.PostImage, http.StatusBadRequest)
 			return
 		}
-		if imageUrl.Valid {
-			post.ImageUrl = imageUrl
+		post.Media = uploadedMedia(images, r.Form["altText"])
+		if len(post.Media) > 0 {
+			post.ImageUrl = sql.NullString{String: post.Media[0].URL, Valid: true}
+		}
+
+		posts := service.NewPostService(repository.NewPostRepository(db))
+		// uploads are only kept if the post is created
+		discardUploads := func() {
+			if err := posts.DiscardImages(mediaURLs(post.Media)...); err != nil {
+				log.Printf("Failed to remove unused uploads: %v", err)
+			}
 		}
 
 		postErrors, hasErrors := validatePost(post)
 		if hasErrors {
+			discardUploads()
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(postErrors)
 			return
 		}
 
-		_, postInsertErr := db.Exec(`
-			INSERT INTO posts (id, user_id, title, content, visibility, post_image, created_at, group_id)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			post.Id, post.UserId, post.Title, post.Content, post.Visibility, post.ImageUrl, post.CreatedAt, post.GroupId)
-		if postInsertErr != nil {
+		if post.QuoteOf != "" {
+			if err := service.NewRepostService(posts.Repo).Quote(currentUserID, &post); err != nil {
+				discardUploads()
+				respondRepostError(w, err)
+				return
+			}
+		}
+
+		if err := posts.Repo.Create(&post); err != nil {
+			discardUploads()
+			log.Printf("Failed to create post: %v", err)
 			http.Error(w, "Failed to create post", http.StatusInternalServerError)
 			return
 		}
-
-		if post.Visibility == "private" && len(post.AllowedFollowers) > 0 {
-			for _, followerID := range post.AllowedFollowers {
-				_, err := db.Exec(`
-					INSERT INTO private_posts (post_id, user_id, created_at)
-					VALUES (?, ?, ?)`, post.Id, followerID, post.CreatedAt)
-				if err != nil {
-					http.Error(w, "Failed to add private post access", http.StatusInternalServerError)
-					return
-				}
-			}
+		if err := newMentionService(db).NotifyPost(post.Id); err != nil {
+			log.Printf("Failed to notify mentions in post %s: %v", post.Id, err)
 		}
 
 		w.WriteHeader(http.StatusCreated)
@@ -113,18 +152,28 @@ func CreatePost(db *sql.DB) http.HandlerFunc {
 }
 
 func validatePost(post model.Post) (*PostCreationErrors, bool) {
+	return checkPost(post, true)
+}
+
+// validateDraft checks a draft like validatePost, except for what a post only needs once it is published:
+// the minimum title and content lengths, the followers of a private post and at least two poll options.
+func validateDraft(post model.Post) (*PostCreationErrors, bool) {
+	return checkPost(post, false)
+}
+
+func checkPost(post model.Post, complete bool) (*PostCreationErrors, bool) {
 	errors := &PostCreationErrors{}
 
 	if len(post.Title) > MaxTitleLength {
 		errors.Title = fmt.Sprintf("Title length too long. Keep it at %d max", MaxTitleLength)
 	}
-	if len(post.Title) < MinTitleLength {
+	if complete && len(post.Title) < MinTitleLength {
 		errors.Title = fmt.Sprintf("Title length too short. Keep it at least %d", MinTitleLength)
 	}
 	if len(post.Content) > MaxContentLength {
 		errors.Content = fmt.Sprintf("Content length too long. Keep it at %d max", MaxContentLength)
 	}
-	if len(post.Content) < MinContentLength {
+	if complete && len(post.Content) < MinContentLength {
 		errors.Content = fmt.Sprintf("Content length too short. Keep it at least %d", MinContentLength)
 	}
 
@@ -133,10 +182,23 @@ func validatePost(post model.Post) (*PostCreationErrors, bool) {
 		errors.PostPrivacy = "Invalid privacy value. Must be 'public', 'almost private', or 'private'"
 	}
 
-	if visibility == "private" && len(post.AllowedFollowers) == 0 {
+	if complete && visibility == "private" && len(post.AllowedFollowers) == 0 {
 		errors.AllowedFollowers = "Please select at least one follower for private posts"
 	}
 
+	if len(post.Media) > model.MaxPostMedia {
+		errors.PostImage = fmt.Sprintf("At most %d images are allowed", model.MaxPostMedia)
+	}
+	for _, m := range post.Media {
+		if len(m.AltText) > MaxAltTextLength {
+			errors.PostImage = fmt.Sprintf("Alt text too long. Keep it at %d max", MaxAltTextLength)
+		}
+	}
+
+	if post.Poll != nil {
+		errors.Poll = checkPoll(post.Poll, post.CreatedAt, complete)
+	}
+
 	hasErrors := errors.HasErrors()
 	return errors, hasErrors
 }
@@ -146,5 +208,101 @@ func (pe *PostCreationErrors) HasErrors() bool {
 		pe.Content != "" ||
 		pe.PostPrivacy != "" ||
 		pe.PostImage != "" ||
-		pe.AllowedFollowers != ""
+		pe.AllowedFollowers != "" ||
+		pe.Poll != ""
+}
+
+// checkPoll returns what is wrong with a poll published at publishedAt, or an empty string.
+func checkPoll(poll *model.Poll, publishedAt time.Time, complete bool) string {
+	options := len(poll.Options)
+	if options > model.MaxPollOptions || (complete && options < model.MinPollOptions) {
+		return fmt.Sprintf("A poll needs between %d and %d options", model.MinPollOptions, model.MaxPollOptions)
+	}
+	seen := map[string]bool{}
+	for _, option := range poll.Options {
+		text := strings.ToLower(option.Text)
+		if complete && text == "" {
+			return "Poll options cannot be empty"
+		}
+		if len(option.Text) > model.MaxPollOptionLength {
+			return fmt.Sprintf("Poll option too long. Keep it at %d max", model.MaxPollOptionLength)
+		}
+		if text != "" && seen[text] {
+			return "Poll options must be different"
+		}
+		seen[text] = true
+	}
+	if complete && poll.ClosesAt != nil && !poll.ClosesAt.After(publishedAt) {
+		return "The poll must close after the post is published"
+	}
+	return ""
+}
+
+// readPollForm returns the poll sent with a post: pollOptions is a JSON array of the options' text,
+// pollMultipleChoice=true lets users choose several, pollHideResults=true hides the results until users
+// vote and pollClosesAt is an optional closing time in RFC 3339 format. It also reports whether the form
+// has pollOptions at all, as an empty one removes the poll of a draft.
+func readPollForm(r *http.Request) (*model.Poll, bool, error) {
+	values, ok := r.Form["pollOptions"]
+	if !ok || values[0] == "" {
+		return nil, ok, nil
+	}
+	var texts []string
+	if err := json.Unmarshal([]byte(values[0]), &texts); err != nil {
+		return nil, true, errors.New("Invalid pollOptions format")
+	}
+	if len(texts) == 0 {
+		return nil, true, nil
+	}
+
+	poll := &model.Poll{
+		MultipleChoice: r.FormValue("pollMultipleChoice") == "true",
+		HideResults:    r.FormValue("pollHideResults") == "true",
+	}
+	if closesAt := r.FormValue("pollClosesAt"); closesAt != "" {
+		t, err := time.Parse(time.RFC3339, closesAt)
+		if err != nil {
+			return nil, true, errors.New("Invalid pollClosesAt, use the RFC 3339 format")
+		}
+		poll.ClosesAt = &t
+	}
+	for i, text := range texts {
+		poll.Options = append(poll.Options, model.PollOption{
+			ID:       uuid.New().String(),
+			Text:     strings.TrimSpace(text),
+			Position: i,
+		})
+	}
+	return poll, true, nil
+}
+
+// uploadedMedia turns saved uploads into a gallery, giving each image the alt text sent at the same position
+func uploadedMedia(images []utils.UploadedImage, altTexts []string) []model.PostMedia {
+	var media []model.PostMedia
+	for i, image := range images {
+		m := model.PostMedia{
+			ID:            uuid.New().String(),
+			URL:           image.URL,
+			ThumbnailURL:  image.ThumbnailURL,
+			FeedURL:       image.FeedURL,
+			Width:         image.Width,
+			Height:        image.Height,
+			Blurhash:      image.Blurhash,
+			DominantColor: image.DominantColor,
+			Position:      i,
+		}
+		if i < len(altTexts) {
+			m.AltText = strings.TrimSpace(altTexts[i])
+		}
+		media = append(media, m)
+	}
+	return media
+}
+
+func mediaURLs(media []model.PostMedia) []string {
+	var urls []string
+	for _, m := range media {
+		urls = append(urls, m.Files()...)
+	}
+	return urls
 }
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"backend/internal/context"
+)
+
+// GetCSRFToken handles GET /api/csrf and returns the token to send in the X-CSRF-Token header,
+// for clients that cannot read the csrf_token cookie themselves.
+func GetCSRFToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]string{"csrf_token": context.GetCSRFToken(r.Context())})
+}
@@ -2,18 +2,62 @@ package handler
 
 import (
 	"backend/internal/context"
+	"backend/internal/model"
 	"backend/internal/repository"
+	"backend/internal/service"
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+const (
+	DefaultFeedPageSize   = 20
+	MaxFeedPageSize       = 100
+	FeedModeChronological = "chronological"
+	FeedModeRanked        = "ranked"
+)
+
+// DashboardHandler handles GET /api/feeds?mode=&cursor=&limit= and returns a page of the home feed.
+// The response's next_cursor fetches the following page and is empty after the last one.
+//
+// mode=ranked orders the feed by score instead of date and adds posts from the user's groups.
+// Admins can add debug=true to see how each ranked post was scored.
 func DashboardHandler(db *sql.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		user := context.MustGetUser(r.Context())
 
-		posts, err := repository.GetPosts(user.ID, db)
+		limit, ok := feedPageSize(w, r)
+		if !ok {
+			return
+		}
+
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = FeedModeChronological
+		}
+
+		var posts []model.Post
+		var nextCursor string
+		var err error
+		switch mode {
+		case FeedModeChronological:
+			posts, nextCursor, err = chronologicalFeed(user.ID, db, r.URL.Query().Get("cursor"), limit)
+		case FeedModeRanked:
+			debug := r.URL.Query().Get("debug") == "true" && model.HasRole(user.Role, model.RoleAdmin)
+			posts, nextCursor, err = rankedFeed(user.ID, db, r.URL.Query().Get("cursor"), limit, debug)
+		default:
+			http.Error(w, "mode must be chronological or ranked", http.StatusBadRequest)
+			return
+		}
+		if err == repository.ErrInvalidFeedCursor || err == repository.ErrInvalidRankedCursor {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
+			log.Println("Error fetching feed:", err)
 			http.Error(w, "Failed to fetch posts", http.StatusInternalServerError)
 			return
 		}
@@ -22,7 +66,9 @@ func DashboardHandler(db *sql.DB) http.HandlerFunc {
 		response := map[string]interface{}{
 			"status": "success",
 			"data": map[string]interface{}{
-				"posts": posts,
+				"posts":       posts,
+				"next_cursor": nextCursor,
+				"mode":        mode,
 			},
 		}
 
@@ -30,3 +76,80 @@ func DashboardHandler(db *sql.DB) http.HandlerFunc {
 		json.NewEncoder(w).Encode(response)
 	}
 }
+
+// feedPageSize reads the limit parameter of a feed request, responding with an error if it is invalid
+func feedPageSize(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return DefaultFeedPageSize, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+		return 0, false
+	}
+	return min(n, MaxFeedPageSize), true
+}
+
+func chronologicalFeed(userID string, db *sql.DB, cursor string, limit int) ([]model.Post, string, error) {
+	var after *repository.FeedCursor
+	if cursor != "" {
+		parsed, err := repository.ParseFeedCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = parsed
+	}
+
+	// one extra post tells whether there is another page
+	posts, err := repository.GetPosts(userID, db, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(*posts) <= limit {
+		return *posts, "", nil
+	}
+	page := (*posts)[:limit]
+	last := page[limit-1]
+	return page, repository.FeedCursor{CreatedAt: last.CreatedAt, ID: last.Id}.String(), nil
+}
+
+func rankedFeed(userID string, db *sql.DB, cursor string, limit int, debug bool) ([]model.Post, string, error) {
+	var from *repository.RankedCursor
+	if cursor != "" {
+		parsed, err := repository.ParseRankedCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		from = parsed
+	}
+
+	posts, next, err := service.NewFeedRankingService(repository.NewFeedRankingRepository(db)).Ranked(userID, from, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if !debug {
+		for i := range posts {
+			posts[i].Rank = nil
+		}
+	}
+	if next == nil {
+		return posts, "", nil
+	}
+	return posts, next.String(), nil
+}
+
+// RunFeedRanking periodically rebuilds the post scores and author affinities behind the ranked feed.
+// It is meant to run in its own goroutine.
+func RunFeedRanking(db *sql.DB, interval time.Duration) {
+	ranking := service.NewFeedRankingService(repository.NewFeedRankingRepository(db))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ranking.Refresh(); err != nil {
+			log.Println("Failed to refresh feed scores:", err)
+		}
+		<-ticker.C
+	}
+}
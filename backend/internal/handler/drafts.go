@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// DraftHandler lets users keep posts as drafts, schedule them and publish them when they are done
+type DraftHandler struct {
+	Service  *service.DraftService
+	Mentions *service.MentionService // Notifies users mentioned in drafts once they are published
+}
+
+// Drafts handles /api/drafts.
+//
+// GET lists the user's drafts and scheduled posts, most recently changed first. POST takes the same multipart
+// form as CreatePost and saves it as a draft, which may be incomplete. A publishAt time in RFC 3339 format
+// schedules the post instead, so it has to be complete.
+func (h *DraftHandler) Drafts(w http.ResponseWriter, r *http.Request) {
+	userID := context.MustGetUser(r.Context()).ID
+	switch r.Method {
+	case http.MethodGet:
+		drafts, err := h.Service.Drafts(userID)
+		if err != nil {
+			respondDraftError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"drafts": drafts})
+	case http.MethodPost:
+		draft := &model.PostDraft{UserID: userID}
+		uploads, err := readDraftForm(r, draft)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		draft.Media = uploads
+		if !validDraft(w, draft) {
+			h.discardImages(uploads)
+			return
+		}
+		if err := h.Service.Create(draft); err != nil {
+			h.discardImages(uploads)
+			respondDraftError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{"success": true, "draft": draft})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Draft handles /api/drafts/:id and POST /api/drafts/:id/publish for the author of the draft.
+//
+// GET returns the draft and DELETE discards it. PUT changes it like PUT /api/posts/:id, also taking group_id,
+// quoteOf, the poll fields of CreatePost and publishAt. An empty publishAt turns a scheduled post back into a draft.
+// POST /api/drafts/:id/publish publishes the draft right away, if it is complete.
+func (h *DraftHandler) Draft(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/drafts/"), "/")
+	draftID, action, _ := strings.Cut(path, "/")
+	if draftID == "" || (action != "" && action != "publish") {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	draft, err := h.Service.Draft(user.ID, draftID)
+	if err != nil {
+		respondDraftError(w, err)
+		return
+	}
+
+	if action == "publish" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.publish(w, draft)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		utils.RespondWithJSON(w, http.StatusOK, draft)
+	case http.MethodPut:
+		h.update(w, r, draft)
+	case http.MethodDelete:
+		if err := h.Service.Delete(user.ID, draftID); err != nil {
+			respondDraftError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *DraftHandler) update(w http.ResponseWriter, r *http.Request, current *model.PostDraft) {
+	draft := *current
+	uploads, err := readDraftForm(r, &draft)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.FormValue("removeImage") == "true" {
+		draft.Media = nil
+	}
+	if len(uploads) > 0 {
+		draft.Media = uploads
+	} else if altTexts, ok := r.Form["altText"]; ok {
+		draft.Media = append([]model.PostMedia(nil), draft.Media...)
+		for i := range draft.Media {
+			if i < len(altTexts) {
+				draft.Media[i].AltText = strings.TrimSpace(altTexts[i])
+			}
+		}
+	}
+
+	if !validDraft(w, &draft) {
+		h.discardImages(uploads)
+		return
+	}
+	if err := h.Service.Update(current, &draft); err != nil {
+		h.discardImages(uploads)
+		respondDraftError(w, err)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true, "draft": draft})
+}
+
+func (h *DraftHandler) publish(w http.ResponseWriter, draft *model.PostDraft) {
+	post := draft.Post()
+	post.CreatedAt = time.Now()
+	if postErrors, hasErrors := validatePost(post); hasErrors {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(postErrors)
+		return
+	}
+	published, err := h.Service.Publish(draft)
+	if err != nil {
+		respondDraftError(w, err)
+		return
+	}
+	if err := h.Mentions.NotifyPost(published.Id); err != nil {
+		log.Printf("Failed to notify mentions in post %s: %v", published.Id, err)
+	}
+	if saved, err := h.Service.Posts.FindByID(published.Id); err == nil && saved != nil {
+		saved.Poll = published.Poll
+		published = saved
+	}
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{"success": true, "post": published})
+}
+
+func (h *DraftHandler) discardImages(media []model.PostMedia) {
+	if err := h.Service.DiscardImages(mediaURLs(media)...); err != nil {
+		log.Printf("Failed to remove unused uploads: %v", err)
+	}
+}
+
+// readDraftForm applies the fields sent in a draft form to draft, leaving the ones left out as they are,
+// and returns the images uploaded with it.
+func readDraftForm(r *http.Request, draft *model.PostDraft) ([]model.PostMedia, error) {
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil && err != http.ErrNotMultipart {
+		return nil, errors.New("Invalid form data")
+	}
+	if values, ok := r.Form["title"]; ok {
+		draft.Title = values[0]
+	}
+	if values, ok := r.Form["content"]; ok {
+		draft.Content = values[0]
+	}
+	if values, ok := r.Form["postPrivacy"]; ok {
+		draft.Visibility = values[0]
+	}
+	if draft.Visibility == "" {
+		draft.Visibility = "public"
+	}
+	if values, ok := r.Form["group_id"]; ok {
+		if values[0] != "" {
+			if _, err := strconv.ParseUint(values[0], 10, 32); err != nil {
+				return nil, errors.New("Invalid group ID")
+			}
+		}
+		draft.GroupID = values[0]
+	}
+	if values, ok := r.Form["quoteOf"]; ok {
+		draft.QuoteOf = values[0]
+	}
+	if values, ok := r.Form["allowedFollowers"]; ok {
+		draft.AllowedFollowers = nil
+		if values[0] != "" {
+			if err := json.Unmarshal([]byte(values[0]), &draft.AllowedFollowers); err != nil {
+				return nil, errors.New("Invalid allowedFollowers format")
+			}
+		}
+	}
+	if poll, ok, err := readPollForm(r); err != nil {
+		return nil, err
+	} else if ok {
+		draft.Poll = poll
+	}
+	if values, ok := r.Form["publishAt"]; ok {
+		draft.PublishAt = nil
+		if values[0] != "" {
+			publishAt, err := time.Parse(time.RFC3339, values[0])
+			if err != nil {
+				return nil, errors.New("Invalid publishAt, use the RFC 3339 format")
+			}
+			draft.PublishAt = &publishAt
+		}
+	}
+
+	images, err := utils.HandlePostImageUploads(r, maxUploadSize, "postImage", model.MaxPostMedia)
+	if err != nil {
+		return nil, err
+	}
+	return uploadedMedia(images, r.Form["altText"]), nil
+}
+
+// validDraft checks a draft like a post if it is scheduled, or with validateDraft otherwise, and writes the
+// errors found. It reports whether there were none.
+func validDraft(w http.ResponseWriter, draft *model.PostDraft) bool {
+	post := draft.Post()
+	validate := validateDraft
+	if draft.PublishAt != nil {
+		validate = validatePost
+		post.CreatedAt = *draft.PublishAt
+	}
+	postErrors, hasErrors := validate(post)
+	if hasErrors {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(postErrors)
+	}
+	return !hasErrors
+}
+
+func respondDraftError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrDraftNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrNotGroupMember:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case service.ErrDraftChanged:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case service.ErrPublishAtPassed, service.ErrQuoteUnavailable, service.ErrReshareTooWide, service.ErrPollClosed:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Draft request failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// RunScheduledPosts periodically publishes the scheduled posts that are due, including the ones that came due
+// while the server was down. It is meant to run in its own goroutine.
+func RunScheduledPosts(db *sql.DB, interval time.Duration) {
+	drafts := service.NewDraftService(repository.NewDraftRepository(db))
+	mentions := newMentionService(db)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		posts, err := drafts.PublishDue()
+		if err != nil {
+			log.Println("Failed to publish scheduled posts:", err)
+		}
+		for _, post := range posts {
+			if err := mentions.NotifyPost(post.Id); err != nil {
+				log.Printf("Failed to notify mentions in post %s: %v", post.Id, err)
+			}
+		}
+		<-ticker.C
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"backend/internal/context"
 	"backend/internal/model"
+	"backend/internal/policy"
 	"backend/internal/service"
 	"backend/internal/utils"
 )
@@ -24,6 +25,8 @@ type CreateGroupRequest struct {
 // GroupHandler holds the business logic service for groups.
 type GroupHandler struct {
 	Service *service.GroupService
+	Audit   *service.AuditService
+	Policy  *policy.Policy
 }
 
 func (h *GroupHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
@@ -32,7 +35,7 @@ func (h *GroupHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	groups, err := h.Service.GetAllGroups()
+	groups, err := h.Service.GetVisibleGroups(context.MustGetUser(r.Context()).ID)
 	if err != nil {
 		log.Printf("Failed to retrieve groups: %v", err)
 		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to retrieve groups")
@@ -82,6 +85,9 @@ func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordAudit(h.Audit, auditEvent(r, creatorID, model.AuditGroupMemberAdded, model.AuditTargetGroup, fmt.Sprint(newGroup.ID)),
+		nil, map[string]any{"user_id": creatorID, "role": "admin", "status": "active"})
+
 	// Respond with Success
 	utils.RespondWithJSON(w, http.StatusCreated, newGroup)
 }
@@ -109,6 +115,18 @@ func (h *GroupHandler) JoinGroupRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// secret groups are not found by users who cannot see them
+	visible, err := h.Policy.CanViewGroup(userID, groupID)
+	if err != nil {
+		log.Printf("Failed to check group access: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create join request")
+		return
+	}
+	if !visible {
+		utils.RespondWithError(w, http.StatusNotFound, "Group not found")
+		return
+	}
+
 	// Call service to create join request
 	err = h.Service.RequestToJoinGroup(groupID, userID)
 	if err != nil {
@@ -167,6 +185,9 @@ func (h *GroupHandler) AcceptJoinRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	recordAudit(h.Audit, auditEvent(r, creatorUserID, model.AuditGroupMemberAdded, model.AuditTargetGroup, fmt.Sprint(groupID)),
+		map[string]any{"status": "pending"}, map[string]any{"user_id": req.UserID, "role": "member", "status": "active"})
+
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
 		"message": "Join request accepted successfully",
 	})
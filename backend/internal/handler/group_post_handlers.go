@@ -1,12 +1,15 @@
 package handler
 
 import (
+	"backend/internal/context"
 	"backend/internal/model"
-	"backend/pkg/getusers"
+	"backend/internal/policy"
+	"backend/internal/repository"
 	"database/sql"
 	"encoding/json"
-	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -19,8 +22,11 @@ func GetGroup(db *sql.DB) http.HandlerFunc {
 			return
 		}
 		groupID := pathParts[3]
+		if !canViewGroup(w, r, db, groupID) {
+			return
+		}
 
-		row := db.QueryRow("SELECT id, title, description FROM groups WHERE id = ?", groupID)
+		row := db.QueryRow("SELECT id, title, description FROM groups WHERE id = ? AND hidden_at IS NULL", groupID)
 
 		var group model.Group
 		err := row.Scan(&group.ID, &group.Title, &group.Description)
@@ -47,44 +53,38 @@ func GetGroupPosts(db *sql.DB) http.HandlerFunc {
 			return
 		}
 		groupID := pathParts[3]
+		if !canViewGroup(w, r, db, groupID) {
+			return
+		}
 
-		rows, err := db.Query(`
-			SELECT
-				p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at,
-				(
-					SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL
-				) AS comment_count
-			FROM posts p
-			WHERE p.group_id = ?
-			ORDER BY p.created_at DESC`, groupID)
+		posts, err := repository.GetGroupPosts(groupID, context.MustGetUser(r.Context()).ID, db)
 		if err != nil {
+			log.Println("Error fetching group posts:", err)
 			http.Error(w, "Failed to fetch group posts", http.StatusInternalServerError)
 			return
 		}
-		defer rows.Close()
-
-		var posts []model.Post
-		for rows.Next() {
-			var post model.Post
-			if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &post.CommentCount); err != nil {
-				http.Error(w, "Failed to scan post", http.StatusInternalServerError)
-				return
-			}
-
-			// Populate Creator and CreatorImg
-			user, err := getusers.GetUserByID(db, post.UserId)
-			if err != nil {
-				// Handle error, e.g., log it or skip this post
-				fmt.Println("User not found for post:", post.Id, err)
-				continue // Skip this post if user not found
-			}
-			post.Creator = user.FirstName + " " + user.LastName
-			post.CreatorImg = user.ImgURL
-
-			posts = append(posts, post)
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(posts)
 	}
 }
+
+// canViewGroup answers with 404 and returns false unless the current user may see the group
+func canViewGroup(w http.ResponseWriter, r *http.Request, db *sql.DB, groupID string) bool {
+	id, err := strconv.ParseUint(groupID, 10, 32)
+	if err != nil {
+		http.NotFound(w, r)
+		return false
+	}
+	visible, err := policy.New(db).CanViewGroup(context.MustGetUser(r.Context()).ID, uint(id))
+	if err != nil {
+		log.Println("Error checking group access:", err)
+		http.Error(w, "Failed to fetch group", http.StatusInternalServerError)
+		return false
+	}
+	if !visible {
+		http.NotFound(w, r)
+		return false
+	}
+	return true
+}
@@ -2,11 +2,18 @@ package handler
 
 import (
 	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
 	"backend/pkg/db/sqlite"
 	"backend/pkg/getusers"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +22,11 @@ import (
 
 var eat = time.FixedZone("EAT", 3*60*60) // East Africa Time (UTC+3)
 
+const invalidCredentialsMessage = "Invalid email or password"
+
+// dummyPasswordHash is a cost 14 bcrypt hash of a random string, matching the cost of utils.HashPassword
+const dummyPasswordHash = "$2a$14$SxYsKOn/yJQjOj.QFPCsZe1e21QPNt3hIGpmg1dA9R2uvcNWsoT3S"
+
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -26,6 +38,7 @@ type LoginErrs struct {
 }
 
 // LoginHandler handles user login requests, checks credentials, and sets a session cookie.
+// For users with 2FA enabled it returns a challenge token instead, completed through LoginTwoFactorHandler.
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed. Use POST for login.", http.StatusMethodNotAllowed)
@@ -48,67 +61,125 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer db.Close()
 	email := strings.ToLower(req.Email)
+	ipAddress := utils.ClientIP(r)
 
-	// check if email is in db
-	var count int
-	emailQuerryErr := db.QueryRow(`SELECT COUNT(*) FROM users WHERE email = ?`, email).Scan(&count)
-	if emailQuerryErr != nil {
-		log.Printf("Error querying database while checking if email exists: %v\n", emailQuerryErr)
+	// throttle repeated failures per account and per IP before doing any password work
+	attempts := service.NewLoginAttemptService(repository.NewLoginAttemptRepository(db))
+	attempts.Audit = service.NewAuditService(repository.NewAuditRepository(db))
+	retryAfter, err := attempts.RetryAfter(email, ipAddress)
+	if err != nil {
+		log.Println("Error checking login attempts:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
-	// if not, email not found err
-	if count < 1 {
-		errs.Email = "We did not find an account with that email"
+
+	user, getUserErr := getusers.GetUserByEmail(db, email)
+	if getUserErr != nil && getUserErr != sql.ErrNoRows {
+		log.Println("An error occured while getting user data ByEmail", getUserErr)
 	}
+	userFound := getUserErr == nil
 
-	var user model.User
-	if count > 0 {
-		var getUserErr error
-		user, getUserErr = getusers.GetUserByEmail(db, email)
-		if getUserErr != nil {
-			log.Println("An error occured while getting user data ByEmailOrNickname", getUserErr)
+	if retryAfter > 0 {
+		if err := attempts.RecordFailure(email, user.ID, ipAddress, r.UserAgent(), service.LoginFailureLocked); err != nil {
+			log.Println("Error recording login attempt:", err)
 		}
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		errs.Password = fmt.Sprintf("Too many failed login attempts. Try again in %d seconds", seconds)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(errs)
+		return
 	}
 
-	compareHashErr := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
-	// if password don't match, incorrect password err
-	if compareHashErr != nil {
-		errs.Password = "Incorrect password. Try again"
+	// compare against a dummy hash for unknown emails so response time does not reveal which emails exist
+	hash := dummyPasswordHash
+	if userFound {
+		hash = user.Password
 	}
+	compareHashErr := bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password))
 
-	if count < 1 || compareHashErr != nil {
+	if !userFound || compareHashErr != nil {
+		if err := attempts.RecordFailure(email, user.ID, ipAddress, r.UserAgent(), service.LoginFailureInvalidCredentials); err != nil {
+			log.Println("Error recording login attempt:", err)
+		}
+		// the same message for unknown emails and wrong passwords prevents account enumeration
+		errs.Password = invalidCredentialsMessage
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(errs)
 		return
-	} else {
-		expiresAt := time.Now().In(eat).Add(24 * time.Hour)
-		sessionID, err := sqlite.InsertSession(db, user.ID, expiresAt)
+	}
+
+	// with 2FA on, hand out a challenge token instead of a session until a valid code is submitted
+	twoFactor := service.NewTwoFactorService(repository.NewTwoFactorRepository(db))
+	enabled, err := twoFactor.IsEnabled(user.ID)
+	if err != nil {
+		log.Println("Error checking two-factor status:", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if enabled {
+		token, expiresAt, err := twoFactor.CreateLoginChallenge(user.ID)
 		if err != nil {
-			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			log.Println("Error creating login challenge:", err)
+			http.Error(w, "Failed to start two-factor login", http.StatusInternalServerError)
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "social-network",
-			Value:    sessionID,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   true,
-			SameSite: http.SameSiteStrictMode,
-			Expires:  expiresAt,
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"challenge_token":     token,
+			"expires_at":          expiresAt,
 		})
+		return
+	}
 
-		response := map[string]interface{}{
-			"message": "Login successful",
-			"user": map[string]interface{}{
-				"id":        user.ID,
-				"email":     user.Email,
-				"firstName": user.FirstName,
-				"lastName":  user.LastName,
-			},
-		}
+	if err := startSession(w, r, db, user, "password"); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	if err := attempts.RecordSuccess(email, user.ID, ipAddress, r.UserAgent()); err != nil {
+		log.Println("Error recording login attempt:", err)
+	}
+}
 
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(response)
+// startSession creates a session for a fully authenticated user, sets the session cookie and writes the login response.
+func startSession(w http.ResponseWriter, r *http.Request, db *sql.DB, user model.User, method string) error {
+	if err := createSession(w, r, db, user.ID, method); err != nil {
+		return err
 	}
+
+	response := map[string]interface{}{
+		"message": "Login successful",
+		"user": map[string]interface{}{
+			"id":        user.ID,
+			"email":     user.Email,
+			"firstName": user.FirstName,
+			"lastName":  user.LastName,
+		},
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+	return nil
+}
+
+// createSession inserts a new session for the user, records the login and sets the session cookie.
+// method names how the user signed in, such as "password" or "oidc:google".
+func createSession(w http.ResponseWriter, r *http.Request, db *sql.DB, userID, method string) error {
+	expiresAt := time.Now().In(eat).Add(sqlite.SessionLifetime)
+	sessionID, err := sqlite.InsertSession(db, userID, expiresAt, r.UserAgent(), utils.ClientIP(r))
+	if err != nil {
+		return err
+	}
+	recordAudit(service.NewAuditService(repository.NewAuditRepository(db)),
+		auditEvent(r, userID, model.AuditLogin, model.AuditTargetSession, sessionHandle(sessionID)),
+		nil, map[string]any{"method": method, "expires_at": expiresAt})
+
+	utils.SetSessionCookie(w, sessionID, expiresAt)
+	return nil
 }
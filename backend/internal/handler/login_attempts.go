@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// GetFailedLoginAttempts handles GET /api/login-attempts and lets the account owner review failed logins.
+func GetFailedLoginAttempts(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		currentUserID := context.MustGetUser(r.Context()).ID
+
+		attempts := service.NewLoginAttemptService(repository.NewLoginAttemptRepository(db))
+		failed, err := attempts.FailedAttempts(currentUserID)
+		if err != nil {
+			log.Printf("Error getting failed login attempts: %v", err)
+			http.Error(w, "Failed to get login attempts", http.StatusInternalServerError)
+			return
+		}
+
+		if failed == nil {
+			failed = []model.LoginAttempt{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(failed)
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/policy"
+	"backend/pkg/blobstore"
+)
+
+// Cache-Control of uploaded files. Keys are content addressed, so a file never changes under its URL, but
+// who may see it does: a public post can be made private, hidden by a moderator or deleted, and an avatar
+// replaced. Shared caches revalidate public files on every use, see blobstore.RevalidateCacheControl, and
+// access to private files is only remembered briefly by the viewer's browser.
+const (
+	PublicMediaCacheControl  = blobstore.RevalidateCacheControl
+	PrivateMediaCacheControl = "private, max-age=300"
+)
+
+// MediaHandler serves uploaded files to the users who may see the profiles or posts they belong to
+type MediaHandler struct {
+	Uploads *blobstore.Server
+	Policy  *policy.Policy
+}
+
+// Serve handles GET and HEAD requests under the uploads path. It must be wrapped by OptionalAuth.
+// Files the viewer may not see get a 404, the same as files that do not exist. Signed URLs and
+// private blobs are checked by their signature instead, see blobstore.Server.
+func (h *MediaHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	key, ok := h.Uploads.KeyOf(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.URL.Query().Has("signature") || strings.HasPrefix(key, blobstore.PrivatePrefix) {
+		h.Uploads.ServeHTTP(w, r)
+		return
+	}
+
+	viewerID := ""
+	if user := context.GetUser(r.Context()); user != nil && context.HasScope(r.Context(), model.ScopeReadFeed) {
+		viewerID = user.ID
+	}
+	visible, public, err := h.Policy.CanViewMedia(viewerID, h.Uploads.URL(key))
+	if err != nil {
+		log.Printf("Failed to check access to %s: %v", key, err)
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		w.Header().Set("Cache-Control", "no-store")
+		http.NotFound(w, r)
+		return
+	}
+
+	if public {
+		h.Uploads.ServeKey(w, r, key, PublicMediaCacheControl)
+		return
+	}
+	w.Header().Set("Vary", "Cookie, Authorization")
+	h.Uploads.ServeKey(w, r, key, PrivateMediaCacheControl)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"backend/internal/context"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// MentionHandler suggests users while an @mention is typed
+type MentionHandler struct {
+	Service *service.MentionService
+}
+
+// Autocomplete handles GET /api/mentions/autocomplete?q=&limit= and returns users whose nickname starts
+// with q, people the user follows first.
+func (h *MentionHandler) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	users, err := h.Service.Suggest(context.MustGetUser(r.Context()).ID, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		log.Println("Error suggesting mentions:", err)
+		http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"users": users})
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// NotificationHandler lists a user's notifications and marks them read
+type NotificationHandler struct {
+	Service *service.NotificationService
+}
+
+// List handles GET /api/notifications?limit= and returns the user's latest notifications with the number unread.
+// New notifications are also pushed over the WebSocket as {"type": "notification", "data": ...}.
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	notifications, unread, err := h.Service.List(context.MustGetUser(r.Context()).ID, limit)
+	if err != nil {
+		log.Println("Error fetching notifications:", err)
+		http.Error(w, "Failed to fetch notifications", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"notifications": notifications,
+		"unread":        unread,
+	})
+}
+
+// Read handles POST /api/notifications/read with {"ids": [...]} and marks those notifications read,
+// or all of them when ids is left out.
+func (h *NotificationHandler) Read(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	marked, err := h.Service.MarkRead(context.MustGetUser(r.Context()).ID, req.IDs)
+	if err != nil {
+		log.Println("Error marking notifications read:", err)
+		http.Error(w, "Failed to update notifications", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true, "marked": marked})
+}
+
+// PushNotification sends a new notification to its user if they are connected to the WebSocket.
+func PushNotification(n model.Notification) {
+	if _, err := sendTo(n.UserID, Envelope{Type: "notification", Data: n}); err != nil {
+		log.Println("Error sending notification to", n.UserID+":", err)
+	}
+}
+
+// newMentionService returns a MentionService whose notifications are pushed over the WebSocket
+func newMentionService(db *sql.DB) *service.MentionService {
+	notifications := service.NewNotificationService(repository.NewNotificationRepository(db))
+	notifications.Push = PushNotification
+	return service.NewMentionService(repository.NewMentionRepository(db), notifications)
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/getusers"
+)
+
+// oidcStateCookieName binds an authorization request to the browser that started it, so a
+// callback URL cannot be used to log someone else's browser into the attacker's account.
+const oidcStateCookieName = "oidc_state"
+
+// OIDCSignupRequest carries a pending signup token and, when completing it, the fields the
+// provider did not supply. Field names match the registration form.
+type OIDCSignupRequest struct {
+	SignupToken       string `json:"signup_token"`
+	FirstName         string `json:"firstName"`
+	LastName          string `json:"lastName"`
+	DateOfBirth       string `json:"dateOfBirth"`
+	Nickname          string `json:"nickname"`
+	AboutMe           string `json:"aboutMe"`
+	ProfileVisibility string `json:"profileVisibility"`
+}
+
+// OIDCHandler handles "Sign in with provider" logins
+type OIDCHandler struct {
+	Service     *service.OIDCService
+	DB          *sql.DB
+	FrontendURL string // Where the browser is sent once the provider redirects back
+}
+
+// Route dispatches the /api/auth/oidc/ endpoints:
+//
+//	GET  /api/auth/oidc/providers          configured provider names
+//	GET  /api/auth/oidc/:provider/start    redirects to the provider
+//	GET  /api/auth/oidc/:provider/callback the provider redirects back here
+//	POST /api/auth/oidc/signup             details of a pending signup
+//	POST /api/auth/oidc/signup/complete    creates the account for a pending signup
+func (h *OIDCHandler) Route(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/auth/oidc/"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "providers":
+		h.providers(w, r)
+	case path == "signup":
+		h.signup(w, r)
+	case path == "signup/complete":
+		h.completeSignup(w, r)
+	case len(parts) == 2 && parts[1] == "start":
+		h.start(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "callback":
+		h.callback(w, r, parts[0])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *OIDCHandler) providers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string][]string{"providers": h.Service.ProviderNames()})
+}
+
+func (h *OIDCHandler) start(w http.ResponseWriter, r *http.Request, provider string) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	authURL, state, err := h.Service.BeginLogin(provider)
+	if err != nil {
+		if err == service.ErrUnknownOIDCProvider {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("Failed to start %s login: %v", provider, err)
+		utils.RespondWithError(w, http.StatusBadGateway, "Failed to contact the sign-in provider")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     "/api/auth/oidc/",
+		MaxAge:   int(service.OIDCLoginStateTTL / time.Second),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode, // must survive the top level redirect back from the provider
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h *OIDCHandler) callback(w http.ResponseWriter, r *http.Request, provider string) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookieName, Path: "/api/auth/oidc/", MaxAge: -1})
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		h.redirectToFrontend(w, r, "/login", url.Values{"error": {providerErr}})
+		return
+	}
+
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil || state == "" || cookie.Value != state {
+		h.redirectToFrontend(w, r, "/login", url.Values{"error": {service.ErrOIDCStateInvalid.Error()}})
+		return
+	}
+
+	result, err := h.Service.CompleteLogin(r.Context(), provider, state, query.Get("code"))
+	if err != nil {
+		switch err {
+		case service.ErrUnknownOIDCProvider, service.ErrOIDCStateInvalid, service.ErrOIDCEmailNotVerified:
+			h.redirectToFrontend(w, r, "/login", url.Values{"error": {err.Error()}})
+		default:
+			// covers rejected tokens and failed code exchanges, details only go to the log
+			log.Printf("Failed to complete %s login: %v", provider, err)
+			h.redirectToFrontend(w, r, "/login", url.Values{"error": {"Sign-in failed, please try again"}})
+		}
+		return
+	}
+
+	if result.Signup != nil {
+		h.redirectToFrontend(w, r, "/register", url.Values{"signup_token": {result.SignupToken}})
+		return
+	}
+
+	// the provider stands in for the password, accounts with 2FA still need their second factor
+	twoFactor := service.NewTwoFactorService(repository.NewTwoFactorRepository(h.DB))
+	enabled, err := twoFactor.IsEnabled(result.UserID)
+	if err != nil {
+		log.Println("Error checking two-factor status:", err)
+		h.redirectToFrontend(w, r, "/login", url.Values{"error": {"Sign-in failed, please try again"}})
+		return
+	}
+	if enabled {
+		token, _, err := twoFactor.CreateLoginChallenge(result.UserID)
+		if err != nil {
+			log.Println("Error creating login challenge:", err)
+			h.redirectToFrontend(w, r, "/login", url.Values{"error": {"Sign-in failed, please try again"}})
+			return
+		}
+		h.redirectToFrontend(w, r, "/login", url.Values{"challenge_token": {token}})
+		return
+	}
+
+	if err := createSession(w, r, h.DB, result.UserID, "oidc:"+provider); err != nil {
+		log.Println("Error creating session:", err)
+		h.redirectToFrontend(w, r, "/login", url.Values{"error": {"Failed to create session"}})
+		return
+	}
+	h.redirectToFrontend(w, r, "/", nil)
+}
+
+// redirectToFrontend sends the browser to a frontend page. Parameters go in the fragment so
+// tokens never reach server logs or Referer headers.
+func (h *OIDCHandler) redirectToFrontend(w http.ResponseWriter, r *http.Request, page string, params url.Values) {
+	target := strings.TrimSuffix(h.FrontendURL, "/") + page
+	if len(params) > 0 {
+		target += "#" + params.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (h *OIDCHandler) signup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req OIDCSignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	signup, err := h.Service.PendingSignup(req.SignupToken)
+	if err != nil {
+		if err == service.ErrOIDCSignupNotFound {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("Failed to get pending signup: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get sign-up details")
+		return
+	}
+
+	missing := []string{"dateOfBirth"}
+	if signup.FirstName == "" {
+		missing = append(missing, "firstName")
+	}
+	if signup.LastName == "" {
+		missing = append(missing, "lastName")
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"signup":         signup,
+		"missing_fields": missing,
+		"min_age":        service.MinAge,
+	})
+}
+
+func (h *OIDCHandler) completeSignup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req OIDCSignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	profile := &model.User{
+		FirstName:         req.FirstName,
+		LastName:          req.LastName,
+		Nickname:          req.Nickname,
+		About:             req.AboutMe,
+		ProfileVisibility: req.ProfileVisibility,
+		CreatedAt:         time.Now(),
+	}
+	if req.DateOfBirth != "" {
+		dob, err := time.Parse("2006-01-02", req.DateOfBirth)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid date format. Use YYYY-MM-DD.")
+			return
+		}
+		profile.DOB = dob
+	}
+
+	validationErrors, err := h.Service.CompleteSignup(req.SignupToken, profile)
+	if validationErrors != nil {
+		// same shape as the registration endpoint's validation errors
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(validationErrors)
+		return
+	}
+	if err != nil {
+		if err == service.ErrOIDCSignupNotFound {
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Printf("Failed to complete signup: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Registration failed")
+		return
+	}
+
+	user, err := getusers.GetUserByID(h.DB, profile.ID)
+	if err != nil {
+		log.Printf("Failed to get new user: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := startSession(w, r, h.DB, user, "oidc_signup"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create session")
+	}
+}
+
+// Identities handles GET /api/identities and lists the providers linked to the current user.
+func (h *OIDCHandler) Identities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	identities, err := h.Service.Identities(user.ID)
+	if err != nil {
+		log.Printf("Failed to list identities: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to list linked accounts")
+		return
+	}
+	if identities == nil {
+		identities = []model.UserIdentity{}
+	}
+	utils.RespondWithJSON(w, http.StatusOK, identities)
+}
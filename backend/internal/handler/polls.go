@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// PollHandler lets users vote in the polls of posts they can see. Polls are created with CreatePost.
+type PollHandler struct {
+	Service *service.PollService
+}
+
+// Vote handles POST and DELETE /api/posts/:id/vote.
+//
+// POST takes {"option_ids": ["..."]}, one option unless the poll is multiple choice. DELETE?option= takes back
+// the vote for that option, or the user's whole vote without it. Both return the poll with its new results,
+// which are also pushed over the WebSocket to the other users who can see them, see HandlePollResults.
+func (h *PollHandler) Vote(w http.ResponseWriter, r *http.Request) {
+	postID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/posts/"), "/vote")
+	if postID == "" || strings.Contains(postID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID := context.MustGetUser(r.Context()).ID
+	var err error
+	var status int
+	var poll *model.Poll
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			OptionIDs []string `json:"option_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		poll, err = h.Service.Vote(userID, postID, req.OptionIDs)
+		status = http.StatusCreated
+	case http.MethodDelete:
+		poll, err = h.Service.Unvote(userID, postID, r.URL.Query().Get("option"))
+		status = http.StatusOK
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		respondPollError(w, err)
+		return
+	}
+	utils.RespondWithJSON(w, status, map[string]interface{}{"success": true, "poll": poll})
+	PushPollResults(postID)
+}
+
+func respondPollError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrPostNotFound, service.ErrPollNotFound, service.ErrNotVoted:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrAlreadyVoted, service.ErrPollClosed:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case service.ErrInvalidVote:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Poll request failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// pollUpdates holds the posts whose poll results changed until HandlePollResults pushes them
+var pollUpdates = make(chan string, 256)
+
+// PushPollResults has the new results of a post's poll pushed over the WebSocket, without waiting for it.
+func PushPollResults(postID string) {
+	select {
+	case pollUpdates <- postID:
+	default:
+		log.Println("Too many poll updates pending, not pushing the results of post", postID)
+	}
+}
+
+// HandlePollResults sends the polls whose votes changed to every online user who can see their results, as
+// each of them would get them in their feed. Several votes in the same poll while it is busy are pushed once.
+// It is meant to run in its own goroutine.
+func HandlePollResults(db *sql.DB) {
+	polls := service.NewPollService(repository.NewPollRepository(db))
+	for postID := range pollUpdates {
+		pending := map[string]bool{postID: true}
+		for drained := false; !drained; {
+			select {
+			case postID := <-pollUpdates:
+				pending[postID] = true
+			default:
+				drained = true
+			}
+		}
+		for postID := range pending {
+			pushPollResults(polls, postID)
+		}
+	}
+}
+
+func pushPollResults(polls *service.PollService, postID string) {
+	for _, userID := range onlineUsers() {
+		poll, err := polls.Poll(userID, postID)
+		if err == service.ErrPostNotFound || err == service.ErrPollNotFound {
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to read poll of post %s for %s: %v", postID, userID, err)
+			continue
+		}
+		if poll.ResultsHidden {
+			continue
+		}
+		if _, err := sendTo(userID, Envelope{Type: "poll", Data: poll}); err != nil {
+			log.Println("Error sending poll results to", userID+":", err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// PostHandler lets authors and group admins edit and delete posts
+type PostHandler struct {
+	Service  *service.PostService
+	Mentions *service.MentionService // Notifies users newly mentioned by an edit
+}
+
+// Post handles PUT and DELETE /api/posts/:id.
+//
+// PUT takes the same multipart form as CreatePost, except quoteOf. Fields left out keep their current value,
+// removeImage=true drops every image and new postImage files replace the gallery.
+// altText fields sent without new images relabel the current images in order.
+func (h *PostHandler) Post(w http.ResponseWriter, r *http.Request) {
+	postID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/posts/"), "/")
+	if postID == "" || strings.Contains(postID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	switch r.Method {
+	case http.MethodPut:
+		h.update(w, r, user, postID)
+	case http.MethodDelete:
+		if err := h.Service.Delete(user, postID); err != nil {
+			respondPostError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PostHandler) update(w http.ResponseWriter, r *http.Request, user *model.User, postID string) {
+	current, err := h.Service.Manageable(user, postID)
+	if err != nil {
+		respondPostError(w, err)
+		return
+	}
+	if current.RepostOf != "" {
+		http.Error(w, "Reposts have nothing to edit, delete the repost instead", http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil && err != http.ErrNotMultipart {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	post := *current
+	post.AllowedFollowers = slices.Clone(current.AllowedFollowers)
+	post.Media = slices.Clone(current.Media)
+	if values, ok := r.Form["title"]; ok {
+		post.Title = values[0]
+	}
+	if values, ok := r.Form["content"]; ok {
+		post.Content = values[0]
+	}
+	if values, ok := r.Form["postPrivacy"]; ok {
+		post.Visibility = values[0]
+	}
+	if values, ok := r.Form["allowedFollowers"]; ok {
+		post.AllowedFollowers = nil
+		if values[0] != "" {
+			if err := json.Unmarshal([]byte(values[0]), &post.AllowedFollowers); err != nil {
+				http.Error(w, "Invalid allowedFollowers format", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if r.FormValue("removeImage") == "true" {
+		post.Media = nil
+	}
+
+	images, err := utils.HandlePostImageUploads(r, maxUploadSize, "postImage", model.MaxPostMedia)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	uploads := uploadedMedia(images, r.Form["altText"])
+	if len(uploads) > 0 {
+		post.Media = uploads
+	} else if altTexts, ok := r.Form["altText"]; ok {
+		for i := range post.Media {
+			if i < len(altTexts) {
+				post.Media[i].AltText = strings.TrimSpace(altTexts[i])
+			}
+		}
+	}
+	post.ImageUrl = sql.NullString{}
+	if len(post.Media) > 0 {
+		post.ImageUrl = sql.NullString{String: post.Media[0].URL, Valid: true}
+	}
+	// new uploads are only kept if the edit goes through
+	discardUpload := func() {
+		if err := h.Service.DiscardImages(mediaURLs(uploads)...); err != nil {
+			log.Printf("Failed to remove unused uploads: %v", err)
+		}
+	}
+
+	postErrors, hasErrors := validatePost(post)
+	if hasErrors {
+		discardUpload()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(postErrors)
+		return
+	}
+
+	if err := h.Service.Update(user.ID, current, &post); err != nil {
+		discardUpload()
+		if err == service.ErrReshareTooWide {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to update post %s: %v", postID, err)
+		http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Mentions.NotifyPost(postID); err != nil {
+		log.Printf("Failed to notify mentions in post %s: %v", postID, err)
+	}
+	if updated, err := h.Service.Repo.FindByID(postID); err == nil && updated != nil {
+		post.Mentions = updated.Mentions
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"post":    post,
+	})
+}
+
+// Edits handles GET /api/posts/:id/edits and lists the earlier versions of a post to the users who may change it.
+func (h *PostHandler) Edits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	postID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/posts/"), "/edits")
+	if postID == "" || strings.Contains(postID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	edits, err := h.Service.Edits(context.MustGetUser(r.Context()), postID)
+	if err != nil {
+		respondPostError(w, err)
+		return
+	}
+	if edits == nil {
+		edits = []model.PostEdit{}
+	}
+	utils.RespondWithJSON(w, http.StatusOK, edits)
+}
+
+func respondPostError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrPostNotFound:
+		http.Error(w, "Post not found", http.StatusNotFound)
+	case service.ErrNotPostManager:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Printf("Post request failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
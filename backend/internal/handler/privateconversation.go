@@ -3,6 +3,7 @@ package handler
 import (
 	"backend/internal/context"
 	"backend/internal/model"
+	"backend/internal/repository"
 	"backend/pkg/getusers"
 	"database/sql"
 	"encoding/json"
@@ -23,10 +24,11 @@ func PrivateConversations(db *sql.DB) http.HandlerFunc {
 		}
 
 		query := `
-        SELECT sender_id, receiver_id, content, created_at 
+        SELECT id, sender_id, receiver_id, content, created_at
         FROM messages
-        WHERE (sender_id = ? AND receiver_id = ?)
-           OR (sender_id = ? AND receiver_id = ?)
+        WHERE ((sender_id = ? AND receiver_id = ?)
+           OR (sender_id = ? AND receiver_id = ?))
+          AND hidden_at IS NULL
         ORDER BY created_at DESC`
 
 		rows, err := db.Query(query, currentUserId, receiverId, receiverId, currentUserId)
@@ -40,7 +42,7 @@ func PrivateConversations(db *sql.DB) http.HandlerFunc {
 		var messages []model.Message
 		for rows.Next() {
 			var msg model.Message
-			if err := rows.Scan(&msg.From, &msg.To, &msg.Content, &msg.Timestamp); err != nil {
+			if err := rows.Scan(&msg.ID, &msg.From, &msg.To, &msg.Content, &msg.Timestamp); err != nil {
 				log.Println("Failed to scan messages details in conversation: %w", err)
 			}
 			messages = append(messages, msg)
@@ -55,6 +57,20 @@ func PrivateConversations(db *sql.DB) http.HandlerFunc {
 			messages = []model.Message{}
 		}
 
+		ids := make([]string, len(messages))
+		for i, msg := range messages {
+			ids[i] = msg.ID
+		}
+		mentions, err := repository.FindMessageMentions(db, ids)
+		if err != nil {
+			log.Println("Failed to query mentions in messages: ", err)
+			http.Error(w, "An error occured, please check back later", http.StatusInternalServerError)
+			return
+		}
+		for i := range messages {
+			messages[i].Mentions = mentions[messages[i].ID]
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(messages)
 	}
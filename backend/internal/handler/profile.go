@@ -3,6 +3,8 @@ package handler
 import (
 	"backend/internal/context"
 	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
 	"backend/pkg/db/sqlite"
 	"backend/pkg/extractid"
 	"backend/pkg/getusers"
@@ -125,12 +127,23 @@ func UpdateProfileHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 		fmt.Println(user.ProfileVisibility)
+		var previousVisibility string
+		if err := db.QueryRow(`SELECT profileVisibility FROM users WHERE id = ?`, currentUserId).Scan(&previousVisibility); err != nil {
+			log.Println("Error getting profile visibility:", err)
+			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+			return
+		}
 		// Update user profile in the database
 		if err := sqlite.UpdateUserVisibility(db, currentUserId, user); err != nil {
 			log.Println("Error updating user profile:", err)
 			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
 			return
 		}
+		if user.ProfileVisibility != previousVisibility {
+			recordAudit(service.NewAuditService(repository.NewAuditRepository(db)),
+				auditEvent(r, currentUserId, model.AuditVisibilityChanged, model.AuditTargetUser, currentUserId),
+				map[string]any{"profile_visibility": previousVisibility}, map[string]any{"profile_visibility": user.ProfileVisibility})
+		}
 
 		w.WriteHeader(http.StatusNoContent)
 	}
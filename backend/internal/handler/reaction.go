@@ -3,6 +3,7 @@ package handler
 import (
 	"backend/internal/context"
 	"backend/internal/model"
+	"backend/internal/policy"
 	"backend/internal/repository"
 	"database/sql"
 	"encoding/json"
@@ -40,6 +41,17 @@ func HandleReaction(db *sql.DB) http.HandlerFunc {
 			return
 		}
 		
+		// Posts the user may not react to are treated as gone
+		visible, err := policy.New(db).CanReact(user.ID, postID)
+		if err != nil {
+			sendErrorResponse(w, "Failed to check post", http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			sendErrorResponse(w, "Post not found", http.StatusNotFound)
+			return
+		}
+
 		// Create reaction object
 		reaction := model.Reaction{
 			UserID: user.ID,
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/policy"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// CreateReportRequest is the payload for reporting content or a user
+type CreateReportRequest struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// ResolveReportRequest is the payload for resolving a report
+type ResolveReportRequest struct {
+	Action      string `json:"action"` // hide, warn, suspend or dismiss
+	Note        string `json:"note"`
+	SuspendDays int    `json:"suspend_days"` // 0 suspends until the user is reinstated
+}
+
+// reportTargetTypes maps the path segment of the report endpoints to the reported kind of content
+var reportTargetTypes = map[string]string{
+	"posts":    model.ReportTargetPost,
+	"comments": model.ReportTargetComment,
+	"messages": model.ReportTargetMessage,
+	"groups":   model.ReportTargetGroup,
+	"users":    model.ReportTargetUser,
+}
+
+// ReportHandler lets users report content and moderators work through the reports
+type ReportHandler struct {
+	Service *service.ModerationService
+	Audit   *service.AuditService
+	Policy  *policy.Policy
+}
+
+// Create handles POST /api/reports/{posts|comments|messages|groups|users}/:id.
+func (h *ReportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/reports/"), "/"), "/")
+	targetType, ok := reportTargetTypes[parts[0]]
+	if !ok || len(parts) != 2 || parts[1] == "" {
+		utils.RespondWithError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	var req CreateReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	visible, err := h.canSee(user.ID, targetType, parts[1])
+	if err != nil {
+		respondModerationError(w, err)
+		return
+	}
+	if !visible {
+		respondModerationError(w, service.ErrReportTargetNotFound)
+		return
+	}
+
+	report, err := h.Service.Report(user.ID, targetType, parts[1], req.Reason, req.Details)
+	if err != nil {
+		respondModerationError(w, err)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusCreated, report)
+}
+
+// canSee keeps users from reporting, and so learning about, posts and groups they cannot see
+func (h *ReportHandler) canSee(userID, targetType, targetID string) (bool, error) {
+	switch targetType {
+	case model.ReportTargetPost:
+		return h.Policy.CanViewPost(userID, targetID)
+	case model.ReportTargetGroup:
+		groupID, err := strconv.ParseUint(targetID, 10, 32)
+		if err != nil {
+			return false, nil
+		}
+		return h.Policy.CanViewGroup(userID, uint(groupID))
+	}
+	return true, nil
+}
+
+// Queue handles GET /api/moderation/reports?status=&type=&reason=&claimed_by=&limit=&offset=.
+// claimed_by=me lists the reports claimed by the current moderator.
+func (h *ReportHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := repository.ReportFilter{
+		Status:     query.Get("status"),
+		TargetType: query.Get("type"),
+		Reason:     query.Get("reason"),
+		ClaimedBy:  query.Get("claimed_by"),
+	}
+	if filter.ClaimedBy == "me" {
+		filter.ClaimedBy = context.MustGetUser(r.Context()).ID
+	}
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	reports, err := h.Service.Queue(filter)
+	if err != nil {
+		respondModerationError(w, err)
+		return
+	}
+	if reports == nil {
+		reports = []model.Report{}
+	}
+	utils.RespondWithJSON(w, http.StatusOK, reports)
+}
+
+// Report handles the actions on one report:
+//
+//	GET  /api/moderation/reports/:id          show the report
+//	POST /api/moderation/reports/:id/claim    assign it to yourself
+//	POST /api/moderation/reports/:id/release  put it back in the queue
+//	POST /api/moderation/reports/:id/resolve  act on it and close it
+func (h *ReportHandler) Report(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/moderation/reports/"), "/"), "/")
+	reportID, action := parts[0], ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+	if reportID == "" || len(parts) > 2 {
+		utils.RespondWithError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	moderator := context.MustGetUser(r.Context())
+	var report *model.Report
+	var err error
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		report, err = h.Service.Get(reportID)
+
+	case action == "claim" && r.Method == http.MethodPost:
+		report, err = h.Service.Claim(moderator, reportID)
+
+	case action == "release" && r.Method == http.MethodPost:
+		report, err = h.Service.Release(moderator, reportID)
+
+	case action == "resolve" && r.Method == http.MethodPost:
+		var req ResolveReportRequest
+		if decodeErr := json.NewDecoder(r.Body).Decode(&req); decodeErr != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+decodeErr.Error())
+			return
+		}
+		if req.SuspendDays < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "suspend_days must not be negative")
+			return
+		}
+		report, err = h.Service.Resolve(moderator, reportID, req.Action, req.Note, time.Duration(req.SuspendDays)*24*time.Hour)
+		if err == nil {
+			recordAudit(h.Audit, auditEvent(r, moderator.ID, model.AuditReportResolved, model.AuditTargetReport, reportID),
+				nil, map[string]any{
+					"target_type":     report.TargetType,
+					"target_id":       report.TargetID,
+					"target_user_id":  report.TargetUserID,
+					"resolution":      report.Resolution,
+					"resolution_note": report.ResolutionNote,
+					"suspend_days":    req.SuspendDays,
+				})
+		}
+
+	case action == "" || action == "claim" || action == "release" || action == "resolve":
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+
+	default:
+		utils.RespondWithError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if err != nil {
+		respondModerationError(w, err)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, report)
+}
+
+// Warnings handles GET /api/warnings and lists the warnings moderators issued to the current user.
+func (h *ReportHandler) Warnings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	warnings, err := h.Service.Warnings(context.MustGetUser(r.Context()).ID)
+	if err != nil {
+		respondModerationError(w, err)
+		return
+	}
+	if warnings == nil {
+		warnings = []model.UserWarning{}
+	}
+	utils.RespondWithJSON(w, http.StatusOK, warnings)
+}
+
+func respondModerationError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrInvalidReportTarget, service.ErrInvalidReportReason, service.ErrReportTooLong,
+		service.ErrCannotReportSelf, service.ErrInvalidResolution, service.ErrInvalidSuspension:
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case service.ErrReportTargetNotFound, service.ErrReportNotFound, service.ErrAdminUserNotFound:
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case service.ErrAlreadyReported, service.ErrReportClaimed, service.ErrReportResolved:
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case service.ErrCannotManageUser:
+		utils.RespondWithError(w, http.StatusForbidden, err.Error())
+	default:
+		log.Printf("Moderation request failed: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Internal server error")
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"backend/internal/context"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// RepostHandler lets users reshare posts as they are. Quotes are created with CreatePost and a quoteOf field.
+type RepostHandler struct {
+	Service *service.RepostService
+}
+
+// Repost handles POST and DELETE /api/posts/:id/repost.
+//
+// POST reposts the post, taking optional postPrivacy and allowedFollowers fields as CreatePost does.
+// Without postPrivacy the repost has the visibility of the post. DELETE undoes the user's repost.
+func (h *RepostHandler) Repost(w http.ResponseWriter, r *http.Request) {
+	postID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/posts/"), "/repost")
+	if postID == "" || strings.Contains(postID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	userID := context.MustGetUser(r.Context()).ID
+	switch r.Method {
+	case http.MethodPost:
+		var allowedFollowers []string
+		if allowedJSON := r.FormValue("allowedFollowers"); allowedJSON != "" {
+			if err := json.Unmarshal([]byte(allowedJSON), &allowedFollowers); err != nil {
+				http.Error(w, "Invalid allowedFollowers format", http.StatusBadRequest)
+				return
+			}
+		}
+		repost, err := h.Service.Repost(userID, postID, r.FormValue("postPrivacy"), allowedFollowers)
+		if err != nil {
+			respondRepostError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{"success": true, "post": repost})
+	case http.MethodDelete:
+		if err := h.Service.Unrepost(userID, postID); err != nil {
+			respondRepostError(w, err)
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func respondRepostError(w http.ResponseWriter, err error) {
+	switch err {
+	case service.ErrPostNotFound, service.ErrNotReposted:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case service.ErrAlreadyReposted:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case service.ErrReshareTooWide, service.ErrInvalidAudience:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Repost request failed: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/pkg/db/sqlite"
+	"backend/pkg/extractid"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SessionInfo describes one active session of the current user.
+// The session ID itself is the cookie secret, so clients only ever see a derived handle.
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// sessionHandle derives the public identifier of a session
+func sessionHandle(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+// GetSessions handles GET /api/sessions and lists the current user's active sessions.
+func GetSessions(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		currentUserID := context.MustGetUser(r.Context()).ID
+		currentSessionID := context.MustGetSessionID(r.Context())
+
+		sessions, err := sqlite.GetUserSessions(db, currentUserID, time.Now())
+		if err != nil {
+			log.Printf("Error getting sessions: %v", err)
+			http.Error(w, "Failed to get sessions", http.StatusInternalServerError)
+			return
+		}
+
+		result := []SessionInfo{}
+		for _, s := range sessions {
+			result = append(result, SessionInfo{
+				ID:         sessionHandle(s.ID),
+				UserAgent:  s.UserAgent,
+				IPAddress:  s.IPAddress,
+				CreatedAt:  s.CreatedAt,
+				LastUsedAt: s.LastUsedAt,
+				ExpiresAt:  s.ExpiresAt,
+				Current:    s.ID == currentSessionID,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// RevokeSession handles DELETE /api/sessions/:id and logs one of the current user's sessions out.
+func RevokeSession(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		currentUserID := context.MustGetUser(r.Context()).ID
+		handle := extractid.ExtractUserIDFromPath(r.URL.Path, "sessions")
+		if handle == "" {
+			http.Error(w, "Invalid session ID", http.StatusBadRequest)
+			return
+		}
+
+		sessions, err := sqlite.GetUserSessions(db, currentUserID, time.Now())
+		if err != nil {
+			log.Printf("Error getting sessions: %v", err)
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+
+		for _, s := range sessions {
+			if sessionHandle(s.ID) != handle {
+				continue
+			}
+			if _, err := sqlite.DeleteUserSession(db, currentUserID, s.ID); err != nil {
+				log.Printf("Error revoking session: %v", err)
+				http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+				return
+			}
+			recordAudit(service.NewAuditService(repository.NewAuditRepository(db)),
+				auditEvent(r, currentUserID, model.AuditSessionRevoked, model.AuditTargetSession, handle),
+				map[string]any{"user_agent": s.UserAgent, "ip_address": s.IPAddress}, nil)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Session revoked"})
+			return
+		}
+
+		http.Error(w, "Session not found", http.StatusNotFound)
+	}
+}
+
+// RevokeOtherSessions handles POST /api/sessions/revoke-others and logs out every session but the current one.
+func RevokeOtherSessions(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		currentUserID := context.MustGetUser(r.Context()).ID
+		currentSessionID := context.MustGetSessionID(r.Context())
+
+		revoked, err := sqlite.DeleteOtherSessions(db, currentUserID, currentSessionID)
+		if err != nil {
+			log.Printf("Error revoking other sessions: %v", err)
+			http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+			return
+		}
+		if revoked > 0 {
+			recordAudit(service.NewAuditService(repository.NewAuditRepository(db)),
+				auditEvent(r, currentUserID, model.AuditOtherSessionsRevoked, model.AuditTargetUser, currentUserID),
+				map[string]any{"sessions": revoked + 1}, map[string]any{"sessions": 1})
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Other sessions revoked",
+			"revoked": revoked,
+		})
+	}
+}
+
+// RunSessionCleanup periodically purges expired sessions. It is meant to run in its own goroutine.
+func RunSessionCleanup(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := sqlite.PurgeExpiredSessions(db, time.Now())
+		if err != nil {
+			log.Println("Failed to purge expired sessions:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d expired sessions", purged)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+)
+
+// DefaultTrendingTags is how many trending tags are returned when the request does not say
+const DefaultTrendingTags = 10
+
+// TagHandler serves the posts using a hashtag and the trending tags
+type TagHandler struct {
+	Service *service.TagService
+}
+
+// Posts handles GET /api/tags/:tag?cursor=&limit= and returns a page of the posts the user can see that use
+// the tag, newest first. As with /api/feeds, next_cursor fetches the following page and is empty after the last one.
+func (h *TagHandler) Posts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tag := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tags/"), "/")
+	limit, ok := feedPageSize(w, r)
+	if !ok {
+		return
+	}
+	var after *repository.FeedCursor
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		parsed, err := repository.ParseFeedCursor(cursor)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		after = parsed
+	}
+
+	posts, next, err := h.Service.Posts(context.MustGetUser(r.Context()).ID, tag, after, limit)
+	if err == service.ErrInvalidTag {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Println("Error fetching tagged posts:", err)
+		http.Error(w, "Failed to fetch posts", http.StatusInternalServerError)
+		return
+	}
+	if posts == nil {
+		posts = []model.Post{}
+	}
+	nextCursor := ""
+	if next != nil {
+		nextCursor = next.String()
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"posts":       posts,
+			"next_cursor": nextCursor,
+		},
+	})
+}
+
+// Trending handles GET /api/trending-tags?limit= and returns the tags people have used most of late
+// in public posts and their comments, as of the last refresh by RunTrendingTags.
+func (h *TagHandler) Trending(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := DefaultTrendingTags
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	tags, err := h.Service.Trending(limit)
+	if err != nil {
+		log.Println("Error fetching trending tags:", err)
+		http.Error(w, "Failed to fetch trending tags", http.StatusInternalServerError)
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"tags": tags})
+}
+
+// RunTrendingTags indexes the tags of posts written before tags were, then periodically rebuilds the
+// trending tags. It is meant to run in its own goroutine.
+func RunTrendingTags(db *sql.DB, interval time.Duration) {
+	tags := service.NewTagService(repository.NewTagRepository(db))
+	if n, err := tags.Backfill(); err != nil {
+		log.Println("Failed to index the tags of older posts:", err)
+	} else if n > 0 {
+		log.Printf("Indexed the tags of %d older posts", n)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := tags.Refresh(); err != nil {
+			log.Println("Failed to refresh trending tags:", err)
+		}
+		<-ticker.C
+	}
+}
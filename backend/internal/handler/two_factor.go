@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/db/sqlite"
+	"backend/pkg/getusers"
+)
+
+// TwoFactorLoginRequest is the payload of the second login step
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // TOTP code or recovery code
+}
+
+// TwoFactorCodeRequest is the payload for confirming or disabling 2FA
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// LoginTwoFactorHandler completes a login started by LoginHandler for a user with 2FA enabled.
+// It exchanges a valid challenge token and code for a session cookie.
+func LoginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed. Use POST for login.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TwoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		http.Error(w, "Invalid JSON format. Please provide challenge_token and code.", http.StatusBadRequest)
+		return
+	}
+
+	db, err := sqlite.ConnectAndMigrate()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	twoFactor := service.NewTwoFactorService(repository.NewTwoFactorRepository(db))
+	attempts := service.NewLoginAttemptService(repository.NewLoginAttemptRepository(db))
+	attempts.Audit = service.NewAuditService(repository.NewAuditRepository(db))
+	ipAddress := utils.ClientIP(r)
+
+	userID, err := twoFactor.VerifyLoginChallenge(req.ChallengeToken, req.Code)
+	if err != nil {
+		// wrong codes count towards the account backoff like wrong passwords do
+		if userID != "" {
+			if user, getUserErr := getusers.GetUserByID(db, userID); getUserErr == nil {
+				if recordErr := attempts.RecordFailure(user.Email, user.ID, ipAddress, r.UserAgent(), service.LoginFailureInvalidTwoFactor); recordErr != nil {
+					log.Println("Error recording login attempt:", recordErr)
+				}
+			}
+		}
+
+		switch err {
+		case service.ErrInvalidTwoFactorCode:
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		case service.ErrLoginChallengeNotFound, service.ErrLoginChallengeExpired, service.ErrLoginChallengeExhausted:
+			utils.RespondWithError(w, http.StatusUnauthorized, err.Error())
+		default:
+			log.Println("Error verifying login challenge:", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to verify code")
+		}
+		return
+	}
+
+	user, err := getusers.GetUserByID(db, userID)
+	if err != nil {
+		log.Println("Error getting user after two-factor login:", err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
+	if err := startSession(w, r, db, user, "two_factor"); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	if err := attempts.RecordSuccess(user.Email, user.ID, ipAddress, r.UserAgent()); err != nil {
+		log.Println("Error recording login attempt:", err)
+	}
+}
+
+// TwoFactorHandler handles 2FA enrollment for the authenticated user
+type TwoFactorHandler struct {
+	Service *service.TwoFactorService
+	Audit   *service.AuditService
+}
+
+// Status handles GET /api/2fa and reports whether 2FA is on and how many recovery codes remain.
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	enabled, err := h.Service.IsEnabled(user.ID)
+	if err != nil {
+		log.Printf("Failed to get two-factor status: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get two-factor status")
+		return
+	}
+
+	remaining := 0
+	if enabled {
+		remaining, err = h.Service.RemainingRecoveryCodes(user.ID)
+		if err != nil {
+			log.Printf("Failed to count recovery codes: %v", err)
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":                  enabled,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// Enroll handles POST /api/2fa/enroll and returns a new secret and its provisioning URI.
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	secret, uri, err := h.Service.BeginEnrollment(user)
+	if err != nil {
+		if err == service.ErrTwoFactorAlreadyEnabled {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		log.Printf("Failed to start two-factor enrollment: %v", err)
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to start two-factor enrollment")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+		"secret":           secret,
+		"provisioning_uri": uri,
+	})
+}
+
+// Confirm handles POST /api/2fa/confirm. A valid first code enables 2FA and returns the recovery codes.
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	codes, err := h.Service.ConfirmEnrollment(user.ID, req.Code)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidTwoFactorCode, service.ErrTwoFactorNotEnrolled:
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		case service.ErrTwoFactorAlreadyEnabled:
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("Failed to confirm two-factor enrollment: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to confirm two-factor enrollment")
+		}
+		return
+	}
+
+	recordAudit(h.Audit, auditEvent(r, user.ID, model.AuditTwoFactorEnabled, model.AuditTargetUser, user.ID),
+		map[string]any{"two_factor": false}, map[string]any{"two_factor": true})
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":        true,
+		"recovery_codes": codes,
+	})
+}
+
+// Disable handles POST /api/2fa/disable. It requires a current code or an unused recovery code.
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	user := context.MustGetUser(r.Context())
+	if err := h.Service.Disable(user.ID, req.Code); err != nil {
+		switch err {
+		case service.ErrInvalidTwoFactorCode, service.ErrTwoFactorNotEnrolled:
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("Failed to disable two-factor authentication: %v", err)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		}
+		return
+	}
+
+	recordAudit(h.Audit, auditEvent(r, user.ID, model.AuditTwoFactorDisabled, model.AuditTargetUser, user.ID),
+		map[string]any{"two_factor": true}, map[string]any{"two_factor": false})
+	utils.RespondWithJSON(w, http.StatusOK, map[string]bool{
+		"enabled": false,
+	})
+}
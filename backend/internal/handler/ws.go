@@ -6,32 +6,88 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
+	"backend/internal/config"
 	"backend/internal/model"
+	"backend/internal/repository"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 )
 
 var (
-	users     = make(map[string]*websocket.Conn)
+	users     = make(map[string]*client)
 	broadcast = make(chan model.Message)
 	mutex     = &sync.Mutex{}
 )
 
-// upgrader upgrades http conns to websocket conns
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  3000,
-	WriteBufferSize: 3000,
-	CheckOrigin: func(r *http.Request) bool {
-		origin := r.Header.Get("Origin")
-		return origin == "http://localhost:3000" // only allow local development origin
-	},
+// writeWait is how long a write to a WebSocket may take before the connection is given up
+const writeWait = 10 * time.Second
+
+// client is the WebSocket connection of an online user. gorilla/websocket allows only one concurrent
+// writer per connection, so everything sent to it goes through send.
+type client struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+// send writes v to the connection as JSON, waiting for the writes already under way.
+func (c *client) send(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteJSON(v)
+}
+
+// sendTo sends v to a user if they are online, and reports whether they were. A connection that cannot be
+// written to is closed and forgotten.
+func sendTo(userID string, v interface{}) (bool, error) {
+	mutex.Lock()
+	c, ok := users[userID]
+	mutex.Unlock()
+	if !ok {
+		return false, nil
+	}
+	if err := c.send(v); err != nil {
+		c.conn.Close()
+		mutex.Lock()
+		if users[userID] == c {
+			delete(users, userID)
+		}
+		mutex.Unlock()
+		return true, err
+	}
+	return true, nil
+}
+
+// onlineUsers returns the IDs of the users connected to the WebSocket.
+func onlineUsers() []string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	online := make([]string, 0, len(users))
+	for userID := range users {
+		online = append(online, userID)
+	}
+	return online
+}
+
+// newUpgrader returns an upgrader for http conns to websocket conns that only accepts
+// handshakes from the configured origins
+func newUpgrader(cfg *config.Config) *websocket.Upgrader {
+	return &websocket.Upgrader{
+		ReadBufferSize:  3000,
+		WriteBufferSize: 3000,
+		CheckOrigin: func(r *http.Request) bool {
+			return cfg.OriginAllowed(r.Header.Get("Origin"))
+		},
+	}
 }
 
 // handleWebSocket uses the upgrader to upgrade the http conn
 // then reads and writes messages from and to the ws
-func WebSocketConnection(db *sql.DB) http.HandlerFunc {
+func WebSocketConnection(db *sql.DB, cfg *config.Config) http.HandlerFunc {
+	upgrader := newUpgrader(cfg)
 	return func(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("hit")
 		// upgrade initial get request to a WebSocket
@@ -53,8 +109,9 @@ func WebSocketConnection(db *sql.DB) http.HandlerFunc {
 		}
 		id := loginMsg.From
 
+		c := &client{conn: conn}
 		mutex.Lock()
-		users[id] = conn
+		users[id] = c
 		mutex.Unlock()
 
 		log.Println(id, "connected")
@@ -63,7 +120,9 @@ func WebSocketConnection(db *sql.DB) http.HandlerFunc {
 		// Clean up on disconnect
 		defer func() {
 			mutex.Lock()
-			delete(users, id)
+			if users[id] == c {
+				delete(users, id)
+			}
 			mutex.Unlock()
 			log.Println(id, "disconnected")
 			BroadcastUserList(db)
@@ -89,28 +148,52 @@ func HandleMessages(db *sql.DB) {
 		msg := <-broadcast
 
 		messageId := uuid.NewString()
-		_, insertMessageErr := db.Exec(`
-		INSERT INTO messages (id, sender_id, receiver_id, content)
-		VALUES (?, ?, ?, ?)`, messageId, msg.From, msg.To, msg.Content)
+		insertMessageErr := saveMessage(db, messageId, msg)
 		if insertMessageErr != nil {
 			log.Println("Failed to save message to database: ", insertMessageErr)
-		}
-
-		mutex.Lock()
-		recipientConn, ok := users[msg.To]
-		mutex.Unlock()
-		if ok {
-			err := recipientConn.WriteJSON(msg)
+		} else {
+			// lets the recipient refer to the message, e.g. to report it
+			msg.ID = messageId
+			mentions, err := repository.FindMessageMentions(db, []string{messageId})
 			if err != nil {
-				log.Println("Error sending message to", msg.To+":", err)
-				recipientConn.Close()
-				delete(users, msg.To)
+				log.Println("Failed to read mentions in message:", err)
 			}
-		} else {
+			msg.Mentions = mentions[messageId]
+		}
+
+		online, err := sendTo(msg.To, msg)
+		if err != nil {
+			log.Println("Error sending message to", msg.To+":", err)
+		} else if !online {
 			log.Println("User", msg.To, "is not online. Message not delivered.")
 		}
 
+		if msg.ID != "" {
+			if err := newMentionService(db).NotifyMessage(msg.ID); err != nil {
+				log.Println("Failed to notify mentions in message:", err)
+			}
+		}
+
 		// update the user list for both sender and recipient
 		BroadcastUserList(db)
 	}
 }
+
+// saveMessage stores a chat message with the users it mentions
+func saveMessage(db *sql.DB, messageID string, msg model.Message) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO messages (id, sender_id, receiver_id, content)
+		VALUES (?, ?, ?, ?)`, messageID, msg.From, msg.To, msg.Content); err != nil {
+		return err
+	}
+	if err := repository.InsertMessageMentions(tx, messageID, msg.From, msg.Content, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
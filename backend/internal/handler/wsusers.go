@@ -94,7 +94,10 @@ func getUserStatuses(db *sql.DB, requestedUserID string) ([]UserStatus, error) {
 	var result []UserStatus
 	for _, user := range userStatuses {
 		status := "offline"
-		if _, ok := users[user.id]; ok {
+		mutex.Lock()
+		_, ok := users[user.id]
+		mutex.Unlock()
+		if ok {
 			status = "online"
 		}
 		result = append(result, UserStatus{
@@ -132,7 +135,7 @@ func HandleUserStatuses(db *sql.DB) http.HandlerFunc {
 }
 
 func BroadcastUserList(db *sql.DB) {
-	for connectedUserID, conn := range users {
+	for _, connectedUserID := range onlineUsers() {
 		result, err := getUserStatuses(db, connectedUserID)
 		if err != nil {
 			log.Println("Failed to get user statuses:", err)
@@ -144,10 +147,8 @@ func BroadcastUserList(db *sql.DB) {
 			Data: result,
 		}
 
-		if err := conn.WriteJSON(payload); err != nil {
+		if _, err := sendTo(connectedUserID, payload); err != nil {
 			log.Println("Failed to send user list update:", err)
-			conn.Close()
-			delete(users, connectedUserID)
 		}
 	}
 }
@@ -3,19 +3,30 @@ package middlewares
 import (
 	"backend/internal/context"
 	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
 	"backend/pkg/db/sqlite"
 	"backend/pkg/getusers"
 	"database/sql"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
-// AuthMiddleware verifies session token and attaches user to context
+// AuthMiddleware verifies session token and attaches user to context.
+// Requests carrying "Authorization: Bearer <token>" are authenticated with a personal API token
+// instead, and the token's scopes are attached to the context alongside the user.
 func AuthMiddleware(db *sql.DB, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get("Authorization"); header != "" {
+			bearerAuth(db, w, r, header, next)
+			return
+		}
+
 		// Get session cookie
-		cookie, err := r.Cookie("social-network")
+		cookie, err := r.Cookie(utils.SessionCookieName)
 		if err != nil {
 			http.Error(w, "Unauthorized: No session cookie", http.StatusUnauthorized)
 			return
@@ -39,16 +50,15 @@ func AuthMiddleware(db *sql.DB, next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		// Check if session is expired
-		if time.Now().After(session.ExpiresAt) {
-			// Clean up expired session
-			_ = sqlite.DeleteSession(db, sessionID)
+		// Check if session is expired, expired rows are removed by the session cleanup job
+		now := time.Now()
+		if now.After(session.ExpiresAt) {
 			http.Error(w, "Unauthorized: Session expired", http.StatusUnauthorized)
 			return
 		}
 
 		// Get user from database
-		user, err := getusers.GetUserByID(db, session.UserID)
+		modelUser, suspension, err := loadUser(db, session.UserID)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				// User was deleted but session still exists
@@ -60,25 +70,165 @@ func AuthMiddleware(db *sql.DB, next http.HandlerFunc) http.HandlerFunc {
 			}
 			return
 		}
-
-		modelUser := &model.User{
-			ID:                user.ID,
-			Email:             user.Email,
-			FirstName:         user.FirstName,
-			LastName:          user.LastName,
-			DOB:               user.DOB,
-			ImgURL:            user.ImgURL,
-			Nickname:          user.Nickname,
-			About:             user.About,
-			ProfileVisibility: user.ProfileVisibility,
-			CreatedAt:         user.CreatedAt,
+		if suspension.ActiveAt(now) {
+			rejectSuspended(w, suspension)
+			return
 		}
 
+		renewSession(db, w, session, now)
+
 		// Add user and session ID to context
 		ctx := context.WithUser(r.Context(), modelUser)
-		ctx = context.WithSessionID(ctx, session.UserID)
+		ctx = context.WithSessionID(ctx, session.ID)
 
 		// Continue with the request
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// OptionalAuth attaches the user to the context like AuthMiddleware when the request carries a valid
+// session cookie or API token, and lets it through anonymously otherwise, for routes where anyone
+// may see some of the content. Sessions are not renewed, so responses carry no Set-Cookie.
+func OptionalAuth(db *sql.DB, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		var userID string
+		var scopes []string
+		bearer := false
+		if raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && raw != "" {
+			tokens := service.NewAPITokenService(repository.NewAPITokenRepository(db))
+			token, err := tokens.Authenticate(strings.TrimSpace(raw))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, scopes, bearer = token.UserID, token.Scopes, true
+		} else if cookie, err := r.Cookie(utils.SessionCookieName); err == nil && cookie.Value != "" {
+			session, err := sqlite.GetSession(db, cookie.Value)
+			if err != nil || now.After(session.ExpiresAt) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID = session.UserID
+		} else {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		modelUser, suspension, err := loadUser(db, userID)
+		if err != nil || suspension.ActiveAt(now) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := context.WithUser(r.Context(), modelUser)
+		if bearer {
+			ctx = context.WithScopes(ctx, scopes)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// bearerAuth authenticates a request made with a personal API token
+func bearerAuth(db *sql.DB, w http.ResponseWriter, r *http.Request, header string, next http.HandlerFunc) {
+	raw, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || raw == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized: Malformed Authorization header", http.StatusUnauthorized)
+		return
+	}
+
+	tokens := service.NewAPITokenService(repository.NewAPITokenRepository(db))
+	token, err := tokens.Authenticate(strings.TrimSpace(raw))
+	if err != nil {
+		if err == service.ErrInvalidAPIToken {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		} else {
+			log.Printf("Error retrieving API token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	modelUser, suspension, err := loadUser(db, token.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Unauthorized: User not found", http.StatusUnauthorized)
+		} else {
+			log.Printf("Error retrieving user: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if suspension.ActiveAt(time.Now()) {
+		rejectSuspended(w, suspension)
+		return
+	}
+
+	ctx := context.WithUser(r.Context(), modelUser)
+	ctx = context.WithScopes(ctx, token.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// loadUser builds the context user, leaving out the password hash, and returns any suspension
+// on record. The caller decides whether the suspension is still in force.
+func loadUser(db *sql.DB, userID string) (*model.User, *model.UserSuspension, error) {
+	user, err := getusers.GetUserByID(db, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	access, err := (&repository.UserRepository{DB: db}).FindAccess(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &model.User{
+		ID:                user.ID,
+		Email:             user.Email,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		DOB:               user.DOB,
+		ImgURL:            user.ImgURL,
+		Nickname:          user.Nickname,
+		About:             user.About,
+		ProfileVisibility: user.ProfileVisibility,
+		CreatedAt:         user.CreatedAt,
+		Role:              access.Role,
+	}, access.Suspension, nil
+}
+
+// rejectSuspended tells a suspended user why they are locked out and for how long
+func rejectSuspended(w http.ResponseWriter, suspension *model.UserSuspension) {
+	message := "Forbidden: Account suspended"
+	if suspension.Until != nil {
+		message += " until " + suspension.Until.UTC().Format(time.RFC3339)
+	}
+	if suspension.Reason != "" {
+		message += ". Reason: " + suspension.Reason
+	}
+	http.Error(w, message, http.StatusForbidden)
+}
+
+// renewSession records activity on the session and slides its expiry forward so active users
+// are not logged out mid-use. Writes are throttled, and sessions never outlive SessionMaxLifetime.
+func renewSession(db *sql.DB, w http.ResponseWriter, session *sqlite.Session, now time.Time) {
+	extend := session.ExpiresAt.Sub(now) < sqlite.SessionRenewAfter
+	if !extend && now.Sub(session.LastUsedAt) < sqlite.SessionTouchInterval {
+		return
+	}
+
+	expiresAt := session.ExpiresAt
+	if extend {
+		expiresAt = now.Add(sqlite.SessionLifetime)
+		if maxExpiry := session.CreatedAt.Add(sqlite.SessionMaxLifetime); expiresAt.After(maxExpiry) {
+			expiresAt = maxExpiry
+		}
+	}
+
+	if err := sqlite.TouchSession(db, session.ID, now, expiresAt); err != nil {
+		log.Printf("Error renewing session: %v", err)
+		return
+	}
+	if expiresAt.After(session.ExpiresAt) {
+		utils.SetSessionCookie(w, session.ID, expiresAt)
+	}
+}
@@ -1,9 +1,18 @@
 package middlewares
 
 import (
+	"backend/internal/context"
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/db/sqlite"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestAuthMiddleware_NoSessionCookie(t *testing.T) {
@@ -56,3 +65,121 @@ func TestAuthMiddleware_EmptySessionCookie(t *testing.T) {
 		t.Errorf("Expected body '%s', got '%s'", expectedBody, rr.Body.String())
 	}
 }
+
+func TestRenewSession_SlidesExpiryForActiveSession(t *testing.T) {
+	db := dbtest.New(t)
+	db.Exec(`INSERT INTO users (id, email, fname, lname, dob, password) VALUES ('user-1', 'jane@example.com', 'Jane', 'Doe', '2000-01-01', 'hash')`)
+
+	now := time.Now()
+	session := &sqlite.Session{
+		ID:         "session-1",
+		UserID:     "user-1",
+		CreatedAt:  now.Add(-20 * time.Hour),
+		ExpiresAt:  now.Add(4 * time.Hour),
+		LastUsedAt: now.Add(-time.Hour),
+	}
+	db.Exec(`INSERT INTO sessions (id, user_id, expires_at, created_at, last_used_at) VALUES (?, ?, ?, ?, ?)`,
+		session.ID, session.UserID, session.ExpiresAt, session.CreatedAt, session.LastUsedAt)
+
+	rr := httptest.NewRecorder()
+	renewSession(db, rr, session, now)
+
+	renewed, err := sqlite.GetSession(db, session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if !renewed.ExpiresAt.Equal(now.Add(sqlite.SessionLifetime)) {
+		t.Errorf("Expected expiry to slide to %v, got %v", now.Add(sqlite.SessionLifetime), renewed.ExpiresAt)
+	}
+	if len(rr.Result().Cookies()) != 1 {
+		t.Error("Expected the session cookie to be re-issued with the new expiry")
+	}
+
+	// a session close to its hard cap is only extended up to the cap
+	session.CreatedAt = now.Add(-sqlite.SessionMaxLifetime + time.Hour)
+	session.ExpiresAt = now.Add(30 * time.Minute)
+	renewSession(db, httptest.NewRecorder(), session, now)
+
+	capped, _ := sqlite.GetSession(db, session.ID)
+	if !capped.ExpiresAt.Equal(session.CreatedAt.Add(sqlite.SessionMaxLifetime)) {
+		t.Errorf("Expected expiry to be capped at %v, got %v", session.CreatedAt.Add(sqlite.SessionMaxLifetime), capped.ExpiresAt)
+	}
+}
+
+func TestAuthMiddleware_BearerToken(t *testing.T) {
+	db := dbtest.New(t)
+	db.Exec(`INSERT INTO users (id, email, fname, lname, dob, password, imgurl, nickname, about) VALUES ('user-1', 'jane@example.com', 'Jane', 'Doe', '2000-01-01', 'hash', '', '', '')`)
+
+	tokens := service.NewAPITokenService(repository.NewAPITokenRepository(db))
+	raw, _, err := tokens.Create("user-1", "bot", []string{model.ScopeReadFeed}, 0)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if user := context.MustGetUser(r.Context()); user.ID != "user-1" {
+			t.Errorf("Expected user-1 in context, got %s", user.ID)
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		handler http.HandlerFunc
+		want    int
+	}{
+		{"granted scope", "Bearer " + raw, RequireScope(model.ScopeReadFeed, ok), http.StatusOK},
+		{"missing scope", "Bearer " + raw, RequireScope(model.ScopePost, ok), http.StatusForbidden},
+		{"session only route", "Bearer " + raw, RequireSession(ok), http.StatusForbidden},
+		{"unknown token", "Bearer " + service.APITokenPrefix + "nope", RequireScope(model.ScopeReadFeed, ok), http.StatusUnauthorized},
+		{"wrong scheme", "Basic " + raw, RequireScope(model.ScopeReadFeed, ok), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Authorization", tt.header)
+			rr := httptest.NewRecorder()
+			AuthMiddleware(db, tt.handler).ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_SuspensionAndRole(t *testing.T) {
+	db := dbtest.New(t)
+	db.Exec(`INSERT INTO users (id, email, fname, lname, dob, password, imgurl, nickname, about, role) VALUES ('user-1', 'jane@example.com', 'Jane', 'Doe', '2000-01-01', 'hash', '', '', '', 'moderator')`)
+	now := time.Now()
+	db.Exec(`INSERT INTO sessions (id, user_id, expires_at, created_at, last_used_at) VALUES ('session-1', 'user-1', ?, ?, ?)`, now.Add(time.Hour), now, now)
+
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	serve := func(handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.AddCookie(&http.Cookie{Name: utils.SessionCookieName, Value: "session-1"})
+		rr := httptest.NewRecorder()
+		AuthMiddleware(db, handler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(RequireRole(model.RoleModerator, ok)); rr.Code != http.StatusOK {
+		t.Errorf("Expected a moderator to pass RequireRole(moderator), got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve(RequireRole(model.RoleAdmin, ok)); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected a moderator to be rejected by RequireRole(admin), got %d", rr.Code)
+	}
+
+	db.Exec(`UPDATE users SET suspended_at = ?, suspended_until = ?, suspension_reason = 'Spamming' WHERE id = 'user-1'`, now, now.Add(time.Hour))
+	rr := serve(ok)
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "Account suspended until") || !strings.Contains(rr.Body.String(), "Spamming") {
+		t.Errorf("Expected a suspended user to be rejected with the reason, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	db.Exec(`UPDATE users SET suspended_until = ? WHERE id = 'user-1'`, now.Add(-time.Minute))
+	if rr := serve(ok); rr.Code != http.StatusOK {
+		t.Errorf("Expected an expired suspension to let the user in, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -1,14 +1,21 @@
 package middlewares
 
-import "net/http"
+import (
+	"net/http"
 
-func EnableCors(next http.Handler) http.HandlerFunc {
+	"backend/internal/config"
+)
+
+func EnableCors(cfg *config.Config, next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		// set CORS headers, echoing the origin only when it is allowed since credentials are included
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); cfg.OriginAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+CSRFHeaderName)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
 
 		// return 200 ok if it's a preflight request
 		if r.Method == http.MethodOptions {
@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/context"
+)
+
+// Names of the double-submit CSRF cookie and the header clients echo it in
+const (
+	CSRFCookieName = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+	csrfTokenSize  = 32
+	csrfCookieTTL  = 30 * 24 * time.Hour
+)
+
+// CSRF protects state-changing requests that browsers could send with the session cookie.
+//
+// Every response carries a random csrf_token cookie. POST, PUT, PATCH and DELETE requests must
+// echo it in the X-CSRF-Token header, which a cross-site page can neither read nor set, and
+// when they carry an Origin or Referer it must be an allowed origin. Requests authenticated
+// with a bearer token are exempt because browsers never attach those on their own.
+func CSRF(cfg *config.Config, next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := csrfCookie(w, r)
+		if err != nil {
+			log.Printf("Error generating CSRF token: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		r = r.WithContext(context.WithCSRFToken(r.Context(), token))
+
+		if isSafeMethod(r.Method) || strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !requestOriginAllowed(cfg, r) {
+			http.Error(w, "Forbidden: Origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		header := r.Header.Get(CSRFHeaderName)
+		cookie, err := r.Cookie(CSRFCookieName)
+		if err != nil || header == "" || subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+			http.Error(w, "Forbidden: Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// requestOriginAllowed checks the Origin header, falling back to the Referer for browsers that
+// omit Origin. Requests with neither, such as from scripts, are left to the token check.
+func requestOriginAllowed(cfg *config.Config, r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		return cfg.OriginAllowed(origin)
+	}
+	if referer := r.Header.Get("Referer"); referer != "" {
+		return cfg.OriginAllowed(referer)
+	}
+	return true
+}
+
+// csrfCookie returns the request's CSRF token, issuing a new cookie if it has none
+func csrfCookie(w http.ResponseWriter, r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(CSRFCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+
+	buf := make([]byte, csrfTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: false, // the frontend reads it to echo it in the header
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Expires:  time.Now().Add(csrfCookieTTL),
+	})
+	return token, nil
+}
@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/config"
+)
+
+func TestCSRF(t *testing.T) {
+	cfg := config.Load(func(string) string { return "" })
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := CSRF(cfg, next)
+
+	tests := []struct {
+		name   string
+		method string
+		cookie string
+		header string
+		origin string
+		auth   string
+		want   int
+	}{
+		{"safe method without token", http.MethodGet, "", "", "", "", http.StatusOK},
+		{"matching token", http.MethodPost, "token", "token", "", "", http.StatusOK},
+		{"matching token from allowed origin", http.MethodPut, "token", "token", config.DefaultAllowedOrigin, "", http.StatusOK},
+		{"missing header", http.MethodPost, "token", "", "", "", http.StatusForbidden},
+		{"missing cookie", http.MethodDelete, "", "token", "", "", http.StatusForbidden},
+		{"mismatched token", http.MethodPost, "token", "other", "", "", http.StatusForbidden},
+		{"foreign origin", http.MethodPost, "token", "token", "https://evil.example.com", "", http.StatusForbidden},
+		{"null origin", http.MethodPost, "token", "token", "null", "", http.StatusForbidden},
+		{"bearer token is exempt", http.MethodPost, "", "", "", "Bearer snpat_abc", http.StatusOK},
+		{"basic auth is not exempt", http.MethodPost, "", "", "", "Basic abc", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/createpost", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeaderName, tt.header)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRF_IssuesCookieOnce(t *testing.T) {
+	cfg := config.Load(func(string) string { return "" })
+	handler := CSRF(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/csrf", nil))
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookieName || cookies[0].Value == "" || cookies[0].HttpOnly {
+		t.Fatalf("Expected a readable CSRF cookie, got %+v", cookies)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/csrf", nil)
+	req.AddCookie(cookies[0])
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if len(rr.Result().Cookies()) != 0 {
+		t.Error("Expected an existing CSRF cookie to be kept")
+	}
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"backend/internal/context"
+	"backend/internal/model"
+)
+
+// RequireRole rejects users whose site-wide role is below role.
+// It must be wrapped by AuthMiddleware.
+func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !model.HasRole(context.MustGetUser(r.Context()).Role, role) {
+			http.Error(w, "Forbidden: This endpoint requires the "+role+" role", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"backend/internal/context"
+)
+
+// RequireScope rejects API token requests whose token was not granted scope.
+// It must be wrapped by AuthMiddleware. Session requests always pass.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !context.HasScope(r.Context(), scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			http.Error(w, "Forbidden: Token is missing the "+scope+" scope", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// RequireSession rejects API token requests. It guards account settings such as
+// sessions, 2FA and token management, which scripts must never be able to change.
+// It must be wrapped by AuthMiddleware.
+func RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if context.IsTokenRequest(r.Context()) {
+			http.Error(w, "Forbidden: This endpoint requires a browser session", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
+// RequireReadWriteScope applies readScope to GET and HEAD requests and writeScope to everything else,
+// for endpoints that both list and create resources.
+func RequireReadWriteScope(readScope, writeScope string, next http.HandlerFunc) http.HandlerFunc {
+	read := RequireScope(readScope, next)
+	write := RequireScope(writeScope, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			read(w, r)
+			return
+		}
+		write(w, r)
+	}
+}
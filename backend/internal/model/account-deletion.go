@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// AccountDeletion is a pending request to delete an account, which can be cancelled until PurgeAfter
+type AccountDeletion struct {
+	UserID      string    `json:"-"`
+	RequestedAt time.Time `json:"requested_at"`
+	PurgeAfter  time.Time `json:"purge_after"`
+}
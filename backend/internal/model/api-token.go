@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// APIToken is a personal access token a user created for scripts and bots
+type APIToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"` // SHA-256 of the token handed to the user
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Scopes that can be granted to an APIToken. Cookie sessions are not limited by scopes.
+const (
+	ScopeReadFeed     = "feed:read"     // Read the feed, posts, comments, profiles and groups
+	ScopePost         = "posts:write"   // Create posts, comments and reactions
+	ScopeMessage      = "messages"      // Read and send private messages
+	ScopeManageGroups = "groups:manage" // Create groups and handle join requests
+)
+
+// APITokenScopes lists every scope a token may be granted
+var APITokenScopes = []string{ScopeReadFeed, ScopePost, ScopeMessage, ScopeManageGroups}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditLogin                  = "auth.login"
+	AuditLoginFailed            = "auth.login_failed"
+	AuditTwoFactorEnabled       = "auth.2fa_enabled"
+	AuditTwoFactorDisabled      = "auth.2fa_disabled"
+	AuditSessionRevoked         = "session.revoked"
+	AuditOtherSessionsRevoked   = "session.revoked_others"
+	AuditAPITokenCreated        = "api_token.created"
+	AuditAPITokenRevoked        = "api_token.revoked"
+	AuditVisibilityChanged      = "profile.visibility_changed"
+	AuditGroupMemberAdded       = "group.member_added"
+	AuditAccountDeletionRequest = "account.deletion_requested"
+	AuditAccountDeletionCancel  = "account.deletion_cancelled"
+	AuditAccountPurged          = "account.purged"
+	AuditUserSuspended          = "admin.user_suspended"
+	AuditUserReinstated         = "admin.user_reinstated"
+	AuditUserLoggedOut          = "admin.user_logged_out"
+	AuditRoleChanged            = "admin.role_changed"
+	AuditPostDeleted            = "admin.post_deleted"
+	AuditCommentDeleted         = "admin.comment_deleted"
+	AuditReportResolved         = "moderation.report_resolved"
+)
+
+// Kinds of things an audit event can be about
+const (
+	AuditTargetUser     = "user"
+	AuditTargetSession  = "session"
+	AuditTargetAPIToken = "api_token"
+	AuditTargetGroup    = "group"
+	AuditTargetPost     = "post"
+	AuditTargetComment  = "comment"
+	AuditTargetReport   = "report"
+)
+
+// AuditEvent is one entry of the append-only audit log
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	Action     string          `json:"action"`
+	ActorID    string          `json:"actor_id,omitempty"` // empty for actions taken by the system
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   string          `json:"target_id,omitempty"`
+	IPAddress  string          `json:"ip_address,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	Diff       json.RawMessage `json:"diff"` // {"field": {"from": ..., "to": ...}}
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditChange is the before and after value of one field in an audit event's diff
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
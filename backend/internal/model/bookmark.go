@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// MaxCollectionNameLength is the longest name a bookmark collection can have
+const MaxCollectionNameLength = 50
+
+// Collection is a named group of a user's bookmarks. Other users may browse it unless it is private,
+// and see only the posts in it that they may see themselves.
+type Collection struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userid"`
+	Name      string    `json:"name"`
+	Private   bool      `json:"private"`
+	PostCount int       `json:"postcount"` // posts in the collection that the viewer may see
+	CreatedAt time.Time `json:"createdat"`
+}
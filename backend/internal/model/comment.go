@@ -51,6 +51,9 @@ type CommentWithUserInfo struct {
 	UserNickname  string `json:"user_nickname"`
 	UserImgURL    string `json:"user_img_url"`
 
+	// Users mentioned in the content
+	Mentions []Mention `json:"mentions,omitempty"`
+
 	// Nested replies
 	Replies []CommentWithUserInfo `json:"replies,omitempty"`
 }
@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// Data export job statuses
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is a job building a ZIP archive of everything a user has stored with us
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"-"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// The records below are what ends up in the JSON files of an export archive
+
+// ExportProfile is the user's own profile, without the password hash
+type ExportProfile struct {
+	ID                string    `json:"id"`
+	Email             string    `json:"email"`
+	FirstName         string    `json:"first_name"`
+	LastName          string    `json:"last_name"`
+	DOB               time.Time `json:"dob"`
+	ImgURL            string    `json:"img_url,omitempty"`
+	Nickname          string    `json:"nickname,omitempty"`
+	About             string    `json:"about,omitempty"`
+	ProfileVisibility string    `json:"profile_visibility"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ExportPost is a post written by the user
+type ExportPost struct {
+	ID               string      `json:"id"`
+	Title            string      `json:"title"`
+	Content          string      `json:"content"`
+	Visibility       string      `json:"visibility"`
+	ImageURL         string      `json:"image_url,omitempty"`
+	Media            []PostMedia `json:"media,omitempty"`
+	GroupID          string      `json:"group_id,omitempty"`
+	AllowedFollowers []string    `json:"allowed_followers,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
+
+// ExportComment is a comment written by the user
+type ExportComment struct {
+	ID        string    `json:"id"`
+	PostID    string    `json:"post_id"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExportReaction is a like or dislike the user left on a post
+type ExportReaction struct {
+	PostID    string    `json:"post_id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportMessage is a private message the user sent or received
+type ExportMessage struct {
+	ID         string    `json:"id"`
+	SenderID   string    `json:"sender_id"`
+	ReceiverID string    `json:"receiver_id"`
+	Content    string    `json:"content"`
+	Read       bool      `json:"read"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ExportFollow is one side of a follow relationship
+type ExportFollow struct {
+	UserID    string    `json:"user_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportGroupMembership is a group the user belongs to or has asked to join
+type ExportGroupMembership struct {
+	GroupID  int64     `json:"group_id"`
+	Title    string    `json:"title"`
+	Role     string    `json:"role"`
+	Status   string    `json:"status"`
+	Creator  bool      `json:"creator"`
+	JoinedAt time.Time `json:"joined_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// LoginAttempt is one password login attempt, kept for throttling and as an audit trail for the account owner
+type LoginAttempt struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"-"`
+	UserID    string    `json:"-"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
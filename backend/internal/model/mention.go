@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Mention is a user referred to by @nickname in a post, a comment or a chat message.
+// Clients turn the written nickname into a link to the user.
+type Mention struct {
+	ID        string    `json:"-"`
+	UserID    string    `json:"user_id"`
+	Nickname  string    `json:"nickname"` // as written, without the @
+	AuthorID  string    `json:"-"`
+	PostID    string    `json:"-"` // set for mentions in posts and their comments
+	CommentID string    `json:"-"`
+	MessageID string    `json:"-"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// MentionSuggestion is a user offered while typing an @mention
+type MentionSuggestion struct {
+	ID        string `json:"id"`
+	Nickname  string `json:"nickname"`
+	FirstName string `json:"firstname"`
+	LastName  string `json:"lastname"`
+	ImgURL    string `json:"imgurl,omitempty"`
+	Following bool   `json:"following"` // whether the user asking follows them
+}
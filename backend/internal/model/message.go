@@ -1,10 +1,12 @@
 package model
 
 type Message struct {
-	Type      string `json:"type,omitempty"` // "message" or "typing"
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Content   string `json:"content,omitempty"`
-	Timestamp string `json:"timestamp,omitempty"`
-	IsTyping  bool   `json:"isTyping,omitempty"`
-}
\ No newline at end of file
+	ID        string    `json:"id,omitempty"`
+	Type      string    `json:"type,omitempty"` // "message" or "typing"
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Content   string    `json:"content,omitempty"`
+	Timestamp string    `json:"timestamp,omitempty"`
+	IsTyping  bool      `json:"isTyping,omitempty"`
+	Mentions  []Mention `json:"mentions,omitempty"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Types of notification
+const (
+	NotificationMention = "mention" // the user was mentioned in a post, comment or message
+)
+
+// Notification tells a user about something that involves them
+type Notification struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Type      string     `json:"type"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	ActorName string     `json:"actor_name,omitempty"`
+	ActorImg  string     `json:"actor_img,omitempty"`
+	MentionID string     `json:"-"`
+	PostID    string     `json:"post_id,omitempty"`
+	CommentID string     `json:"comment_id,omitempty"`
+	MessageID string     `json:"message_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"` // nil while unread
+}
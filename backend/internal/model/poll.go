@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Limits of a poll
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 10
+	MaxPollOptionLength = 100
+)
+
+// Poll lets the users who can see a post vote for one of its options, or for several of them if the
+// poll is multiple choice
+type Poll struct {
+	PostID         string       `json:"postid"`
+	MultipleChoice bool         `json:"multiplechoice"`
+	HideResults    bool         `json:"hideresults"`        // results are hidden from users until they vote or the poll closes
+	ClosesAt       *time.Time   `json:"closesat,omitempty"` // nil for polls that stay open
+	Closed         bool         `json:"closed"`
+	Options        []PollOption `json:"options"`
+	Voters         int          `json:"voters"`
+	Voted          bool         `json:"voted"`         // whether the viewer has voted
+	ResultsHidden  bool         `json:"resultshidden"` // whether the counts were left out for the viewer, see HideResults
+}
+
+// PollOption is one of the answers of a poll
+type PollOption struct {
+	ID       string `json:"id"`
+	Text     string `json:"text"`
+	Position int    `json:"position"`
+	Votes    int    `json:"votes"`
+	Voted    bool   `json:"voted"` // whether the viewer voted for it
+}
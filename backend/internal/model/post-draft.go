@@ -0,0 +1,46 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PostDraft is a post its author has not published yet. A draft may be incomplete until it is published,
+// either by its author or, once PublishAt is due, by the scheduler.
+type PostDraft struct {
+	ID               string      `json:"id"`
+	UserID           string      `json:"userid"`
+	Title            string      `json:"title"`
+	Content          string      `json:"content"`
+	Visibility       string      `json:"status"`
+	GroupID          string      `json:"groupid,omitempty"`
+	QuoteOf          string      `json:"quoteof,omitempty"`
+	AllowedFollowers []string    `json:"allowedfollowers"`
+	Media            []PostMedia `json:"media"`
+	Poll             *Poll       `json:"poll,omitempty"`         // the poll to publish with the post, without votes
+	PublishAt        *time.Time  `json:"publishat,omitempty"`    // nil unless the draft is scheduled
+	PublishError     string      `json:"publisherror,omitempty"` // why publishing the scheduled post last failed
+	CreatedAt        time.Time   `json:"createdat"`
+	UpdatedAt        time.Time   `json:"updatedat"`
+}
+
+// Post returns the post the draft becomes once published, without an ID or creation time.
+func (d *PostDraft) Post() Post {
+	post := Post{
+		UserId:           d.UserID,
+		Title:            d.Title,
+		Content:          d.Content,
+		Visibility:       d.Visibility,
+		QuoteOf:          d.QuoteOf,
+		AllowedFollowers: d.AllowedFollowers,
+		Media:            d.Media,
+		Poll:             d.Poll,
+	}
+	if d.GroupID != "" {
+		post.GroupId = sql.NullString{String: d.GroupID, Valid: true}
+	}
+	if len(d.Media) > 0 {
+		post.ImageUrl = sql.NullString{String: d.Media[0].URL, Valid: true}
+	}
+	return post
+}
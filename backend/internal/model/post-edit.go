@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// PostEdit is the version of a post as it was before one of its edits
+type PostEdit struct {
+	ID               string      `json:"id"`
+	PostID           string      `json:"post_id"`
+	EditorID         string      `json:"editor_id,omitempty"`
+	Title            string      `json:"title"`
+	Content          string      `json:"content"`
+	Visibility       string      `json:"visibility"`
+	ImageURL         string      `json:"image_url,omitempty"`
+	Media            []PostMedia `json:"media"`
+	AllowedFollowers []string    `json:"allowed_followers"`
+	EditedAt         time.Time   `json:"edited_at"`
+}
@@ -0,0 +1,31 @@
+package model
+
+// MaxPostMedia is the number of images a post can hold
+const MaxPostMedia = 10
+
+// PostMedia is one image of a post's gallery. URL is the full size image, the smaller
+// variants and the placeholder are empty for images uploaded before they were generated.
+type PostMedia struct {
+	ID            string `json:"id"`
+	PostID        string `json:"-"`
+	URL           string `json:"url"`
+	ThumbnailURL  string `json:"thumbnail_url,omitempty"`
+	FeedURL       string `json:"feed_url,omitempty"`
+	AltText       string `json:"alt_text"`
+	Width         int    `json:"width"`  // 0 when unknown
+	Height        int    `json:"height"` // 0 when unknown
+	Blurhash      string `json:"blurhash,omitempty"`
+	DominantColor string `json:"dominant_color,omitempty"` // as #rrggbb
+	Position      int    `json:"position"`
+}
+
+// Files returns the web paths of every file stored for the image
+func (m PostMedia) Files() []string {
+	var files []string
+	for _, url := range []string{m.URL, m.FeedURL, m.ThumbnailURL} {
+		if url != "" {
+			files = append(files, url)
+		}
+	}
+	return files
+}
@@ -0,0 +1,14 @@
+package model
+
+// PostRank explains where a post landed in the ranked feed
+type PostRank struct {
+	Score       float64 `json:"score"`
+	AgeHours    float64 `json:"age_hours"`
+	Recency     float64 `json:"recency"` // decay factor the boosts are multiplied by, 1 for a new post
+	Likes       int     `json:"likes"`
+	Dislikes    int     `json:"dislikes"`
+	Comments    int     `json:"comments"`
+	Engagement  float64 `json:"engagement"` // from 0 to 1
+	Affinity    float64 `json:"affinity"`   // from 0 to 1, how much the viewer interacts with the author
+	GroupMember bool    `json:"group_member"`
+}
@@ -11,11 +11,35 @@ type Post struct {
 	Title            string         `json:"title"`
 	Content          string         `json:"content"`
 	Visibility       string         `json:"status"`
-	ImageUrl         sql.NullString `json:"imageurl"`
+	ImageUrl         sql.NullString `json:"imageurl"` // the first of Media, kept for older clients
 	CreatedAt        time.Time      `json:"createdat"`
 	AllowedFollowers []string       `json:"allowedfollowers,omitempty"`
 	Creator          string         `json:"creator,omitempty"`
 	CreatorImg       string         `json:"creatorimg,omitempty"`
 	CommentCount     int            `json:"commentcount,omitempty"`
 	GroupId          sql.NullString `json:"groupid,omitempty"`
+	UpdatedAt        *time.Time     `json:"updatedat,omitempty"` // nil until the post is edited
+	Rank             *PostRank      `json:"rank,omitempty"`      // only shown to admins debugging the ranked feed
+	Media            []PostMedia    `json:"media"`
+	Mentions         []Mention      `json:"mentions,omitempty"` // users mentioned in the title and content
+	Poll             *Poll          `json:"poll,omitempty"`
+
+	// A repost shares the post RepostOf as it is: its author is the user who reshared it and it has no
+	// title, content or images of its own. A quote shares QuoteOf below its own title and content.
+	RepostOf       string `json:"repostof,omitempty"`
+	QuoteOf        string `json:"quoteof,omitempty"`
+	Original       *Post  `json:"original,omitempty"`       // the post reposted or quoted, if the viewer may see it
+	OriginalStatus string `json:"originalstatus,omitempty"` // why a quote has no Original, see OriginalDeleted
+	RepostCount    int    `json:"repostcount,omitempty"`
+	QuoteCount     int    `json:"quotecount,omitempty"`
+	Reposted       bool   `json:"reposted,omitempty"` // whether the viewer has reposted the post
+
+	Saved   bool       `json:"saved"`             // whether the viewer has bookmarked the post
+	SavedAt *time.Time `json:"savedat,omitempty"` // when, in lists of bookmarks
 }
+
+// Values of Post.OriginalStatus
+const (
+	OriginalDeleted     = "deleted"     // the quoted post was deleted, leaving a tombstone
+	OriginalUnavailable = "unavailable" // the quoted post was hidden or the viewer may not see it
+)
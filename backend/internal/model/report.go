@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// Kinds of content a report can be about
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+	ReportTargetMessage = "message"
+	ReportTargetGroup   = "group"
+	ReportTargetUser    = "user"
+)
+
+// ReportReasons are the categories a reporter picks from
+var ReportReasons = []string{"spam", "harassment", "hate", "violence", "nudity", "misinformation", "other"}
+
+// Report statuses in the moderation queue
+const (
+	ReportOpen     = "open"
+	ReportClaimed  = "claimed"
+	ReportResolved = "resolved"
+)
+
+// Ways a moderator can resolve a report
+const (
+	ResolutionHide    = "hide"    // hide the reported content
+	ResolutionWarn    = "warn"    // record a warning against the author
+	ResolutionSuspend = "suspend" // suspend the author
+	ResolutionDismiss = "dismiss" // take no action
+)
+
+// Report is a user's complaint about a piece of content or another user
+type Report struct {
+	ID             string     `json:"id"`
+	ReporterID     string     `json:"reporter_id"`
+	TargetType     string     `json:"target_type"`
+	TargetID       string     `json:"target_id"`
+	TargetUserID   string     `json:"target_user_id,omitempty"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details,omitempty"`
+	Status         string     `json:"status"`
+	ClaimedBy      string     `json:"claimed_by,omitempty"`
+	ClaimedAt      *time.Time `json:"claimed_at,omitempty"`
+	Resolution     string     `json:"resolution,omitempty"`
+	ResolutionNote string     `json:"resolution_note,omitempty"`
+	ResolvedBy     string     `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Target is filled in for moderators so they can judge the report without looking it up
+	Target *ReportTarget `json:"target,omitempty"`
+}
+
+// ReportTarget is the reported content as it currently stands
+type ReportTarget struct {
+	AuthorID    string `json:"author_id,omitempty"`
+	RecipientID string `json:"recipient_id,omitempty"` // the other participant of a reported message
+	Preview     string `json:"preview"`
+	Hidden      bool   `json:"hidden"`
+}
+
+// UserWarning is a warning a moderator issued to a user
+type UserWarning struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	ReportID  string    `json:"report_id,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
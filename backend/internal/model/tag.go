@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// TrendingTag is a hashtag in the trending list
+type TrendingTag struct {
+	Tag        string    `json:"tag"`
+	Users      int       `json:"users"` // people who used it within the trending window
+	Score      float64   `json:"score"` // users weighted by how recently they used it
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// TagUse is one post or comment using a hashtag
+type TagUse struct {
+	Tag       string
+	UserID    string
+	CreatedAt time.Time
+}
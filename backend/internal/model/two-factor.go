@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// TwoFactor holds a user's TOTP enrollment
+type TwoFactor struct {
+	UserID       string     `json:"user_id"`
+	Secret       string     `json:"-"`
+	Enabled      bool       `json:"enabled"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// LoginChallenge is the pending second step of a login for a user with 2FA enabled
+type LoginChallenge struct {
+	ID        string    `json:"-"` // SHA-256 of the token handed to the client
+	UserID    string    `json:"user_id"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
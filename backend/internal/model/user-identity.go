@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// UserIdentity links an account at an external OIDC provider to a local user
+type UserIdentity struct {
+	ID          int64      `json:"id"`
+	UserID      string     `json:"-"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// OIDCLoginState is an authorization request that has been sent to a provider and not yet completed
+type OIDCLoginState struct {
+	ID           string // SHA-256 of the state parameter
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+}
+
+// OIDCPendingSignup is a verified external identity without a local account yet,
+// waiting for the user to supply the fields the provider does not share
+type OIDCPendingSignup struct {
+	ID        string    `json:"-"` // SHA-256 of the signup token handed to the client
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"-"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Picture   string    `json:"picture,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
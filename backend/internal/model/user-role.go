@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// Site-wide roles. Each role has every permission of the ones before it.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists the site-wide roles from least to most privileged
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// RoleRank orders roles by privilege. Unknown roles rank below RoleUser.
+func RoleRank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasRole reports whether role grants at least the privileges of minimum
+func HasRole(role, minimum string) bool {
+	return RoleRank(role) >= RoleRank(minimum) && RoleRank(minimum) >= 0
+}
+
+// UserSuspension records why and until when a user is locked out of the site
+type UserSuspension struct {
+	SuspendedAt time.Time  `json:"suspended_at"`
+	Until       *time.Time `json:"until,omitempty"` // nil until a moderator reinstates the user
+	Reason      string     `json:"reason"`
+	SuspendedBy string     `json:"suspended_by,omitempty"`
+}
+
+// ActiveAt reports whether the suspension is in force at t
+func (s *UserSuspension) ActiveAt(t time.Time) bool {
+	return s != nil && (s.Until == nil || t.Before(*s.Until))
+}
+
+// UserAccess is what the auth middleware needs beyond the profile to decide whether a user may act
+type UserAccess struct {
+	Role       string
+	Suspension *UserSuspension
+}
+
+// AdminUser is a user as listed in the administration API
+type AdminUser struct {
+	ID         string          `json:"id"`
+	Email      string          `json:"email"`
+	FirstName  string          `json:"first_name"`
+	LastName   string          `json:"last_name"`
+	Nickname   string          `json:"nickname,omitempty"`
+	Role       string          `json:"role"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Suspension *UserSuspension `json:"suspension,omitempty"`
+}
@@ -13,5 +13,6 @@ type User struct {
 	About             string    `json:"about,omitempty" db:"about"`
 	Password          string    `json:"password" db:"password"`
 	ProfileVisibility string    `json:"profile_visibility" db:"profileVisibility"`
+	Role              string    `json:"role,omitempty" db:"role"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 }
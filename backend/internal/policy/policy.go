@@ -0,0 +1,74 @@
+// Package policy decides who may see and act on posts and groups. Handlers ask it before
+// reading or changing content instead of checking relationships themselves.
+package policy
+
+import (
+	"database/sql"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// Policy answers access questions from the current state of posts, followers and group memberships
+type Policy struct {
+	DB     *sql.DB
+	Groups *repository.GroupRepository
+}
+
+// New creates and returns a new instance of Policy.
+func New(db *sql.DB) *Policy {
+	return &Policy{DB: db, Groups: repository.NewGroupRepository(db)}
+}
+
+// CanViewPost reports whether the post exists and the user may see it.
+// The rules are shared with the feed queries, see repository.PostVisibleTo.
+func (p *Policy) CanViewPost(userID, postID string) (bool, error) {
+	return repository.PostVisibleTo(p.DB, postID, userID)
+}
+
+// CanComment reports whether the user may comment on the post. Everyone who can see a post may.
+func (p *Policy) CanComment(userID, postID string) (bool, error) {
+	return p.CanViewPost(userID, postID)
+}
+
+// CanReact reports whether the user may like or dislike the post. Everyone who can see a post may.
+func (p *Policy) CanReact(userID, postID string) (bool, error) {
+	return p.CanViewPost(userID, postID)
+}
+
+// CanViewMedia reports whether the user, "" when anonymous, may see the uploaded file at webPath,
+// and whether everyone may so that it can be cached publicly. See repository.MediaAccess.
+func (p *Policy) CanViewMedia(userID, webPath string) (visible, public bool, err error) {
+	return repository.MediaAccess(p.DB, webPath, userID)
+}
+
+// CanViewGroup reports whether the group exists and the user may see its details.
+// Only the group's posts are limited to members, see CanViewPost.
+func (p *Policy) CanViewGroup(userID string, groupID uint) (bool, error) {
+	group, err := p.Groups.FindGroupByID(groupID)
+	if err != nil || group == nil {
+		return false, err
+	}
+	_, status, err := p.Groups.CheckUserMembership(groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	return GroupVisible(group, userID, status), nil
+}
+
+// GroupVisible is the rule behind CanViewGroup for a group that is already loaded, with the user's
+// membership status or "" if they have none. Secret groups are only shown to their creator and to
+// users with an active or pending membership, everyone else can find public and private groups.
+func GroupVisible(group *model.Group, userID, memberStatus string) bool {
+	return group.PrivacySetting != "secret" || group.CreatorID == userID || memberStatus != ""
+}
+
+// CanPostInGroup reports whether the user may post in the group, which takes an active membership.
+func (p *Policy) CanPostInGroup(userID string, groupID uint) (bool, error) {
+	group, err := p.Groups.FindGroupByID(groupID)
+	if err != nil || group == nil {
+		return false, err
+	}
+	_, status, err := p.Groups.CheckUserMembership(groupID, userID)
+	return status == "active", err
+}
@@ -0,0 +1,149 @@
+package policy
+
+import (
+	"testing"
+
+	"backend/pkg/db/dbtest"
+)
+
+func newTestPolicy(t *testing.T) *Policy {
+	t.Helper()
+	db := dbtest.New(t)
+
+	for _, id := range []string{"author", "follower", "requested", "picked", "creator", "member", "pending", "stranger"} {
+		db.Exec(`INSERT INTO users (id, email, fname, lname, dob, password) VALUES (?, ?, 'Test', 'User', '2000-01-01', 'hash')`, id, id+"@example.com")
+	}
+	db.Exec(`INSERT INTO followers (follower_id, followed_id, status) VALUES ('follower', 'author', 'accepted'), ('picked', 'author', 'accepted'), ('requested', 'author', 'requested'), ('author', 'stranger', 'accepted')`)
+	db.Exec(`INSERT INTO groups (id, title, description, creator_id, privacy_setting) VALUES (1, 'Open', '', 'creator', 'public'), (2, 'Closed', '', 'creator', 'private'), (3, 'Hidden', '', 'creator', 'secret')`)
+	for _, group := range []int{1, 2, 3} {
+		db.Exec(`INSERT INTO group_members (group_id, user_id, role, status) VALUES (?, 'creator', 'admin', 'active'), (?, 'member', 'member', 'active'), (?, 'pending', 'member', 'pending')`, group, group, group)
+	}
+	db.Exec(`INSERT INTO posts (id, user_id, title, content, visibility) VALUES ('public', 'author', 'T', 'C', 'public'), ('almostprivate', 'author', 'T', 'C', 'almostprivate'), ('private', 'author', 'T', 'C', 'private')`)
+	db.Exec(`INSERT INTO posts (id, user_id, title, content, visibility, group_id) VALUES ('open-group', 'member', 'T', 'C', 'public', '1'), ('secret-group', 'member', 'T', 'C', 'public', '3')`)
+	db.Exec(`INSERT INTO private_posts (post_id, user_id, created_at) VALUES ('private', 'picked', CURRENT_TIMESTAMP)`)
+	db.Exec(`UPDATE users SET imgurl = '/uploads/avatars/a.png' WHERE id = 'author'`)
+	db.Exec(`UPDATE posts SET post_image = '/uploads/posts/public.png' WHERE id = 'public'`)
+	db.Exec(`INSERT INTO post_media (id, post_id, url, feed_url, thumbnail_url, position) VALUES
+        ('m1', 'private', '/uploads/posts/private.png', '/uploads/posts/private-feed.png', '/uploads/posts/private-thumb.png', 0),
+        ('m2', 'secret-group', '/uploads/posts/group.png', '', '', 0),
+        ('m3', 'private', '/uploads/posts/shared.png', '', '', 1),
+        ('m4', 'public', '/uploads/posts/shared.png', '', '', 1)`)
+
+	return New(db)
+}
+
+func TestPostAccess(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		post    string
+		user    string
+		allowed bool
+	}{
+		{"public", "author", true},
+		{"public", "stranger", true},
+		{"almostprivate", "author", true},
+		{"almostprivate", "follower", true},
+		{"almostprivate", "requested", false},
+		{"almostprivate", "stranger", false}, // followed by the author, not following them
+		{"private", "author", true},
+		{"private", "picked", true},
+		{"private", "follower", false},
+		{"private", "stranger", false},
+		{"open-group", "member", true},
+		{"open-group", "creator", true},
+		{"open-group", "pending", false},
+		{"open-group", "stranger", false},
+		{"secret-group", "member", true},
+		{"secret-group", "pending", false},
+		{"secret-group", "author", false},
+		{"missing", "author", false},
+	}
+	checks := map[string]func(userID, postID string) (bool, error){
+		"CanViewPost": p.CanViewPost,
+		"CanComment":  p.CanComment,
+		"CanReact":    p.CanReact,
+	}
+	for _, tt := range tests {
+		for name, check := range checks {
+			allowed, err := check(tt.user, tt.post)
+			if err != nil {
+				t.Fatalf("%s(%s, %s) failed: %v", name, tt.user, tt.post, err)
+			}
+			if allowed != tt.allowed {
+				t.Errorf("%s(%s, %s) = %v, want %v", name, tt.user, tt.post, allowed, tt.allowed)
+			}
+		}
+	}
+}
+
+func TestGroupAccess(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		group uint
+		user  string
+		view  bool
+		post  bool
+	}{
+		{1, "creator", true, true},
+		{1, "member", true, true},
+		{1, "pending", true, false},
+		{1, "stranger", true, false},
+		{2, "member", true, true},
+		{2, "stranger", true, false},
+		{3, "creator", true, true},
+		{3, "member", true, true},
+		{3, "pending", true, false},
+		{3, "stranger", false, false},
+		{4, "creator", false, false},
+	}
+	for _, tt := range tests {
+		view, err := p.CanViewGroup(tt.user, tt.group)
+		if err != nil {
+			t.Fatalf("CanViewGroup(%s, %d) failed: %v", tt.user, tt.group, err)
+		}
+		if view != tt.view {
+			t.Errorf("CanViewGroup(%s, %d) = %v, want %v", tt.user, tt.group, view, tt.view)
+		}
+		post, err := p.CanPostInGroup(tt.user, tt.group)
+		if err != nil {
+			t.Fatalf("CanPostInGroup(%s, %d) failed: %v", tt.user, tt.group, err)
+		}
+		if post != tt.post {
+			t.Errorf("CanPostInGroup(%s, %d) = %v, want %v", tt.user, tt.group, post, tt.post)
+		}
+	}
+}
+
+func TestMediaAccess(t *testing.T) {
+	p := newTestPolicy(t)
+
+	tests := []struct {
+		path    string
+		user    string
+		visible bool
+		public  bool
+	}{
+		{"/uploads/avatars/a.png", "", true, true},
+		{"/uploads/posts/public.png", "", true, true},
+		{"/uploads/posts/public.png", "stranger", true, true},
+		{"/uploads/posts/private.png", "author", true, false},
+		{"/uploads/posts/private-thumb.png", "picked", true, false},
+		{"/uploads/posts/private-feed.png", "follower", false, false},
+		{"/uploads/posts/private.png", "", false, false},
+		{"/uploads/posts/group.png", "member", true, false},
+		{"/uploads/posts/group.png", "stranger", false, false},
+		{"/uploads/posts/shared.png", "", true, true}, // also used by the public post
+		{"/uploads/posts/unused.png", "author", false, false},
+	}
+	for _, tt := range tests {
+		visible, public, err := p.CanViewMedia(tt.user, tt.path)
+		if err != nil {
+			t.Fatalf("CanViewMedia(%q, %q) failed: %v", tt.user, tt.path, err)
+		}
+		if visible != tt.visible || public != tt.public {
+			t.Errorf("CanViewMedia(%q, %q) = %v, %v, expected %v, %v", tt.user, tt.path, visible, public, tt.visible, tt.public)
+		}
+	}
+}
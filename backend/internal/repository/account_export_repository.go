@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+
+	"backend/internal/model"
+)
+
+// The queries below collect a user's data for an export archive
+
+// ExportProfile retrieves the user's profile, or nil if the user does not exist.
+func (r *AccountRepository) ExportProfile(userID string) (*model.ExportProfile, error) {
+	var profile model.ExportProfile
+	var createdAt sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT id, email, fname, lname, dob, COALESCE(imgurl, ''), COALESCE(nickname, ''), COALESCE(about, ''), profileVisibility, created_at
+		FROM users
+		WHERE id = ?
+	`, userID).Scan(&profile.ID, &profile.Email, &profile.FirstName, &profile.LastName, &profile.DOB,
+		&profile.ImgURL, &profile.Nickname, &profile.About, &profile.ProfileVisibility, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile.CreatedAt = createdAt.Time
+	return &profile, nil
+}
+
+// ExportPosts returns the user's posts, oldest first, with the followers chosen for private posts.
+func (r *AccountRepository) ExportPosts(userID string) ([]model.ExportPost, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, title, content, visibility, COALESCE(post_image, ''), COALESCE(group_id, ''), created_at
+		FROM posts
+		WHERE user_id = ?
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.ExportPost
+	index := map[string]int{}
+	for rows.Next() {
+		var post model.ExportPost
+		var createdAt sql.NullTime
+		if err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.Visibility, &post.ImageURL, &post.GroupID, &createdAt); err != nil {
+			return nil, err
+		}
+		post.CreatedAt = createdAt.Time
+		index[post.ID] = len(posts)
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	allowed, err := r.DB.Query(`
+		SELECT pp.post_id, pp.user_id
+		FROM private_posts pp
+		JOIN posts p ON p.id = pp.post_id
+		WHERE p.user_id = ?
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer allowed.Close()
+
+	for allowed.Next() {
+		var postID, followerID string
+		if err := allowed.Scan(&postID, &followerID); err != nil {
+			return nil, err
+		}
+		if i, ok := index[postID]; ok {
+			posts[i].AllowedFollowers = append(posts[i].AllowedFollowers, followerID)
+		}
+	}
+	if err := allowed.Err(); err != nil {
+		return nil, err
+	}
+	allowed.Close()
+
+	media, err := r.DB.Query(`
+		SELECT pm.id, pm.post_id, pm.url, pm.thumbnail_url, pm.feed_url, pm.alt_text, pm.width, pm.height, pm.blurhash, pm.dominant_color, pm.position
+		FROM post_media pm
+		JOIN posts p ON p.id = pm.post_id
+		WHERE p.user_id = ?
+		ORDER BY pm.post_id, pm.position
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer media.Close()
+
+	for media.Next() {
+		var m model.PostMedia
+		if err := media.Scan(&m.ID, &m.PostID, &m.URL, &m.ThumbnailURL, &m.FeedURL, &m.AltText, &m.Width, &m.Height,
+			&m.Blurhash, &m.DominantColor, &m.Position); err != nil {
+			return nil, err
+		}
+		if i, ok := index[m.PostID]; ok {
+			posts[i].Media = append(posts[i].Media, m)
+		}
+	}
+	return posts, media.Err()
+}
+
+// ExportComments returns the comments the user wrote, oldest first.
+func (r *AccountRepository) ExportComments(userID string) ([]model.ExportComment, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, post_id, COALESCE(parent_id, ''), content, created_at, updated_at
+		FROM comments
+		WHERE user_id = ?
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []model.ExportComment
+	for rows.Next() {
+		var comment model.ExportComment
+		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.ParentID, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+// ExportReactions returns the reactions the user left, oldest first.
+func (r *AccountRepository) ExportReactions(userID string) ([]model.ExportReaction, error) {
+	rows, err := r.DB.Query(`
+		SELECT post_id, type, created_at
+		FROM reactions
+		WHERE user_id = ?
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reactions []model.ExportReaction
+	for rows.Next() {
+		var reaction model.ExportReaction
+		var createdAt sql.NullTime
+		if err := rows.Scan(&reaction.PostID, &reaction.Type, &createdAt); err != nil {
+			return nil, err
+		}
+		reaction.CreatedAt = createdAt.Time
+		reactions = append(reactions, reaction)
+	}
+	return reactions, rows.Err()
+}
+
+// ExportMessages returns the private messages the user sent or received, oldest first.
+func (r *AccountRepository) ExportMessages(userID string) ([]model.ExportMessage, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, COALESCE(sender_id, ''), COALESCE(receiver_id, ''), content, COALESCE(read, 0), created_at
+		FROM messages
+		WHERE sender_id = ? OR receiver_id = ?
+		ORDER BY created_at
+	`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []model.ExportMessage
+	for rows.Next() {
+		var message model.ExportMessage
+		var createdAt sql.NullTime
+		if err := rows.Scan(&message.ID, &message.SenderID, &message.ReceiverID, &message.Content, &message.Read, &createdAt); err != nil {
+			return nil, err
+		}
+		message.CreatedAt = createdAt.Time
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// ExportFollowers returns the users following the user, including pending requests.
+func (r *AccountRepository) ExportFollowers(userID string) ([]model.ExportFollow, error) {
+	return r.queryFollows(`
+		SELECT u.id, u.fname, u.lname, f.status, f.created_at
+		FROM followers f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followed_id = ?
+		ORDER BY f.created_at
+	`, userID)
+}
+
+// ExportFollowing returns the users the user follows or has asked to follow.
+func (r *AccountRepository) ExportFollowing(userID string) ([]model.ExportFollow, error) {
+	return r.queryFollows(`
+		SELECT u.id, u.fname, u.lname, f.status, f.created_at
+		FROM followers f
+		JOIN users u ON u.id = f.followed_id
+		WHERE f.follower_id = ?
+		ORDER BY f.created_at
+	`, userID)
+}
+
+func (r *AccountRepository) queryFollows(query, userID string) ([]model.ExportFollow, error) {
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var follows []model.ExportFollow
+	for rows.Next() {
+		var follow model.ExportFollow
+		var createdAt sql.NullTime
+		if err := rows.Scan(&follow.UserID, &follow.FirstName, &follow.LastName, &follow.Status, &createdAt); err != nil {
+			return nil, err
+		}
+		follow.CreatedAt = createdAt.Time
+		follows = append(follows, follow)
+	}
+	return follows, rows.Err()
+}
+
+// ExportGroupMemberships returns the groups the user is a member of or has asked to join.
+func (r *AccountRepository) ExportGroupMemberships(userID string) ([]model.ExportGroupMembership, error) {
+	rows, err := r.DB.Query(`
+		SELECT g.id, g.title, gm.role, gm.status, g.creator_id = gm.user_id, gm.joined_at
+		FROM group_members gm
+		JOIN groups g ON g.id = gm.group_id
+		WHERE gm.user_id = ? AND gm.deleted_at IS NULL AND g.deleted_at IS NULL
+		ORDER BY gm.joined_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memberships []model.ExportGroupMembership
+	for rows.Next() {
+		var membership model.ExportGroupMembership
+		if err := rows.Scan(&membership.GroupID, &membership.Title, &membership.Role, &membership.Status, &membership.Creator, &membership.JoinedAt); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+	return memberships, rows.Err()
+}
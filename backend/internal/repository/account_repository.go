@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+// AccountRepository handles database operations for account deletion and data exports
+type AccountRepository struct {
+	DB *sql.DB
+}
+
+// NewAccountRepository creates and returns a new instance of AccountRepository.
+func NewAccountRepository(db *sql.DB) *AccountRepository {
+	return &AccountRepository{DB: db}
+}
+
+// CreateDeletion schedules an account for deletion.
+func (r *AccountRepository) CreateDeletion(deletion *model.AccountDeletion) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO account_deletions (user_id, requested_at, purge_after)
+		VALUES (?, ?, ?)
+	`, deletion.UserID, deletion.RequestedAt, deletion.PurgeAfter)
+	return err
+}
+
+// FindDeletion retrieves the pending deletion of an account, or nil if none is scheduled.
+func (r *AccountRepository) FindDeletion(userID string) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+	err := r.DB.QueryRow(`
+		SELECT user_id, requested_at, purge_after
+		FROM account_deletions
+		WHERE user_id = ?
+	`, userID).Scan(&deletion.UserID, &deletion.RequestedAt, &deletion.PurgeAfter)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// DeleteDeletion cancels a scheduled deletion and reports whether there was one.
+func (r *AccountRepository) DeleteDeletion(userID string) (bool, error) {
+	result, err := r.DB.Exec(`DELETE FROM account_deletions WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// FindDueDeletions returns the deletions whose grace period has ended by now.
+func (r *AccountRepository) FindDueDeletions(now time.Time) ([]model.AccountDeletion, error) {
+	rows, err := r.DB.Query(`
+		SELECT user_id, requested_at, purge_after
+		FROM account_deletions
+		WHERE julianday(purge_after) <= julianday(?)
+		ORDER BY purge_after
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []model.AccountDeletion
+	for rows.Next() {
+		var deletion model.AccountDeletion
+		if err := rows.Scan(&deletion.UserID, &deletion.RequestedAt, &deletion.PurgeAfter); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, rows.Err()
+}
+
+// FindUploadPaths returns the web paths of every file the user uploaded: the avatar, and the images of their posts,
+// earlier versions of their posts and drafts with their smaller variants.
+func (r *AccountRepository) FindUploadPaths(userID string) ([]string, error) {
+	rows, err := r.DB.Query(`
+		SELECT imgurl FROM users WHERE id = ? AND imgurl IS NOT NULL AND imgurl != ''
+		UNION
+		SELECT post_image FROM posts WHERE user_id = ? AND post_image IS NOT NULL AND post_image != ''
+		UNION
+		SELECT pm.url FROM post_media pm JOIN posts p ON p.id = pm.post_id WHERE p.user_id = ?
+		UNION
+		SELECT pm.feed_url FROM post_media pm JOIN posts p ON p.id = pm.post_id WHERE p.user_id = ? AND pm.feed_url != ''
+		UNION
+		SELECT pm.thumbnail_url FROM post_media pm JOIN posts p ON p.id = pm.post_id WHERE p.user_id = ? AND pm.thumbnail_url != ''
+		UNION
+		SELECT f.value FROM post_drafts d, json_each(d.media) m,
+			json_each(json_array(json_extract(m.value, '$.url'), json_extract(m.value, '$.feed_url'), json_extract(m.value, '$.thumbnail_url'))) f
+		WHERE d.user_id = ? AND f.value IS NOT NULL AND f.value != ''
+		UNION
+		SELECT e.post_image FROM post_edits e JOIN posts p ON p.id = e.post_id
+		WHERE p.user_id = ? AND e.post_image IS NOT NULL AND e.post_image != ''
+		UNION
+		SELECT f.value FROM post_edits e JOIN posts p ON p.id = e.post_id, json_each(e.media) m,
+			json_each(json_array(json_extract(m.value, '$.url'), json_extract(m.value, '$.feed_url'), json_extract(m.value, '$.thumbnail_url'))) f
+		WHERE p.user_id = ? AND f.value IS NOT NULL AND f.value != ''
+	`, userID, userID, userID, userID, userID, userID, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// PurgeUser deletes a user whose deletion is due, letting ON DELETE CASCADE remove their data.
+// It reports false if the deletion was cancelled or is not due anymore.
+func (r *AccountRepository) PurgeUser(userID string, now time.Time) (bool, error) {
+	// the check and the delete are one statement so a cancellation cannot slip in between
+	result, err := r.DB.Exec(`
+		DELETE FROM users
+		WHERE id = ? AND id IN (
+			SELECT user_id FROM account_deletions WHERE julianday(purge_after) <= julianday(?)
+		)
+	`, userID, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+const dataExportColumns = `id, user_id, status, file_path, size_bytes, created_at, started_at, completed_at, expires_at`
+
+// CreateExport stores a new export job.
+func (r *AccountRepository) CreateExport(export *model.DataExport) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO data_exports (id, user_id, status, created_at)
+		VALUES (?, ?, ?, ?)
+	`, export.ID, export.UserID, export.Status, export.CreatedAt)
+	return err
+}
+
+// FindExport retrieves one of a user's exports, or nil if it does not exist.
+func (r *AccountRepository) FindExport(userID, id string) (*model.DataExport, error) {
+	row := r.DB.QueryRow(`SELECT `+dataExportColumns+` FROM data_exports WHERE id = ? AND user_id = ?`, id, userID)
+	export, err := scanDataExport(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return export, err
+}
+
+// FindExportByID retrieves an export whoever it belongs to, or nil if it does not exist.
+func (r *AccountRepository) FindExportByID(id string) (*model.DataExport, error) {
+	row := r.DB.QueryRow(`SELECT `+dataExportColumns+` FROM data_exports WHERE id = ?`, id)
+	export, err := scanDataExport(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return export, err
+}
+
+// FindExportsByUserID returns all exports of a user, newest first.
+func (r *AccountRepository) FindExportsByUserID(userID string) ([]model.DataExport, error) {
+	return r.queryExports(`SELECT `+dataExportColumns+` FROM data_exports WHERE user_id = ? ORDER BY created_at DESC`, userID)
+}
+
+// FindExportsByStatus returns the exports in a status, oldest first.
+func (r *AccountRepository) FindExportsByStatus(status string) ([]model.DataExport, error) {
+	return r.queryExports(`SELECT `+dataExportColumns+` FROM data_exports WHERE status = ? ORDER BY created_at`, status)
+}
+
+// FindExpiredExports returns the finished exports whose download window has closed.
+func (r *AccountRepository) FindExpiredExports(now time.Time) ([]model.DataExport, error) {
+	return r.queryExports(`
+		SELECT `+dataExportColumns+`
+		FROM data_exports
+		WHERE expires_at IS NOT NULL AND julianday(expires_at) <= julianday(?)
+	`, now)
+}
+
+func (r *AccountRepository) queryExports(query string, args ...any) ([]model.DataExport, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []model.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *export)
+	}
+	return exports, rows.Err()
+}
+
+// ClaimExport moves a pending export to running and reports whether this caller got it.
+func (r *AccountRepository) ClaimExport(id string, now time.Time) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE data_exports SET status = ?, started_at = ?
+		WHERE id = ? AND status = ?
+	`, model.DataExportRunning, now, id, model.DataExportPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// CompleteExport records the archive of a finished export.
+func (r *AccountRepository) CompleteExport(id, filePath string, size int64, completedAt, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`
+		UPDATE data_exports SET status = ?, file_path = ?, size_bytes = ?, completed_at = ?, expires_at = ?
+		WHERE id = ?
+	`, model.DataExportReady, filePath, size, completedAt, expiresAt, id)
+	return err
+}
+
+// FailExport marks an export as failed. Failed exports expire like finished ones.
+func (r *AccountRepository) FailExport(id string, completedAt, expiresAt time.Time) error {
+	_, err := r.DB.Exec(`
+		UPDATE data_exports SET status = ?, completed_at = ?, expires_at = ?
+		WHERE id = ?
+	`, model.DataExportFailed, completedAt, expiresAt, id)
+	return err
+}
+
+// DeleteExport removes an export record.
+func (r *AccountRepository) DeleteExport(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM data_exports WHERE id = ?`, id)
+	return err
+}
+
+type dataExportScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDataExport(row dataExportScanner) (*model.DataExport, error) {
+	var export model.DataExport
+	var filePath sql.NullString
+	var startedAt, completedAt, expiresAt sql.NullTime
+	err := row.Scan(&export.ID, &export.UserID, &export.Status, &filePath, &export.SizeBytes,
+		&export.CreatedAt, &startedAt, &completedAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	export.FilePath = filePath.String
+	if startedAt.Valid {
+		export.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		export.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		export.ExpiresAt = &expiresAt.Time
+	}
+	return &export, nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// AdminRepository handles database operations for site administration
+type AdminRepository struct {
+	DB *sql.DB
+}
+
+// NewAdminRepository creates and returns a new instance of AdminRepository.
+func NewAdminRepository(db *sql.DB) *AdminRepository {
+	return &AdminRepository{DB: db}
+}
+
+// UserFilter narrows down the users listed by ListUsers
+type UserFilter struct {
+	Query     string // Matched against email, names and nickname
+	Role      string
+	Suspended *bool // Only users whose suspension is or is not in force at Now
+	Now       time.Time
+	Limit     int
+	Offset    int
+}
+
+const adminUserColumns = `id, email, fname, lname, COALESCE(nickname, ''), role, created_at,
+	suspended_at, suspended_until, suspension_reason, suspended_by`
+
+// activeSuspension matches users whose suspension is in force at the bound time
+const activeSuspension = `(suspended_at IS NOT NULL AND (suspended_until IS NULL OR julianday(suspended_until) > julianday(?)))`
+
+// ListUsers returns the users matching filter, newest first.
+func (r *AdminRepository) ListUsers(filter UserFilter) ([]model.AdminUser, error) {
+	var conditions []string
+	var args []any
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		pattern := "%" + escapeLike(strings.ToLower(q)) + "%"
+		conditions = append(conditions, `(LOWER(email) LIKE ? ESCAPE '\' OR LOWER(fname || ' ' || lname) LIKE ? ESCAPE '\' OR LOWER(COALESCE(nickname, '')) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern)
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, `role = ?`)
+		args = append(args, filter.Role)
+	}
+	if filter.Suspended != nil {
+		if *filter.Suspended {
+			conditions = append(conditions, activeSuspension)
+		} else {
+			conditions = append(conditions, `NOT `+activeSuspension)
+		}
+		args = append(args, filter.Now)
+	}
+
+	query := `SELECT ` + adminUserColumns + ` FROM users`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += ` ORDER BY created_at DESC, id LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.AdminUser
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// FindUser retrieves a user for administration, or nil if the user does not exist.
+func (r *AdminRepository) FindUser(userID string) (*model.AdminUser, error) {
+	row := r.DB.QueryRow(`SELECT `+adminUserColumns+` FROM users WHERE id = ?`, userID)
+	user, err := scanAdminUser(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return user, err
+}
+
+// SetRole changes the site-wide role of a user.
+func (r *AdminRepository) SetRole(userID, role string) error {
+	_, err := r.DB.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, userID)
+	return err
+}
+
+// PromoteByEmail gives role to the users with the given emails unless they already have a higher one.
+// It returns how many users were changed.
+func (r *AdminRepository) PromoteByEmail(emails []string, role string) (int64, error) {
+	var changed int64
+	for _, email := range emails {
+		result, err := r.DB.Exec(`UPDATE users SET role = ? WHERE LOWER(email) = LOWER(?) AND role != ?`, role, email, role)
+		if err != nil {
+			return changed, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return changed, err
+		}
+		changed += n
+	}
+	return changed, nil
+}
+
+// Suspend locks a user out until suspension.Until, or indefinitely if it is nil.
+func (r *AdminRepository) Suspend(userID string, suspension *model.UserSuspension) error {
+	_, err := r.DB.Exec(`
+		UPDATE users SET suspended_at = ?, suspended_until = ?, suspension_reason = ?, suspended_by = ?
+		WHERE id = ?
+	`, suspension.SuspendedAt, suspension.Until, suspension.Reason, suspension.SuspendedBy, userID)
+	return err
+}
+
+// Reinstate lifts a user's suspension.
+func (r *AdminRepository) Reinstate(userID string) error {
+	_, err := r.DB.Exec(`
+		UPDATE users SET suspended_at = NULL, suspended_until = NULL, suspension_reason = NULL, suspended_by = NULL
+		WHERE id = ?
+	`, userID)
+	return err
+}
+
+// DeleteSessions logs a user out everywhere and returns how many sessions were removed.
+func (r *AdminRepository) DeleteSessions(userID string) (int64, error) {
+	result, err := r.DB.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeletePost removes a post, its gallery, comments and reactions going with it through ON DELETE CASCADE.
+// It returns the web paths of the post's images, and false if there was no such post.
+func (r *AdminRepository) DeletePost(postID string) ([]string, bool, error) {
+	return deletePost(r.DB, postID)
+}
+
+// DeleteComment removes a comment and its replies, and reports whether it existed.
+func (r *AdminRepository) DeleteComment(commentID string) (bool, error) {
+	result, err := r.DB.Exec(`DELETE FROM comments WHERE id = ?`, commentID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+type adminUserScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAdminUser(row adminUserScanner) (*model.AdminUser, error) {
+	var user model.AdminUser
+	var createdAt, suspendedAt, suspendedUntil sql.NullTime
+	var reason, suspendedBy sql.NullString
+	err := row.Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Nickname, &user.Role, &createdAt,
+		&suspendedAt, &suspendedUntil, &reason, &suspendedBy)
+	if err != nil {
+		return nil, err
+	}
+	user.CreatedAt = createdAt.Time
+	user.Suspension = scanSuspension(suspendedAt, suspendedUntil, reason, suspendedBy)
+	return &user, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
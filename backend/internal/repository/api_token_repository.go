@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// APITokenRepository handles database operations for personal access tokens
+type APITokenRepository struct {
+	DB *sql.DB
+}
+
+// NewAPITokenRepository creates and returns a new instance of APITokenRepository.
+func NewAPITokenRepository(db *sql.DB) *APITokenRepository {
+	return &APITokenRepository{DB: db}
+}
+
+const apiTokenColumns = `id, user_id, name, token_hash, prefix, scopes, last_used_at, expires_at, created_at`
+
+// Create stores a new token.
+func (r *APITokenRepository) Create(token *model.APIToken) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO api_tokens (id, user_id, name, token_hash, prefix, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, token.ID, token.UserID, token.Name, token.TokenHash, token.Prefix, strings.Join(token.Scopes, " "), token.ExpiresAt, token.CreatedAt)
+	return err
+}
+
+// FindByHash retrieves a token by the hash of its secret, or nil if no such token exists.
+func (r *APITokenRepository) FindByHash(tokenHash string) (*model.APIToken, error) {
+	row := r.DB.QueryRow(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE token_hash = ?`, tokenHash)
+	token, err := scanAPIToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return token, err
+}
+
+// FindByUserID returns all tokens of a user, newest first.
+func (r *APITokenRepository) FindByUserID(userID string) ([]model.APIToken, error) {
+	rows, err := r.DB.Query(`
+		SELECT `+apiTokenColumns+`
+		FROM api_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []model.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// UpdateLastUsed records when a token was last used.
+func (r *APITokenRepository) UpdateLastUsed(id string, usedAt time.Time) error {
+	_, err := r.DB.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id)
+	return err
+}
+
+// Delete removes a token owned by the given user and reports whether it existed.
+func (r *APITokenRepository) Delete(userID, id string) (bool, error) {
+	result, err := r.DB.Exec(`DELETE FROM api_tokens WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+type apiTokenScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIToken(row apiTokenScanner) (*model.APIToken, error) {
+	var token model.APIToken
+	var scopes string
+	var lastUsedAt, expiresAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.Prefix, &scopes, &lastUsedAt, &expiresAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	return &token, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// AuditRepository handles database operations for the audit log. It can only add and read events.
+type AuditRepository struct {
+	DB *sql.DB
+}
+
+// NewAuditRepository creates and returns a new instance of AuditRepository.
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{DB: db}
+}
+
+// AuditFilter narrows down the events returned by List
+type AuditFilter struct {
+	Action     string
+	ActorID    string
+	TargetType string
+	TargetID   string
+	IPAddress  string
+	Since      *time.Time
+	Until      *time.Time
+	BeforeID   int64 // Only events older than this one, for paging through the whole log
+	Limit      int
+	Offset     int
+}
+
+// Insert appends an event to the log and sets its ID.
+func (r *AuditRepository) Insert(event *model.AuditEvent) error {
+	return r.DB.QueryRow(`
+		INSERT INTO audit_events (action, actor_id, target_type, target_id, ip_address, user_agent, diff, created_at)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, event.Action, event.ActorID, event.TargetType, event.TargetID, event.IPAddress, event.UserAgent,
+		string(event.Diff), event.CreatedAt).Scan(&event.ID)
+}
+
+// List returns the events matching filter, newest first.
+func (r *AuditRepository) List(filter AuditFilter) ([]model.AuditEvent, error) {
+	var conditions []string
+	var args []any
+	for column, value := range map[string]string{
+		"action":      filter.Action,
+		"actor_id":    filter.ActorID,
+		"target_type": filter.TargetType,
+		"target_id":   filter.TargetID,
+		"ip_address":  filter.IPAddress,
+	} {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if filter.Since != nil {
+		conditions = append(conditions, `julianday(created_at) >= julianday(?)`)
+		args = append(args, *filter.Since)
+	}
+	if filter.Until != nil {
+		conditions = append(conditions, `julianday(created_at) < julianday(?)`)
+		args = append(args, *filter.Until)
+	}
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, `id < ?`)
+		args = append(args, filter.BeforeID)
+	}
+
+	query := `SELECT id, action, COALESCE(actor_id, ''), target_type, target_id, ip_address, user_agent, diff, created_at FROM audit_events`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.AuditEvent
+	for rows.Next() {
+		var event model.AuditEvent
+		var diff string
+		if err := rows.Scan(&event.ID, &event.Action, &event.ActorID, &event.TargetType, &event.TargetID,
+			&event.IPAddress, &event.UserAgent, &diff, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Diff = []byte(diff)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// BookmarkRepository handles database operations for bookmarks and their collections
+type BookmarkRepository struct {
+	DB *sql.DB
+}
+
+// NewBookmarkRepository creates and returns a new instance of BookmarkRepository.
+func NewBookmarkRepository(db *sql.DB) *BookmarkRepository {
+	return &BookmarkRepository{DB: db}
+}
+
+// Save bookmarks a post for the user, and adds it to the collection unless collectionID is empty.
+// Saving a post again keeps the time it was first saved.
+func (r *BookmarkRepository) Save(userID, postID, collectionID string, at time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO bookmarks (user_id, post_id, created_at) VALUES (?, ?, ?)`, userID, postID, at); err != nil {
+		return err
+	}
+	if collectionID != "" {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO bookmark_collection_posts (collection_id, post_id, created_at) VALUES (?, ?, ?)
+		`, collectionID, postID, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Remove deletes the user's bookmark of a post and takes the post out of their collections.
+// It reports whether the post was bookmarked.
+func (r *BookmarkRepository) Remove(userID, postID string) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM bookmark_collection_posts
+		WHERE post_id = ? AND collection_id IN (SELECT id FROM bookmark_collections WHERE user_id = ?)
+	`, postID, userID); err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(`DELETE FROM bookmarks WHERE user_id = ? AND post_id = ?`, userID, postID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// RemoveFromCollection takes a post out of a collection, leaving it bookmarked. It reports whether the post was in it.
+func (r *BookmarkRepository) RemoveFromCollection(collectionID, postID string) (bool, error) {
+	result, err := r.DB.Exec(`DELETE FROM bookmark_collection_posts WHERE collection_id = ? AND post_id = ?`, collectionID, postID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// FindSaved returns up to limit posts the viewer bookmarked, or that are in the collection unless collectionID
+// is empty, most recently saved first, starting after the cursor or from the latest if it is nil.
+// Saved posts the viewer may no longer see are left out.
+func (r *BookmarkRepository) FindSaved(viewerID, collectionID string, after *FeedCursor, limit int) ([]model.Post, error) {
+	source, owner, ownerArg := `bookmarks b`, `b.user_id = ?`, viewerID
+	if collectionID != "" {
+		source, owner, ownerArg = `bookmark_collection_posts b`, `b.collection_id = ?`, collectionID
+	}
+	visible, visibleArgs := visiblePostCondition(viewerID)
+	query := `
+SELECT
+    p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at, p.updated_at,
+    COALESCE(p.repost_of, ''), COALESCE(p.quote_of, ''),
+    u.fname, u.lname, COALESCE(u.imgurl, ''),
+    (
+        SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL AND c.hidden_at IS NULL
+    ) AS comment_count,
+    b.created_at
+FROM ` + source + `
+JOIN posts p ON p.id = b.post_id
+JOIN users u ON u.id = p.user_id
+WHERE ` + owner + `
+AND ` + visible
+	args := append([]any{ownerArg}, visibleArgs...)
+
+	if after != nil {
+		query += `
+AND (julianday(b.created_at) < julianday(?) OR (julianday(b.created_at) = julianday(?) AND p.id < ?))`
+		args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+	}
+	query += `
+ORDER BY julianday(b.created_at) DESC, p.id DESC
+LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		var updatedAt sql.NullTime
+		var savedAt time.Time
+		var firstName, lastName string
+		if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &updatedAt,
+			&post.RepostOf, &post.QuoteOf, &firstName, &lastName, &post.CreatorImg, &post.CommentCount, &savedAt); err != nil {
+			return nil, err
+		}
+		if updatedAt.Valid {
+			post.UpdatedAt = &updatedAt.Time
+		}
+		post.Creator = firstName + " " + lastName
+		post.SavedAt = &savedAt
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return posts, attachDetails(r.DB, viewerID, posts)
+}
+
+// attachSaved fills in whether the viewer bookmarked each post and the post it reshares.
+func attachSaved(db *sql.DB, viewerID string, posts []model.Post) error {
+	var ids []string
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+		if post.Original != nil {
+			ids = append(ids, post.Original.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	args := []any{viewerID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := db.Query(`
+		SELECT post_id FROM bookmarks WHERE user_id = ? AND post_id IN (?`+strings.Repeat(`, ?`, len(ids)-1)+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	saved := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		saved[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Saved = saved[posts[i].Id]
+		if posts[i].Original != nil {
+			posts[i].Original.Saved = saved[posts[i].Original.Id]
+		}
+	}
+	return nil
+}
+
+// FindCollection returns a collection without its post count, or nil if it does not exist.
+func (r *BookmarkRepository) FindCollection(id string) (*model.Collection, error) {
+	var c model.Collection
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, name, private, created_at FROM bookmark_collections WHERE id = ?
+	`, id).Scan(&c.ID, &c.UserID, &c.Name, &c.Private, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CollectionNameTaken reports whether the user has another collection with the name, regardless of case.
+func (r *BookmarkRepository) CollectionNameTaken(userID, name, exceptID string) (bool, error) {
+	var taken bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM bookmark_collections WHERE user_id = ? AND name = ? COLLATE NOCASE AND id != ?)
+	`, userID, name, exceptID).Scan(&taken)
+	return taken, err
+}
+
+// FindCollections returns the owner's collections by name, each with the number of its posts the viewer
+// may see. Private collections are left out unless includePrivate is set.
+func (r *BookmarkRepository) FindCollections(ownerID, viewerID string, includePrivate bool) ([]model.Collection, error) {
+	visible, visibleArgs := visiblePostCondition(viewerID)
+	args := append(visibleArgs, ownerID, includePrivate)
+	rows, err := r.DB.Query(`
+		SELECT bc.id, bc.user_id, bc.name, bc.private, bc.created_at,
+			(
+				SELECT COUNT(*) FROM bookmark_collection_posts b JOIN posts p ON p.id = b.post_id
+				WHERE b.collection_id = bc.id AND `+visible+`
+			)
+		FROM bookmark_collections bc
+		WHERE bc.user_id = ? AND (bc.private = 0 OR ?)
+		ORDER BY bc.name COLLATE NOCASE, bc.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []model.Collection{}
+	for rows.Next() {
+		var c model.Collection
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.Private, &c.CreatedAt, &c.PostCount); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+// CreateCollection saves a new collection.
+func (r *BookmarkRepository) CreateCollection(c *model.Collection) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO bookmark_collections (id, user_id, name, private, created_at) VALUES (?, ?, ?, ?, ?)
+	`, c.ID, c.UserID, c.Name, c.Private, c.CreatedAt)
+	return err
+}
+
+// UpdateCollection saves the name and privacy of a collection.
+func (r *BookmarkRepository) UpdateCollection(c *model.Collection) error {
+	_, err := r.DB.Exec(`UPDATE bookmark_collections SET name = ?, private = ? WHERE id = ?`, c.Name, c.Private, c.ID)
+	return err
+}
+
+// DeleteCollection removes a collection. The posts in it stay bookmarked.
+func (r *BookmarkRepository) DeleteCollection(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM bookmark_collections WHERE id = ?`, id)
+	return err
+}
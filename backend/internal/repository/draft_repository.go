@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"backend/internal/model"
+)
+
+// DraftRepository handles database operations for drafts and scheduled posts
+type DraftRepository struct {
+	DB *sql.DB
+}
+
+// NewDraftRepository creates and returns a new instance of DraftRepository.
+func NewDraftRepository(db *sql.DB) *DraftRepository {
+	return &DraftRepository{DB: db}
+}
+
+const draftColumns = `id, user_id, title, content, visibility, COALESCE(group_id, ''), COALESCE(quote_of, ''),
+	allowed_followers, media, COALESCE(poll, ''), publish_at, publish_error, created_at, updated_at`
+
+// FindByID returns a draft, or nil if it does not exist.
+func (r *DraftRepository) FindByID(id string) (*model.PostDraft, error) {
+	draft, err := scanDraft(r.DB.QueryRow(`SELECT `+draftColumns+` FROM post_drafts WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return draft, err
+}
+
+// FindByUser returns the user's drafts, most recently changed first.
+func (r *DraftRepository) FindByUser(userID string) ([]model.PostDraft, error) {
+	return r.findDrafts(`SELECT `+draftColumns+` FROM post_drafts WHERE user_id = ? ORDER BY julianday(updated_at) DESC, id`, userID)
+}
+
+// FindDue returns the scheduled drafts whose publish time is not after now, earliest first.
+func (r *DraftRepository) FindDue(now time.Time) ([]model.PostDraft, error) {
+	return r.findDrafts(`
+		SELECT `+draftColumns+` FROM post_drafts
+		WHERE publish_at IS NOT NULL AND julianday(publish_at) <= julianday(?)
+		ORDER BY julianday(publish_at), id
+	`, now)
+}
+
+func (r *DraftRepository) findDrafts(query string, args ...any) ([]model.PostDraft, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []model.PostDraft{}
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, *draft)
+	}
+	return drafts, rows.Err()
+}
+
+func scanDraft(row interface{ Scan(...any) error }) (*model.PostDraft, error) {
+	var d model.PostDraft
+	var allowed, media, poll string
+	var publishAt sql.NullTime
+	if err := row.Scan(&d.ID, &d.UserID, &d.Title, &d.Content, &d.Visibility, &d.GroupID, &d.QuoteOf,
+		&allowed, &media, &poll, &publishAt, &d.PublishError, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(allowed), &d.AllowedFollowers); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(media), &d.Media); err != nil {
+		return nil, err
+	}
+	if poll != "" {
+		if err := json.Unmarshal([]byte(poll), &d.Poll); err != nil {
+			return nil, err
+		}
+	}
+	if publishAt.Valid {
+		d.PublishAt = &publishAt.Time
+	}
+	return &d, nil
+}
+
+// Create saves a new draft.
+func (r *DraftRepository) Create(d *model.PostDraft) error {
+	allowed, media, poll, err := marshalDraftLists(d)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(`
+		INSERT INTO post_drafts (id, user_id, title, content, visibility, group_id, quote_of, allowed_followers, media,
+			poll, publish_at, publish_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, NULLIF(?, ''), ?, ?, ?, ?)
+	`, d.ID, d.UserID, d.Title, d.Content, d.Visibility, d.GroupID, d.QuoteOf, allowed, media, poll,
+		d.PublishAt, d.PublishError, d.CreatedAt, d.UpdatedAt)
+	return err
+}
+
+// Update saves the changes to a draft.
+func (r *DraftRepository) Update(d *model.PostDraft) error {
+	allowed, media, poll, err := marshalDraftLists(d)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(`
+		UPDATE post_drafts
+		SET title = ?, content = ?, visibility = ?, group_id = NULLIF(?, ''), quote_of = NULLIF(?, ''),
+			allowed_followers = ?, media = ?, poll = NULLIF(?, ''), publish_at = ?, publish_error = ?, updated_at = ?
+		WHERE id = ?
+	`, d.Title, d.Content, d.Visibility, d.GroupID, d.QuoteOf, allowed, media, poll, d.PublishAt, d.PublishError,
+		d.UpdatedAt, d.ID)
+	return err
+}
+
+// marshalDraftLists returns the JSON stored for the allowed followers, gallery and poll of a draft,
+// the last one empty if the draft has no poll.
+func marshalDraftLists(d *model.PostDraft) (string, string, string, error) {
+	followers := d.AllowedFollowers
+	if followers == nil {
+		followers = []string{}
+	}
+	allowed, err := json.Marshal(followers)
+	if err != nil {
+		return "", "", "", err
+	}
+	media, err := json.Marshal(emptyMediaIfNil(d.Media))
+	if err != nil {
+		return "", "", "", err
+	}
+	var poll []byte
+	if d.Poll != nil {
+		if poll, err = json.Marshal(d.Poll); err != nil {
+			return "", "", "", err
+		}
+	}
+	return string(allowed), string(media), string(poll), nil
+}
+
+// Delete removes a draft.
+func (r *DraftRepository) Delete(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM post_drafts WHERE id = ?`, id)
+	return err
+}
+
+// Publish replaces the draft with post in one transaction, as long as the draft has not changed since
+// it was read. It reports false, creating nothing, if the draft was changed, published or deleted meanwhile.
+func (r *DraftRepository) Publish(d *model.PostDraft, post *model.Post) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM post_drafts WHERE id = ? AND julianday(updated_at) = julianday(?)`, d.ID, d.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if err := createPost(tx, post); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Unschedule turns a scheduled draft that could not be published back into a draft, keeping the reason
+// for the author, unless it has changed since it was read.
+func (r *DraftRepository) Unschedule(d *model.PostDraft, reason string) error {
+	_, err := r.DB.Exec(`
+		UPDATE post_drafts SET publish_at = NULL, publish_error = ?
+		WHERE id = ? AND julianday(updated_at) = julianday(?)
+	`, reason, d.ID, d.UpdatedAt)
+	return err
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// RankWeights tunes the ranked feed. service.DefaultRankWeights holds the values in use.
+type RankWeights struct {
+	Like               float64 // weight of a like in a post's engagement
+	Dislike            float64 // weight of a dislike, taken off the engagement
+	Comment            float64 // weight of a comment
+	EngagementMidpoint float64 // weighted interactions at which engagement reaches 0.5
+	AffinityMidpoint   float64 // interactions with an author at which affinity reaches 0.5
+
+	Engagement      float64       // boost of a post with full engagement, added to a base of 1
+	Affinity        float64       // boost of a post by an author the viewer has full affinity with
+	Group           float64       // boost of a post in one of the viewer's groups
+	RecencyHalfLife time.Duration // age at which the boosts are down to half
+}
+
+// RankedCursor points into a ranked feed. Every page is ranked as of the same time so posts keep their places.
+type RankedCursor struct {
+	AsOf   time.Time
+	Offset int
+}
+
+// ErrInvalidRankedCursor is returned by ParseRankedCursor for cursors that were not made by RankedCursor.String
+var ErrInvalidRankedCursor = errors.New("invalid ranked feed cursor")
+
+// String encodes the cursor for use in a URL.
+func (c RankedCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.AsOf.Format(time.RFC3339Nano) + "," + strconv.Itoa(c.Offset)))
+}
+
+// ParseRankedCursor decodes a cursor made by RankedCursor.String.
+func ParseRankedCursor(s string) (*RankedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidRankedCursor
+	}
+	asOf, offset, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, ErrInvalidRankedCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, asOf)
+	if err != nil {
+		return nil, ErrInvalidRankedCursor
+	}
+	n, err := strconv.Atoi(offset)
+	if err != nil || n < 0 {
+		return nil, ErrInvalidRankedCursor
+	}
+	return &RankedCursor{AsOf: t, Offset: n}, nil
+}
+
+// FeedRankingRepository keeps the precomputed scores of the ranked feed and reads the feed from them
+type FeedRankingRepository struct {
+	DB *sql.DB
+}
+
+// NewFeedRankingRepository creates and returns a new instance of FeedRankingRepository.
+func NewFeedRankingRepository(db *sql.DB) *FeedRankingRepository {
+	return &FeedRankingRepository{DB: db}
+}
+
+// RefreshScores rebuilds post_scores for the posts created since postsSince, and author_affinities from the
+// reactions, comments and messages since interactionsSince, in one transaction.
+func (r *FeedRankingRepository) RefreshScores(postsSince, interactionsSince time.Time, weights RankWeights, now time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM post_scores`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO post_scores (post_id, likes, dislikes, comments, engagement, computed_at)
+		SELECT id, likes, dislikes, comments, weighted / (weighted + ?), ?
+		FROM (
+			SELECT id, likes, dislikes, comments, MAX(0.0, ? * likes - ? * dislikes + ? * comments) AS weighted
+			FROM (
+				SELECT p.id,
+					(SELECT COUNT(*) FROM reactions r WHERE r.post_id = p.id AND r.type = 'like') AS likes,
+					(SELECT COUNT(*) FROM reactions r WHERE r.post_id = p.id AND r.type = 'dislike') AS dislikes,
+					(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.hidden_at IS NULL) AS comments
+				FROM posts p
+				WHERE p.hidden_at IS NULL AND julianday(p.created_at) >= julianday(?)
+			)
+		)
+	`, weights.EngagementMidpoint, now, weights.Like, weights.Dislike, weights.Comment, postsSince)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM author_affinities`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO author_affinities (user_id, author_id, interactions, affinity, computed_at)
+		SELECT user_id, author_id, COUNT(*), COUNT(*) * 1.0 / (COUNT(*) + ?), ?
+		FROM (
+			SELECT r.user_id, p.user_id AS author_id
+			FROM reactions r JOIN posts p ON p.id = r.post_id
+			WHERE julianday(r.created_at) >= julianday(?)
+			UNION ALL
+			SELECT c.user_id, p.user_id
+			FROM comments c JOIN posts p ON p.id = c.post_id
+			WHERE c.hidden_at IS NULL AND julianday(c.created_at) >= julianday(?)
+			UNION ALL
+			SELECT m.sender_id, m.receiver_id
+			FROM messages m
+			WHERE julianday(m.created_at) >= julianday(?)
+		)
+		WHERE user_id != author_id
+		GROUP BY user_id, author_id
+	`, weights.AffinityMidpoint, now, interactionsSince, interactionsSince, interactionsSince)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRankedPosts returns up to limit posts the user can see, created between since and asOf, best ranked first
+// and skipping the first offset. Unlike the chronological feed it includes posts from the user's groups.
+// Every post carries the explanation of its rank.
+func (r *FeedRankingRepository) GetRankedPosts(viewerID string, since, asOf time.Time, weights RankWeights, offset, limit int) ([]model.Post, error) {
+	visible, visibleArgs := visiblePostCondition(viewerID)
+	halfLifeHours := weights.RecencyHalfLife.Hours()
+
+	// arguments in the order of the placeholders
+	args := []any{weights.Engagement, weights.Affinity, weights.Group, halfLifeHours, asOf, viewerID}
+	args = append(args, visibleArgs...)
+	args = append(args, since, asOf, offset, limit)
+	rows, err := r.DB.Query(`
+SELECT *,
+    (1 + ? * engagement + ? * affinity + ? * group_member) / (1 + age_hours / ?) AS score
+FROM (
+    SELECT
+        p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at, p.updated_at, p.group_id,
+        COALESCE(p.repost_of, ''), COALESCE(p.quote_of, ''),
+        u.fname, u.lname, COALESCE(u.imgurl, ''),
+        (
+            SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL AND c.hidden_at IS NULL
+        ) AS comment_count,
+        COALESCE(s.likes, 0), COALESCE(s.dislikes, 0), COALESCE(s.comments, 0),
+        COALESCE(s.engagement, 0) AS engagement,
+        COALESCE(a.affinity, 0) AS affinity,
+        p.group_id IS NOT NULL AS group_member,
+        MAX(0.0, (julianday(?) - julianday(p.created_at)) * 24) AS age_hours
+    FROM posts p
+    JOIN users u ON u.id = p.user_id
+    LEFT JOIN post_scores s ON s.post_id = p.id
+    LEFT JOIN author_affinities a ON a.user_id = ? AND a.author_id = p.user_id
+    WHERE `+visible+`
+    AND julianday(p.created_at) >= julianday(?)
+    AND julianday(p.created_at) <= julianday(?)
+)
+ORDER BY score DESC, id DESC
+LIMIT ?, ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		var updatedAt sql.NullTime
+		var firstName, lastName string
+		rank := &model.PostRank{}
+		if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &updatedAt, &post.GroupId,
+			&post.RepostOf, &post.QuoteOf, &firstName, &lastName, &post.CreatorImg, &post.CommentCount,
+			&rank.Likes, &rank.Dislikes, &rank.Comments, &rank.Engagement, &rank.Affinity, &rank.GroupMember, &rank.AgeHours, &rank.Score); err != nil {
+			return nil, err
+		}
+		if updatedAt.Valid {
+			post.UpdatedAt = &updatedAt.Time
+		}
+		post.Creator = firstName + " " + lastName
+		rank.Recency = 1 / (1 + rank.AgeHours/halfLifeHours)
+		post.Rank = rank
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return posts, attachDetails(r.DB, viewerID, posts)
+}
@@ -17,7 +17,7 @@ func NewGroupRepository(db *sql.DB) *GroupRepository {
 
 // FindAll retrieves all groups from the database.
 func (r *GroupRepository) FindAll() ([]model.Group, error) {
-	rows, err := r.DB.Query("SELECT id, title, description, creator_id, privacy_setting, created_at FROM groups ORDER BY created_at DESC")
+	rows, err := r.DB.Query("SELECT id, title, description, creator_id, privacy_setting, created_at FROM groups WHERE hidden_at IS NULL ORDER BY created_at DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +73,7 @@ func (r *GroupRepository) FindGroupByID(groupID uint) (*model.Group, error) {
 	err := r.DB.QueryRow(`
 		SELECT id, title, description, creator_id, privacy_setting, created_at, updated_at
 		FROM groups
-		WHERE id = ? AND deleted_at IS NULL
+		WHERE id = ? AND deleted_at IS NULL AND hidden_at IS NULL
 	`, groupID).Scan(&group.ID, &group.Title, &group.Description, &group.CreatorID, &group.PrivacySetting, &group.CreatedAt, &group.UpdatedAt)
 
 	if err != nil {
@@ -154,3 +154,23 @@ func (r *GroupRepository) IsGroupCreator(groupID uint, userID string) (bool, err
 
 	return creatorID == userID, nil
 }
+
+// FindMemberStatuses returns the status of each of the user's group memberships, keyed by group ID.
+func (r *GroupRepository) FindMemberStatuses(userID string) (map[uint]string, error) {
+	rows, err := r.DB.Query(`SELECT group_id, status FROM group_members WHERE user_id = ? AND deleted_at IS NULL`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := make(map[uint]string)
+	for rows.Next() {
+		var groupID uint
+		var status string
+		if err := rows.Scan(&groupID, &status); err != nil {
+			return nil, err
+		}
+		statuses[groupID] = status
+	}
+	return statuses, rows.Err()
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+// LoginAttemptRepository handles database operations for login attempts
+type LoginAttemptRepository struct {
+	DB *sql.DB
+}
+
+// NewLoginAttemptRepository creates and returns a new instance of LoginAttemptRepository.
+func NewLoginAttemptRepository(db *sql.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+// Insert records a login attempt.
+func (r *LoginAttemptRepository) Insert(attempt *model.LoginAttempt) error {
+	var userID sql.NullString
+	if attempt.UserID != "" {
+		userID = sql.NullString{String: attempt.UserID, Valid: true}
+	}
+	_, err := r.DB.Exec(`
+		INSERT INTO login_attempts (email, user_id, ip_address, user_agent, success, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, attempt.Email, userID, attempt.IPAddress, attempt.UserAgent, attempt.Success, attempt.Reason, attempt.CreatedAt)
+	return err
+}
+
+// RecentAccountFailures returns the times of failed attempts for an email since the later of
+// `since` and its last successful login, newest first. Attempts rejected by a lockout are not counted.
+func (r *LoginAttemptRepository) RecentAccountFailures(email string, since time.Time) ([]time.Time, error) {
+	return r.queryTimes(`
+		SELECT created_at FROM login_attempts
+		WHERE email = ? AND success = 0 AND reason != 'locked'
+		  AND julianday(created_at) > julianday(?)
+		  AND id > COALESCE((SELECT MAX(id) FROM login_attempts WHERE email = ? AND success = 1), 0)
+		ORDER BY id DESC
+	`, email, since, email)
+}
+
+// RecentIPFailures returns the times of failed attempts from an IP address since `since`, newest first.
+func (r *LoginAttemptRepository) RecentIPFailures(ipAddress string, since time.Time) ([]time.Time, error) {
+	return r.queryTimes(`
+		SELECT created_at FROM login_attempts
+		WHERE ip_address = ? AND success = 0 AND reason != 'locked'
+		  AND julianday(created_at) > julianday(?)
+		ORDER BY id DESC
+	`, ipAddress, since)
+}
+
+// FindFailedByUserID returns the most recent failed attempts against a user's account.
+func (r *LoginAttemptRepository) FindFailedByUserID(userID string, limit int) ([]model.LoginAttempt, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, email, user_id, ip_address, user_agent, success, reason, created_at
+		FROM login_attempts
+		WHERE user_id = ? AND success = 0
+		ORDER BY id DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []model.LoginAttempt
+	for rows.Next() {
+		var a model.LoginAttempt
+		var userAgent sql.NullString
+		if err := rows.Scan(&a.ID, &a.Email, &a.UserID, &a.IPAddress, &userAgent, &a.Success, &a.Reason, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.UserAgent = userAgent.String
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+func (r *LoginAttemptRepository) queryTimes(query string, args ...any) ([]time.Time, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
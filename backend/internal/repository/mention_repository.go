@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/utils"
+	"backend/pkg/mention"
+)
+
+// MentionRepository reads the users mentioned in posts, comments and messages, and suggests users to mention
+type MentionRepository struct {
+	DB *sql.DB
+}
+
+// NewMentionRepository creates and returns a new instance of MentionRepository.
+func NewMentionRepository(db *sql.DB) *MentionRepository {
+	return &MentionRepository{DB: db}
+}
+
+// resolveMentions returns the users mentioned in text, in order. Nicknames are matched regardless of case,
+// preferring the user whose nickname has the same case when several match. Nicknames nobody has, and ones
+// that only differ in case from several nicknames, are left out.
+func resolveMentions(tx *sql.Tx, authorID, text string, at time.Time) ([]model.Mention, error) {
+	nicknames := mention.Parse(text)
+	if len(nicknames) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(nicknames))
+	for i, nickname := range nicknames {
+		args[i] = nickname
+	}
+	rows, err := tx.Query(`
+		SELECT id, nickname FROM users WHERE nickname COLLATE NOCASE IN (?`+strings.Repeat(`, ?`, len(nicknames)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := map[string]map[string]string{} // lowercased nickname -> nickname -> user ID
+	for rows.Next() {
+		var id, nickname string
+		if err := rows.Scan(&id, &nickname); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(nickname)
+		if candidates[key] == nil {
+			candidates[key] = map[string]string{}
+		}
+		candidates[key][nickname] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var mentions []model.Mention
+	for _, written := range nicknames {
+		matches := candidates[strings.ToLower(written)]
+		userID, ok := matches[written]
+		if !ok && len(matches) == 1 {
+			for _, id := range matches {
+				userID, ok = id, true
+			}
+		}
+		if ok {
+			mentions = append(mentions, model.Mention{ID: utils.GenerateUUID(), UserID: userID, Nickname: written, AuthorID: authorID, CreatedAt: at})
+		}
+	}
+	return mentions, nil
+}
+
+func insertMentions(tx *sql.Tx, mentions []model.Mention) error {
+	for _, m := range mentions {
+		if _, err := tx.Exec(`
+			INSERT INTO mentions (id, user_id, nickname, author_id, post_id, comment_id, message_id, created_at)
+			VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)
+		`, m.ID, m.UserID, m.Nickname, m.AuthorID, m.PostID, m.CommentID, m.MessageID, m.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPostMentions makes the mentions of a post's own text match its title and content. Users who stay
+// mentioned keep their row, and so are not notified again.
+func setPostMentions(tx *sql.Tx, post *model.Post, at time.Time) error {
+	mentions, err := resolveMentions(tx, post.UserId, post.Title+"\n"+post.Content, at)
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Query(`SELECT user_id FROM mentions WHERE post_id = ? AND comment_id IS NULL`, post.Id)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[userID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var added []model.Mention
+	for _, m := range mentions {
+		if existing[m.UserID] {
+			delete(existing, m.UserID)
+			continue
+		}
+		m.PostID = post.Id
+		added = append(added, m)
+	}
+	for userID := range existing {
+		if _, err := tx.Exec(`DELETE FROM mentions WHERE post_id = ? AND comment_id IS NULL AND user_id = ?`, post.Id, userID); err != nil {
+			return err
+		}
+	}
+	return insertMentions(tx, added)
+}
+
+// InsertCommentMentions saves the users mentioned in a new comment, in the transaction creating it.
+func InsertCommentMentions(tx *sql.Tx, postID, commentID, authorID, content string, createdAt time.Time) error {
+	mentions, err := resolveMentions(tx, authorID, content, createdAt)
+	if err != nil {
+		return err
+	}
+	for i := range mentions {
+		mentions[i].PostID, mentions[i].CommentID = postID, commentID
+	}
+	return insertMentions(tx, mentions)
+}
+
+// InsertMessageMentions saves the users mentioned in a new chat message, in the transaction creating it.
+func InsertMessageMentions(tx *sql.Tx, messageID, authorID, content string, createdAt time.Time) error {
+	mentions, err := resolveMentions(tx, authorID, content, createdAt)
+	if err != nil {
+		return err
+	}
+	for i := range mentions {
+		mentions[i].MessageID = messageID
+	}
+	return insertMentions(tx, mentions)
+}
+
+// findMentions returns the mentions whose column, one of post_id, comment_id and message_id, is one of ids,
+// keyed by that column. condition further limits them and starts with AND.
+func findMentions(db *sql.DB, column, condition string, ids []string) (map[string][]model.Mention, error) {
+	mentions := make(map[string][]model.Mention)
+	if len(ids) == 0 {
+		return mentions, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := db.Query(`
+		SELECT `+column+`, user_id, nickname FROM mentions
+		WHERE `+column+` IN (?`+strings.Repeat(`, ?`, len(ids)-1)+`)`+condition+`
+		ORDER BY created_at, rowid
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var m model.Mention
+		if err := rows.Scan(&id, &m.UserID, &m.Nickname); err != nil {
+			return nil, err
+		}
+		mentions[id] = append(mentions[id], m)
+	}
+	return mentions, rows.Err()
+}
+
+// attachMentions fills in the users mentioned in each post's own text.
+func attachMentions(db *sql.DB, posts []model.Post) error {
+	ids := make([]string, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].Id
+	}
+	mentions, err := findMentions(db, "post_id", " AND comment_id IS NULL", ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Mentions = mentions[posts[i].Id]
+	}
+	return nil
+}
+
+// FindCommentMentions returns the users mentioned in each of the comments, keyed by comment ID.
+func FindCommentMentions(db *sql.DB, commentIDs []string) (map[string][]model.Mention, error) {
+	return findMentions(db, "comment_id", "", commentIDs)
+}
+
+// FindMessageMentions returns the users mentioned in each of the messages, keyed by message ID.
+func FindMessageMentions(db *sql.DB, messageIDs []string) (map[string][]model.Mention, error) {
+	return findMentions(db, "message_id", "", messageIDs)
+}
+
+// UnnotifiedInPost returns the mentions in a post's own text that nobody has been notified of.
+func (r *MentionRepository) UnnotifiedInPost(postID string) ([]model.Mention, error) {
+	return r.findUnnotified(`m.post_id = ? AND m.comment_id IS NULL`, postID)
+}
+
+// UnnotifiedInComment returns the mentions in a comment that nobody has been notified of.
+func (r *MentionRepository) UnnotifiedInComment(commentID string) ([]model.Mention, error) {
+	return r.findUnnotified(`m.comment_id = ?`, commentID)
+}
+
+// UnnotifiedInMessage returns the mentions in a chat message that nobody has been notified of.
+func (r *MentionRepository) UnnotifiedInMessage(messageID string) ([]model.Mention, error) {
+	return r.findUnnotified(`m.message_id = ?`, messageID)
+}
+
+func (r *MentionRepository) findUnnotified(condition, id string) ([]model.Mention, error) {
+	rows, err := r.DB.Query(`
+		SELECT m.id, m.user_id, m.nickname, m.author_id, COALESCE(m.post_id, ''), COALESCE(m.comment_id, ''),
+			COALESCE(m.message_id, ''), m.created_at
+		FROM mentions m
+		WHERE `+condition+`
+		AND NOT EXISTS (SELECT 1 FROM notifications n WHERE n.mention_id = m.id)
+		ORDER BY m.created_at, m.rowid
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []model.Mention
+	for rows.Next() {
+		var m model.Mention
+		if err := rows.Scan(&m.ID, &m.UserID, &m.Nickname, &m.AuthorID, &m.PostID, &m.CommentID, &m.MessageID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, m)
+	}
+	return mentions, rows.Err()
+}
+
+// CanSee reports whether the mentioned user may see what they were mentioned in: the post, the post
+// and the comment, or the message, which only its sender and recipient see.
+func (r *MentionRepository) CanSee(m model.Mention) (bool, error) {
+	if m.MessageID != "" {
+		var visible bool
+		err := r.DB.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM messages WHERE id = ? AND (sender_id = ? OR receiver_id = ?) AND hidden_at IS NULL)
+		`, m.MessageID, m.UserID, m.UserID).Scan(&visible)
+		return visible, err
+	}
+	if m.CommentID != "" {
+		var hidden bool
+		err := r.DB.QueryRow(`SELECT hidden_at IS NOT NULL FROM comments WHERE id = ?`, m.CommentID).Scan(&hidden)
+		if err == sql.ErrNoRows || hidden {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return PostVisibleTo(r.DB, m.PostID, m.UserID)
+}
+
+// Suggest returns up to limit users with a nickname starting with prefix, regardless of case, for the
+// viewer to mention. People the viewer follows come first, then people following the viewer,
+// then everyone else, each by nickname. The viewer is left out.
+func (r *MentionRepository) Suggest(viewerID, prefix string, limit int) ([]model.MentionSuggestion, error) {
+	rows, err := r.DB.Query(`
+		SELECT u.id, u.nickname, u.fname, u.lname, COALESCE(u.imgurl, ''),
+			EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = ? AND f.followed_id = u.id AND f.status = 'accepted') AS following,
+			EXISTS(SELECT 1 FROM followers f WHERE f.follower_id = u.id AND f.followed_id = ? AND f.status = 'accepted') AS follower
+		FROM users u
+		WHERE u.id != ? AND u.nickname IS NOT NULL AND u.nickname != ''
+		AND LOWER(u.nickname) LIKE ? ESCAPE '\'
+		ORDER BY following DESC, follower DESC, LOWER(u.nickname), u.id
+		LIMIT ?
+	`, viewerID, viewerID, viewerID, escapeLike(strings.ToLower(prefix))+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []model.MentionSuggestion{}
+	for rows.Next() {
+		var s model.MentionSuggestion
+		var follower bool
+		if err := rows.Scan(&s.ID, &s.Nickname, &s.FirstName, &s.LastName, &s.ImgURL, &s.Following, &follower); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// NotificationRepository handles database operations for notifications
+type NotificationRepository struct {
+	DB *sql.DB
+}
+
+// NewNotificationRepository creates and returns a new instance of NotificationRepository.
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{DB: db}
+}
+
+// Create saves a new notification and fills in the name and picture of its actor.
+func (r *NotificationRepository) Create(n *model.Notification) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO notifications (id, user_id, type, actor_id, mention_id, post_id, comment_id, message_id, created_at)
+		VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)
+	`, n.ID, n.UserID, n.Type, n.ActorID, n.MentionID, n.PostID, n.CommentID, n.MessageID, n.CreatedAt)
+	if err != nil || n.ActorID == "" {
+		return err
+	}
+	return r.DB.QueryRow(`SELECT fname || ' ' || lname, COALESCE(imgurl, '') FROM users WHERE id = ?`, n.ActorID).
+		Scan(&n.ActorName, &n.ActorImg)
+}
+
+// FindByUser returns up to limit of the user's notifications, newest first.
+func (r *NotificationRepository) FindByUser(userID string, limit int) ([]model.Notification, error) {
+	rows, err := r.DB.Query(`
+		SELECT n.id, n.user_id, n.type, COALESCE(n.actor_id, ''), COALESCE(u.fname || ' ' || u.lname, ''), COALESCE(u.imgurl, ''),
+			COALESCE(n.post_id, ''), COALESCE(n.comment_id, ''), COALESCE(n.message_id, ''), n.created_at, n.read_at
+		FROM notifications n
+		LEFT JOIN users u ON u.id = n.actor_id
+		WHERE n.user_id = ?
+		ORDER BY n.created_at DESC, n.rowid DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []model.Notification{}
+	for rows.Next() {
+		var n model.Notification
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.ActorID, &n.ActorName, &n.ActorImg,
+			&n.PostID, &n.CommentID, &n.MessageID, &n.CreatedAt, &readAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// CountUnread returns how many of the user's notifications are unread.
+func (r *NotificationRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks the user's notifications with the given IDs as read, or all of them when ids is empty.
+// It returns how many were unread.
+func (r *NotificationRepository) MarkRead(userID string, ids []string, at time.Time) (int64, error) {
+	query := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	args := []any{at, userID}
+	if len(ids) > 0 {
+		query += ` AND id IN (?` + strings.Repeat(`, ?`, len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	result, err := r.DB.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+// OIDCRepository handles database operations for external identities and in-flight OIDC logins
+type OIDCRepository struct {
+	DB *sql.DB
+}
+
+// NewOIDCRepository creates and returns a new instance of OIDCRepository.
+func NewOIDCRepository(db *sql.DB) *OIDCRepository {
+	return &OIDCRepository{DB: db}
+}
+
+// FindIdentity retrieves the identity linked to a provider account, or nil if it is not linked.
+func (r *OIDCRepository) FindIdentity(provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	var lastLoginAt sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, provider, subject, email, created_at, last_login_at
+		FROM user_identities
+		WHERE provider = ? AND subject = ?
+	`, provider, subject).Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt, &lastLoginAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if lastLoginAt.Valid {
+		identity.LastLoginAt = &lastLoginAt.Time
+	}
+	return &identity, nil
+}
+
+// FindIdentitiesByUserID returns the external identities linked to a user.
+func (r *OIDCRepository) FindIdentitiesByUserID(userID string) ([]model.UserIdentity, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, user_id, provider, subject, email, created_at, last_login_at
+		FROM user_identities
+		WHERE user_id = ?
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []model.UserIdentity
+	for rows.Next() {
+		var identity model.UserIdentity
+		var lastLoginAt sql.NullTime
+		if err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt, &lastLoginAt); err != nil {
+			return nil, err
+		}
+		if lastLoginAt.Valid {
+			identity.LastLoginAt = &lastLoginAt.Time
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// LinkIdentity links a provider account to a user.
+func (r *OIDCRepository) LinkIdentity(identity *model.UserIdentity) error {
+	result, err := r.DB.Exec(`
+		INSERT INTO user_identities (user_id, provider, subject, email, created_at, last_login_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt, identity.LastLoginAt)
+	if err != nil {
+		return err
+	}
+	identity.ID, err = result.LastInsertId()
+	return err
+}
+
+// TouchIdentity records a login through an identity.
+func (r *OIDCRepository) TouchIdentity(id int64, loginAt time.Time) error {
+	_, err := r.DB.Exec(`UPDATE user_identities SET last_login_at = ? WHERE id = ?`, loginAt, id)
+	return err
+}
+
+// CreateLoginState stores an authorization request until the provider redirects back.
+func (r *OIDCRepository) CreateLoginState(state *model.OIDCLoginState) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO oidc_login_states (id, provider, code_verifier, nonce, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, state.ID, state.Provider, state.CodeVerifier, state.Nonce, state.ExpiresAt)
+	return err
+}
+
+// TakeLoginState deletes a login state and returns it, or nil if it does not exist.
+// Deleting before use makes every state single use.
+func (r *OIDCRepository) TakeLoginState(id string) (*model.OIDCLoginState, error) {
+	var state model.OIDCLoginState
+	err := r.DB.QueryRow(`
+		DELETE FROM oidc_login_states WHERE id = ?
+		RETURNING id, provider, code_verifier, nonce, expires_at
+	`, id).Scan(&state.ID, &state.Provider, &state.CodeVerifier, &state.Nonce, &state.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// CreatePendingSignup stores a verified identity that still needs a local account.
+func (r *OIDCRepository) CreatePendingSignup(signup *model.OIDCPendingSignup) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO oidc_pending_signups (id, provider, subject, email, first_name, last_name, picture, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, signup.ID, signup.Provider, signup.Subject, signup.Email, signup.FirstName, signup.LastName, signup.Picture, signup.ExpiresAt)
+	return err
+}
+
+// FindPendingSignup retrieves a pending signup, or nil if it does not exist.
+func (r *OIDCRepository) FindPendingSignup(id string) (*model.OIDCPendingSignup, error) {
+	var signup model.OIDCPendingSignup
+	err := r.DB.QueryRow(`
+		SELECT id, provider, subject, email, first_name, last_name, picture, expires_at
+		FROM oidc_pending_signups
+		WHERE id = ?
+	`, id).Scan(&signup.ID, &signup.Provider, &signup.Subject, &signup.Email, &signup.FirstName, &signup.LastName, &signup.Picture, &signup.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &signup, nil
+}
+
+// DeletePendingSignup removes a pending signup.
+func (r *OIDCRepository) DeletePendingSignup(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM oidc_pending_signups WHERE id = ?`, id)
+	return err
+}
+
+// PurgeExpired removes login states and pending signups that expired before now.
+func (r *OIDCRepository) PurgeExpired(now time.Time) error {
+	if _, err := r.DB.Exec(`DELETE FROM oidc_login_states WHERE julianday(expires_at) <= julianday(?)`, now); err != nil {
+		return err
+	}
+	_, err := r.DB.Exec(`DELETE FROM oidc_pending_signups WHERE julianday(expires_at) <= julianday(?)`, now)
+	return err
+}
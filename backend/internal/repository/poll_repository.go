@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// PollRepository handles database operations for the votes of polls
+type PollRepository struct {
+	DB *sql.DB
+}
+
+// NewPollRepository creates and returns a new instance of PollRepository.
+func NewPollRepository(db *sql.DB) *PollRepository {
+	return &PollRepository{DB: db}
+}
+
+// FindPoll returns the poll of a post as the viewer sees it at now, or nil if the post has none.
+func (r *PollRepository) FindPoll(postID, viewerID string, now time.Time) (*model.Poll, error) {
+	polls, err := findPolls(r.DB, viewerID, []string{postID}, now)
+	if err != nil {
+		return nil, err
+	}
+	return polls[postID], nil
+}
+
+// Vote records the user's votes for the options of a poll. It reports false, recording none of them,
+// if the user already voted for one of them or, in a single choice poll, for any option.
+func (r *PollRepository) Vote(postID, userID string, optionIDs []string, at time.Time) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for _, optionID := range optionIDs {
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO poll_votes (post_id, option_id, user_id, multiple_choice, created_at)
+			SELECT post_id, ?, ?, multiple_choice, ? FROM polls WHERE post_id = ?
+		`, optionID, userID, at, postID)
+		if err != nil {
+			return false, err
+		}
+		if n, err := result.RowsAffected(); err != nil || n == 0 {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// Unvote removes the user's vote for an option of a poll, or all their votes in it when optionID is empty.
+// It reports whether there was a vote to remove.
+func (r *PollRepository) Unvote(postID, userID, optionID string) (bool, error) {
+	query := `DELETE FROM poll_votes WHERE post_id = ? AND user_id = ?`
+	args := []any{postID, userID}
+	if optionID != "" {
+		query += ` AND option_id = ?`
+		args = append(args, optionID)
+	}
+	result, err := r.DB.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// insertPoll saves the poll of a new post with its options in the order given.
+func insertPoll(tx *sql.Tx, postID string, poll *model.Poll) error {
+	if _, err := tx.Exec(`
+		INSERT INTO polls (post_id, multiple_choice, hide_results, closes_at) VALUES (?, ?, ?, ?)
+	`, postID, poll.MultipleChoice, poll.HideResults, poll.ClosesAt); err != nil {
+		return err
+	}
+	for i, option := range poll.Options {
+		if _, err := tx.Exec(`
+			INSERT INTO poll_options (id, post_id, text, position) VALUES (?, ?, ?, ?)
+		`, option.ID, postID, option.Text, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachPolls fills in the polls of the posts and of the posts they reshare, with their results as the viewer
+// may see them now.
+func attachPolls(db *sql.DB, viewerID string, posts []model.Post) error {
+	var ids []string
+	for _, post := range posts {
+		ids = append(ids, post.Id)
+		if post.Original != nil {
+			ids = append(ids, post.Original.Id)
+		}
+	}
+	polls, err := findPolls(db, viewerID, ids, time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Poll = polls[posts[i].Id]
+		if posts[i].Original != nil {
+			posts[i].Original.Poll = polls[posts[i].Original.Id]
+		}
+	}
+	return nil
+}
+
+// findPolls returns the polls of the posts keyed by post ID. The results of a poll that hides them are left
+// out until the viewer votes or the poll closes, except for the author of the post.
+func findPolls(db *sql.DB, viewerID string, postIDs []string, now time.Time) (map[string]*model.Poll, error) {
+	polls := make(map[string]*model.Poll)
+	if len(postIDs) == 0 {
+		return polls, nil
+	}
+	in := `(?` + strings.Repeat(`, ?`, len(postIDs)-1) + `)`
+	args := []any{now, viewerID}
+	for _, id := range postIDs {
+		args = append(args, id)
+	}
+
+	rows, err := db.Query(`
+		SELECT pl.post_id, p.user_id, pl.multiple_choice, pl.hide_results, pl.closes_at,
+			pl.closes_at IS NOT NULL AND julianday(pl.closes_at) <= julianday(?),
+			(SELECT COUNT(DISTINCT v.user_id) FROM poll_votes v WHERE v.post_id = pl.post_id),
+			EXISTS (SELECT 1 FROM poll_votes v WHERE v.post_id = pl.post_id AND v.user_id = ?)
+		FROM polls pl
+		JOIN posts p ON p.id = pl.post_id
+		WHERE pl.post_id IN `+in, args...)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[string]string)
+	for rows.Next() {
+		var poll model.Poll
+		var closesAt sql.NullTime
+		var authorID string
+		if err := rows.Scan(&poll.PostID, &authorID, &poll.MultipleChoice, &poll.HideResults, &closesAt, &poll.Closed,
+			&poll.Voters, &poll.Voted); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if closesAt.Valid {
+			poll.ClosesAt = &closesAt.Time
+		}
+		poll.Options = []model.PollOption{}
+		polls[poll.PostID] = &poll
+		authors[poll.PostID] = authorID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(polls) == 0 {
+		return polls, nil
+	}
+
+	rows, err = db.Query(`
+		SELECT o.post_id, o.id, o.text, o.position, COUNT(v.user_id), COALESCE(MAX(v.user_id = ?), 0)
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		WHERE o.post_id IN `+in+`
+		GROUP BY o.id
+		ORDER BY o.post_id, o.position
+	`, args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var postID string
+		var option model.PollOption
+		if err := rows.Scan(&postID, &option.ID, &option.Text, &option.Position, &option.Votes, &option.Voted); err != nil {
+			return nil, err
+		}
+		if poll := polls[postID]; poll != nil {
+			poll.Options = append(poll.Options, option)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for postID, poll := range polls {
+		if poll.HideResults && !poll.Voted && !poll.Closed && authors[postID] != viewerID {
+			poll.ResultsHidden = true
+			poll.Voters = 0
+			for i := range poll.Options {
+				poll.Options[i].Votes = 0
+			}
+		}
+	}
+	return polls, nil
+}
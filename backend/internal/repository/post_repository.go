@@ -0,0 +1,361 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"backend/internal/model"
+)
+
+// PostRepository handles database operations for changing and removing posts
+type PostRepository struct {
+	DB *sql.DB
+}
+
+// NewPostRepository creates and returns a new instance of PostRepository.
+func NewPostRepository(db *sql.DB) *PostRepository {
+	return &PostRepository{DB: db}
+}
+
+// FindByID retrieves a post with its allowed followers, or nil if it does not exist or was hidden by a moderator.
+func (r *PostRepository) FindByID(id string) (*model.Post, error) {
+	var post model.Post
+	var updatedAt sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, title, content, visibility, post_image, created_at, group_id, updated_at,
+			COALESCE(repost_of, ''), COALESCE(quote_of, '')
+		FROM posts
+		WHERE id = ? AND hidden_at IS NULL
+	`, id).Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl,
+		&post.CreatedAt, &post.GroupId, &updatedAt, &post.RepostOf, &post.QuoteOf)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updatedAt.Valid {
+		post.UpdatedAt = &updatedAt.Time
+	}
+
+	post.AllowedFollowers, err = r.findAllowedFollowers(post.Id)
+	if err != nil {
+		return nil, err
+	}
+	media, err := findMedia(r.DB, []string{post.Id})
+	if err != nil {
+		return nil, err
+	}
+	post.Media = emptyMediaIfNil(media[post.Id])
+	mentions, err := findMentions(r.DB, "post_id", " AND comment_id IS NULL", []string{post.Id})
+	if err != nil {
+		return nil, err
+	}
+	post.Mentions = mentions[post.Id]
+	return &post, nil
+}
+
+// Create saves a new post, repost or quote with its gallery, poll, hashtags, mentions and, for private posts,
+// the followers allowed to see it.
+func (r *PostRepository) Create(post *model.Post) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := createPost(tx, post); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func createPost(tx *sql.Tx, post *model.Post) error {
+	if _, err := tx.Exec(`
+		INSERT INTO posts (id, user_id, title, content, visibility, post_image, created_at, group_id, repost_of, quote_of)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''))
+	`, post.Id, post.UserId, post.Title, post.Content, post.Visibility, post.ImageUrl, post.CreatedAt, post.GroupId,
+		post.RepostOf, post.QuoteOf); err != nil {
+		return err
+	}
+	if err := insertMedia(tx, post.Id, post.Media); err != nil {
+		return err
+	}
+	if post.Poll != nil {
+		if err := insertPoll(tx, post.Id, post.Poll); err != nil {
+			return err
+		}
+	}
+	if err := setPostTags(tx, post.Id, postTags(post), post.CreatedAt); err != nil {
+		return err
+	}
+	if err := setPostMentions(tx, post, post.CreatedAt); err != nil {
+		return err
+	}
+	if post.Visibility == "private" {
+		for _, followerID := range post.AllowedFollowers {
+			if _, err := tx.Exec(`
+				INSERT INTO private_posts (post_id, user_id, created_at) VALUES (?, ?, ?)
+			`, post.Id, followerID, post.CreatedAt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *PostRepository) findAllowedFollowers(postID string) ([]string, error) {
+	rows, err := r.DB.Query(`SELECT user_id FROM private_posts WHERE post_id = ? ORDER BY user_id`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// IsGroupAdmin reports whether the user is an active admin of the group.
+func (r *PostRepository) IsGroupAdmin(groupID, userID string) (bool, error) {
+	var isAdmin bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM group_members
+			WHERE CAST(group_id AS TEXT) = ? AND user_id = ? AND role = 'admin' AND status = 'active' AND deleted_at IS NULL
+		)
+	`, groupID, userID).Scan(&isAdmin)
+	return isAdmin, err
+}
+
+// Update saves the edited post, keeps its previous version in the edit history and rewrites its
+// gallery, hashtags, mentions and private_posts rows to match post.Media, its text and post.AllowedFollowers,
+// all in one transaction.
+func (r *PostRepository) Update(post *model.Post, previous *model.PostEdit) error {
+	allowed, err := json.Marshal(previous.AllowedFollowers)
+	if err != nil {
+		return err
+	}
+	media, err := json.Marshal(emptyMediaIfNil(previous.Media))
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO post_edits (id, post_id, editor_id, title, content, visibility, post_image, media, allowed_followers, edited_at)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''), ?, ?, ?)
+	`, previous.ID, previous.PostID, previous.EditorID, previous.Title, previous.Content, previous.Visibility,
+		previous.ImageURL, string(media), string(allowed), previous.EditedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE posts SET title = ?, content = ?, visibility = ?, post_image = ?, updated_at = ?
+		WHERE id = ?
+	`, post.Title, post.Content, post.Visibility, post.ImageUrl, post.UpdatedAt, post.Id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM post_media WHERE post_id = ?`, post.Id); err != nil {
+		return err
+	}
+	if err := insertMedia(tx, post.Id, post.Media); err != nil {
+		return err
+	}
+	if err := setPostTags(tx, post.Id, postTags(post), *post.UpdatedAt); err != nil {
+		return err
+	}
+	if err := setPostMentions(tx, post, *post.UpdatedAt); err != nil {
+		return err
+	}
+
+	// keep the rows of followers who stay in the audience, so their created_at is not reset
+	deleteQuery := `DELETE FROM private_posts WHERE post_id = ?`
+	args := []any{post.Id}
+	if len(post.AllowedFollowers) > 0 {
+		deleteQuery += ` AND user_id NOT IN (?` + strings.Repeat(`, ?`, len(post.AllowedFollowers)-1) + `)`
+		for _, userID := range post.AllowedFollowers {
+			args = append(args, userID)
+		}
+	}
+	if _, err := tx.Exec(deleteQuery, args...); err != nil {
+		return err
+	}
+	for _, userID := range post.AllowedFollowers {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO private_posts (post_id, user_id, created_at) VALUES (?, ?, ?)
+		`, post.Id, userID, post.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes a post with its gallery, comments, reactions, edit history and reposts. It returns the web paths
+// of the images of the post and its earlier versions, and whether the post existed.
+func (r *PostRepository) Delete(postID string) ([]string, bool, error) {
+	return deletePost(r.DB, postID)
+}
+
+func deletePost(db *sql.DB, postID string) ([]string, bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT url FROM post_media WHERE post_id = ?
+		UNION
+		SELECT feed_url FROM post_media WHERE post_id = ? AND feed_url != ''
+		UNION
+		SELECT thumbnail_url FROM post_media WHERE post_id = ? AND thumbnail_url != ''
+		UNION
+		SELECT post_image FROM posts WHERE id = ? AND post_image IS NOT NULL AND post_image != ''
+		UNION
+		SELECT post_image FROM post_edits WHERE post_id = ? AND post_image IS NOT NULL AND post_image != ''
+		UNION
+		SELECT f.value FROM post_edits e, json_each(e.media) m,
+			json_each(json_array(json_extract(m.value, '$.url'), json_extract(m.value, '$.feed_url'), json_extract(m.value, '$.thumbnail_url'))) f
+		WHERE e.post_id = ? AND f.value IS NOT NULL AND f.value != ''
+	`, postID, postID, postID, postID, postID, postID)
+	if err != nil {
+		return nil, false, err
+	}
+	var images []string
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			rows.Close()
+			return nil, false, err
+		}
+		images = append(images, image)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	result, err := tx.Exec(`DELETE FROM posts WHERE id = ?`, postID)
+	if err != nil {
+		return nil, false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, false, err
+	}
+	return images, true, tx.Commit()
+}
+
+// FindEdits returns the previous versions of a post, newest first.
+func (r *PostRepository) FindEdits(postID string) ([]model.PostEdit, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, post_id, COALESCE(editor_id, ''), title, content, visibility, COALESCE(post_image, ''), media, allowed_followers, edited_at
+		FROM post_edits
+		WHERE post_id = ?
+		ORDER BY edited_at DESC, rowid DESC
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []model.PostEdit
+	for rows.Next() {
+		var edit model.PostEdit
+		var media, allowed string
+		if err := rows.Scan(&edit.ID, &edit.PostID, &edit.EditorID, &edit.Title, &edit.Content, &edit.Visibility,
+			&edit.ImageURL, &media, &allowed, &edit.EditedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(media), &edit.Media); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(allowed), &edit.AllowedFollowers); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+	return edits, rows.Err()
+}
+
+// findMedia returns the galleries of the posts keyed by post ID, each in display order.
+func findMedia(db *sql.DB, postIDs []string) (map[string][]model.PostMedia, error) {
+	media := make(map[string][]model.PostMedia)
+	if len(postIDs) == 0 {
+		return media, nil
+	}
+	args := make([]any, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+	rows, err := db.Query(`
+		SELECT id, post_id, url, thumbnail_url, feed_url, alt_text, width, height, blurhash, dominant_color, position
+		FROM post_media
+		WHERE post_id IN (?`+strings.Repeat(`, ?`, len(postIDs)-1)+`)
+		ORDER BY post_id, position
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m model.PostMedia
+		if err := rows.Scan(&m.ID, &m.PostID, &m.URL, &m.ThumbnailURL, &m.FeedURL, &m.AltText, &m.Width, &m.Height,
+			&m.Blurhash, &m.DominantColor, &m.Position); err != nil {
+			return nil, err
+		}
+		media[m.PostID] = append(media[m.PostID], m)
+	}
+	return media, rows.Err()
+}
+
+// attachMedia fills in the gallery of each post, leaving an empty one on posts without images.
+func attachMedia(db *sql.DB, posts []model.Post) error {
+	ids := make([]string, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].Id
+	}
+	media, err := findMedia(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Media = emptyMediaIfNil(media[posts[i].Id])
+	}
+	return nil
+}
+
+// insertMedia saves a post's gallery, numbering the images in the order given.
+func insertMedia(tx *sql.Tx, postID string, media []model.PostMedia) error {
+	for i, m := range media {
+		if _, err := tx.Exec(`
+			INSERT INTO post_media (id, post_id, url, thumbnail_url, feed_url, alt_text, width, height, blurhash, dominant_color, position)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, m.ID, postID, m.URL, m.ThumbnailURL, m.FeedURL, m.AltText, m.Width, m.Height, m.Blurhash, m.DominantColor, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func emptyMediaIfNil(media []model.PostMedia) []model.PostMedia {
+	if media == nil {
+		return []model.PostMedia{}
+	}
+	return media
+}
@@ -2,65 +2,223 @@ package repository
 
 import (
 	"backend/internal/model"
-	"backend/pkg/getusers"
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 )
 
-func GetPosts(id string, db *sql.DB) (*[]model.Post, error) {
+// FeedCursor points at the last post of a feed page. The next page starts with the post after it.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ErrInvalidFeedCursor is returned by ParseFeedCursor for cursors that were not made by FeedCursor.String
+var ErrInvalidFeedCursor = errors.New("invalid feed cursor")
+
+// String encodes the cursor for use in a URL.
+func (c FeedCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "," + c.ID))
+}
+
+// ParseFeedCursor decodes a cursor made by FeedCursor.String.
+func ParseFeedCursor(s string) (*FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidFeedCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), ",")
+	if !ok || id == "" {
+		return nil, ErrInvalidFeedCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidFeedCursor
+	}
+	return &FeedCursor{CreatedAt: t, ID: id}, nil
+}
+
+// attachDetails fills in what feeds show along with each post: its gallery, the users it mentions,
+// the post it reshares and whether the viewer saved it.
+func attachDetails(db *sql.DB, viewerID string, posts []model.Post) error {
+	if err := attachMedia(db, posts); err != nil {
+		return err
+	}
+	if err := attachMentions(db, posts); err != nil {
+		return err
+	}
+	if err := attachReshares(db, viewerID, posts); err != nil {
+		return err
+	}
+	if err := attachPolls(db, viewerID, posts); err != nil {
+		return err
+	}
+	return attachSaved(db, viewerID, posts)
+}
+
+// GetPosts returns up to limit posts the user can see on the home feed, newest first,
+// starting after the cursor or from the newest post if it is nil.
+func GetPosts(id string, db *sql.DB, after *FeedCursor, limit int) (*[]model.Post, error) {
 	var posts []model.Post
 
-	rows, err := db.Query(`
-SELECT 
-    p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at,
+	visible, visibleArgs := visiblePostCondition(id)
+	query := `
+SELECT
+    p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at, p.updated_at,
+    COALESCE(p.repost_of, ''), COALESCE(p.quote_of, ''),
+    u.fname, u.lname, COALESCE(u.imgurl, ''),
     (
-        SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL
+        SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL AND c.hidden_at IS NULL
     ) AS comment_count
 FROM posts p
-WHERE (p.visibility = 'public' 
-   OR p.user_id = ?
-   OR (
-        p.visibility = 'almostprivate'
-        AND EXISTS (
-            SELECT 1
-            FROM followers
-            WHERE (followers.followed_id = ? OR followers.follower_id = ?)
-              AND followers.status = 'accepted'
-        )
-    )
-   OR (
-        p.visibility = 'private'
-        AND EXISTS (
-            SELECT 1 
-            FROM private_posts 
-            WHERE private_posts.post_id = p.id
-              AND private_posts.user_id = ?
-        )
-    ))
-AND p.group_id IS NULL
-ORDER BY p.created_at DESC`, id, id, id, id)
+JOIN users u ON u.id = p.user_id
+WHERE p.group_id IS NULL
+AND p.hidden_at IS NULL
+AND ` + visible
+	args := visibleArgs
 
+	if after != nil {
+		query += `
+AND (julianday(p.created_at) < julianday(?) OR (julianday(p.created_at) = julianday(?) AND p.id < ?))`
+		args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+	}
+	query += `
+ORDER BY julianday(p.created_at) DESC, p.id DESC
+LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-
 		var post model.Post
-		if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &post.CommentCount); err != nil {
+		var updatedAt sql.NullTime
+		var firstName, lastName string
+		if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &updatedAt,
+			&post.RepostOf, &post.QuoteOf, &firstName, &lastName, &post.CreatorImg, &post.CommentCount); err != nil {
 			fmt.Println(err.Error())
 
 			return nil, err
 		}
-		user, err := getusers.GetUserByID(db, post.UserId)
-		if err != nil {
-			fmt.Println("User not found:", post.UserId)
+		if updatedAt.Valid {
+			post.UpdatedAt = &updatedAt.Time
+		}
+		post.Creator = firstName + " " + lastName
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return &posts, attachDetails(db, id, posts)
+}
+
+// GetGroupPosts returns the posts of a group the user can see, newest first. Only active members of the group see any.
+func GetGroupPosts(groupID, viewerID string, db *sql.DB) ([]model.Post, error) {
+	visible, args := visiblePostCondition(viewerID)
+	rows, err := db.Query(`
+SELECT
+    p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at, p.updated_at,
+    COALESCE(p.repost_of, ''), COALESCE(p.quote_of, ''),
+    u.fname, u.lname, COALESCE(u.imgurl, ''),
+    (
+        SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL AND c.hidden_at IS NULL
+    ) AS comment_count
+FROM posts p
+JOIN users u ON u.id = p.user_id
+WHERE p.group_id = ?
+AND `+visible+`
+ORDER BY p.created_at DESC`, append([]any{groupID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		var updatedAt sql.NullTime
+		var firstName, lastName string
+		if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &updatedAt,
+			&post.RepostOf, &post.QuoteOf, &firstName, &lastName, &post.CreatorImg, &post.CommentCount); err != nil {
+			return nil, err
+		}
+		if updatedAt.Valid {
+			post.UpdatedAt = &updatedAt.Time
 		}
-		post.Creator = user.FirstName + " " + user.LastName
-		post.CreatorImg = user.ImgURL
+		post.Creator = firstName + " " + lastName
 		posts = append(posts, post)
 	}
-	return &posts, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return posts, attachDetails(db, viewerID, posts)
+}
+
+// GetTaggedPosts returns up to limit posts the user can see that use the tag, in their own text or in a
+// visible comment, newest first, starting after the cursor or from the newest post if it is nil.
+// Unlike the home feed it includes posts of the user's groups.
+func GetTaggedPosts(tag, viewerID string, db *sql.DB, after *FeedCursor, limit int) ([]model.Post, error) {
+	visible, visibleArgs := visiblePostCondition(viewerID)
+	query := `
+SELECT
+    p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at, p.updated_at,
+    COALESCE(p.repost_of, ''), COALESCE(p.quote_of, ''),
+    u.fname, u.lname, COALESCE(u.imgurl, ''),
+    (
+        SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL AND c.hidden_at IS NULL
+    ) AS comment_count
+FROM posts p
+JOIN users u ON u.id = p.user_id
+WHERE EXISTS (
+    SELECT 1 FROM post_tags t
+    LEFT JOIN comments c ON c.id = t.comment_id
+    WHERE t.post_id = p.id AND t.tag = ? AND (t.comment_id IS NULL OR c.hidden_at IS NULL)
+)
+AND ` + visible
+	args := append([]any{tag}, visibleArgs...)
+
+	if after != nil {
+		query += `
+AND (julianday(p.created_at) < julianday(?) OR (julianday(p.created_at) = julianday(?) AND p.id < ?))`
+		args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+	}
+	query += `
+ORDER BY julianday(p.created_at) DESC, p.id DESC
+LIMIT ?`
+	args = append(args, limit)
 
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		var updatedAt sql.NullTime
+		var firstName, lastName string
+		if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &updatedAt,
+			&post.RepostOf, &post.QuoteOf, &firstName, &lastName, &post.CreatorImg, &post.CommentCount); err != nil {
+			return nil, err
+		}
+		if updatedAt.Valid {
+			post.UpdatedAt = &updatedAt.Time
+		}
+		post.Creator = firstName + " " + lastName
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return posts, attachDetails(db, viewerID, posts)
 }
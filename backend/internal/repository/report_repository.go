@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// ReportRepository handles database operations for reports, moderation actions and warnings
+type ReportRepository struct {
+	DB *sql.DB
+}
+
+// NewReportRepository creates and returns a new instance of ReportRepository.
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{DB: db}
+}
+
+// ReportFilter narrows down the reports listed in the moderation queue
+type ReportFilter struct {
+	Status     string
+	TargetType string
+	Reason     string
+	ClaimedBy  string
+	Limit      int
+	Offset     int
+}
+
+// reportTargetQueries look up the author and a preview of each kind of reportable content
+var reportTargetQueries = map[string]string{
+	model.ReportTargetPost:    `SELECT user_id, '', title || ': ' || content, hidden_at IS NOT NULL FROM posts WHERE id = ?`,
+	model.ReportTargetComment: `SELECT user_id, '', content, hidden_at IS NOT NULL FROM comments WHERE id = ?`,
+	model.ReportTargetMessage: `SELECT COALESCE(sender_id, ''), COALESCE(receiver_id, ''), content, hidden_at IS NOT NULL FROM messages WHERE id = ?`,
+	model.ReportTargetGroup:   `SELECT CAST(creator_id AS TEXT), '', title || ': ' || COALESCE(description, ''), hidden_at IS NOT NULL FROM groups WHERE id = ? AND deleted_at IS NULL`,
+	model.ReportTargetUser:    `SELECT id, '', fname || ' ' || lname || COALESCE(' (' || NULLIF(nickname, '') || ')', ''), 0 FROM users WHERE id = ?`,
+}
+
+// hideTargetQueries hide each kind of content that can be hidden
+var hideTargetQueries = map[string]string{
+	model.ReportTargetPost:    `UPDATE posts SET hidden_at = ? WHERE id = ? AND hidden_at IS NULL`,
+	model.ReportTargetComment: `UPDATE comments SET hidden_at = ? WHERE id = ? AND hidden_at IS NULL`,
+	model.ReportTargetMessage: `UPDATE messages SET hidden_at = ? WHERE id = ? AND hidden_at IS NULL`,
+	model.ReportTargetGroup:   `UPDATE groups SET hidden_at = ? WHERE id = ? AND hidden_at IS NULL`,
+}
+
+// FindTarget retrieves the reported content, or nil if it does not exist (anymore).
+func (r *ReportRepository) FindTarget(targetType, targetID string) (*model.ReportTarget, error) {
+	query, ok := reportTargetQueries[targetType]
+	if !ok {
+		return nil, nil
+	}
+	var target model.ReportTarget
+	err := r.DB.QueryRow(query, targetID).Scan(&target.AuthorID, &target.RecipientID, &target.Preview, &target.Hidden)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// HideTarget hides a piece of content and reports whether it was visible before.
+func (r *ReportRepository) HideTarget(targetType, targetID string, now time.Time) (bool, error) {
+	query, ok := hideTargetQueries[targetType]
+	if !ok {
+		return false, nil
+	}
+	result, err := r.DB.Exec(query, now, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Create stores a new report.
+func (r *ReportRepository) Create(report *model.Report) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO reports (id, reporter_id, target_type, target_id, target_user_id, reason, details, status, created_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?)
+	`, report.ID, report.ReporterID, report.TargetType, report.TargetID, report.TargetUserID,
+		report.Reason, report.Details, report.Status, report.CreatedAt)
+	return err
+}
+
+// HasUnresolved reports whether the reporter already has an unresolved report about the target.
+func (r *ReportRepository) HasUnresolved(reporterID, targetType, targetID string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM reports
+			WHERE reporter_id = ? AND target_type = ? AND target_id = ? AND status != ?
+		)
+	`, reporterID, targetType, targetID, model.ReportResolved).Scan(&exists)
+	return exists, err
+}
+
+const reportColumns = `id, reporter_id, target_type, target_id, target_user_id, reason, details, status,
+	claimed_by, claimed_at, resolution, resolution_note, resolved_by, resolved_at, created_at`
+
+// FindByID retrieves a report, or nil if it does not exist.
+func (r *ReportRepository) FindByID(id string) (*model.Report, error) {
+	row := r.DB.QueryRow(`SELECT `+reportColumns+` FROM reports WHERE id = ?`, id)
+	report, err := scanReport(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return report, err
+}
+
+// List returns the reports matching filter, oldest first so the queue is worked in order.
+func (r *ReportRepository) List(filter ReportFilter) ([]model.Report, error) {
+	var conditions []string
+	var args []any
+	for column, value := range map[string]string{
+		"status":      filter.Status,
+		"target_type": filter.TargetType,
+		"reason":      filter.Reason,
+		"claimed_by":  filter.ClaimedBy,
+	} {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+
+	query := `SELECT ` + reportColumns + ` FROM reports`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	query += ` ORDER BY created_at, id LIMIT ? OFFSET ?`
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []model.Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	return reports, rows.Err()
+}
+
+// Claim assigns an open report to a moderator. It reports false if the report is not open.
+func (r *ReportRepository) Claim(id, moderatorID string, now time.Time) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE reports SET status = ?, claimed_by = ?, claimed_at = ?
+		WHERE id = ? AND status = ?
+	`, model.ReportClaimed, moderatorID, now, id, model.ReportOpen)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Release puts a report claimed by moderatorID back in the queue.
+func (r *ReportRepository) Release(id, moderatorID string) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE reports SET status = ?, claimed_by = NULL, claimed_at = NULL
+		WHERE id = ? AND status = ? AND claimed_by = ?
+	`, model.ReportOpen, id, model.ReportClaimed, moderatorID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ResolveTarget resolves every unresolved report about a target, since one decision answers them all.
+// It returns how many reports were resolved.
+func (r *ReportRepository) ResolveTarget(targetType, targetID, resolution, note, moderatorID string, now time.Time) (int64, error) {
+	result, err := r.DB.Exec(`
+		UPDATE reports
+		SET status = ?, resolution = ?, resolution_note = ?, resolved_by = ?, resolved_at = ?
+		WHERE target_type = ? AND target_id = ? AND status != ?
+	`, model.ReportResolved, resolution, note, moderatorID, now, targetType, targetID, model.ReportResolved)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CreateWarning records a warning against a user.
+func (r *ReportRepository) CreateWarning(warning *model.UserWarning, moderatorID string) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO user_warnings (id, user_id, report_id, moderator_id, reason, created_at)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?, ?)
+	`, warning.ID, warning.UserID, warning.ReportID, moderatorID, warning.Reason, warning.CreatedAt)
+	return err
+}
+
+// FindWarningsByUserID returns the warnings issued to a user, newest first.
+func (r *ReportRepository) FindWarningsByUserID(userID string) ([]model.UserWarning, error) {
+	rows, err := r.DB.Query(`
+		SELECT id, user_id, COALESCE(report_id, ''), reason, created_at
+		FROM user_warnings
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warnings []model.UserWarning
+	for rows.Next() {
+		var warning model.UserWarning
+		if err := rows.Scan(&warning.ID, &warning.UserID, &warning.ReportID, &warning.Reason, &warning.CreatedAt); err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings, rows.Err()
+}
+
+type reportScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReport(row reportScanner) (*model.Report, error) {
+	var report model.Report
+	var targetUserID, claimedBy, resolution, resolutionNote, resolvedBy sql.NullString
+	var claimedAt, resolvedAt sql.NullTime
+	err := row.Scan(&report.ID, &report.ReporterID, &report.TargetType, &report.TargetID, &targetUserID,
+		&report.Reason, &report.Details, &report.Status, &claimedBy, &claimedAt, &resolution, &resolutionNote,
+		&resolvedBy, &resolvedAt, &report.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	report.TargetUserID = targetUserID.String
+	report.ClaimedBy = claimedBy.String
+	report.Resolution = resolution.String
+	report.ResolutionNote = resolutionNote.String
+	report.ResolvedBy = resolvedBy.String
+	if claimedAt.Valid {
+		report.ClaimedAt = &claimedAt.Time
+	}
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	return &report, nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"cmp"
+	"database/sql"
+	"strings"
+
+	"backend/internal/model"
+)
+
+// FindRepost returns the ID of the user's repost of the post, or "" if they have not reposted it.
+func (r *PostRepository) FindRepost(userID, postID string) (string, error) {
+	var id string
+	err := r.DB.QueryRow(`SELECT id FROM posts WHERE repost_of = ? AND user_id = ?`, postID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// attachReshares fills in the post each repost and quote shares, as the viewer may see it, and how many
+// times each post, shared ones included, was reposted and quoted. Quotes of posts that were deleted or
+// that the viewer may not see get an OriginalStatus instead.
+func attachReshares(db *sql.DB, viewerID string, posts []model.Post) error {
+	var originalIDs []string
+	for _, post := range posts {
+		if id := cmp.Or(post.RepostOf, post.QuoteOf); id != "" {
+			originalIDs = append(originalIDs, id)
+		}
+	}
+	originals, err := findOriginals(db, viewerID, originalIDs)
+	if err != nil {
+		return err
+	}
+	if err := countReshares(db, viewerID, originals); err != nil {
+		return err
+	}
+	byID := make(map[string]*model.Post, len(originals))
+	for i := range originals {
+		byID[originals[i].Id] = &originals[i]
+	}
+
+	var missing []string
+	for i := range posts {
+		if original := byID[cmp.Or(posts[i].RepostOf, posts[i].QuoteOf)]; original != nil {
+			posts[i].Original = original
+		} else if posts[i].QuoteOf != "" {
+			missing = append(missing, posts[i].QuoteOf)
+		}
+	}
+	existing, err := existingPosts(db, missing)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		if posts[i].QuoteOf == "" || posts[i].Original != nil {
+			continue
+		}
+		posts[i].OriginalStatus = model.OriginalDeleted
+		if existing[posts[i].QuoteOf] {
+			posts[i].OriginalStatus = model.OriginalUnavailable
+		}
+	}
+	return countReshares(db, viewerID, posts)
+}
+
+// findOriginals returns the posts with the given IDs that the viewer may see, with their galleries and mentions.
+func findOriginals(db *sql.DB, viewerID string, ids []string) ([]model.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	visible, visibleArgs := visiblePostCondition(viewerID)
+	args := make([]any, 0, len(ids)+len(visibleArgs))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := db.Query(`
+SELECT
+    p.id, p.user_id, p.title, p.content, p.visibility, p.post_image, p.created_at, p.updated_at, p.group_id,
+    COALESCE(p.quote_of, ''), u.fname, u.lname, COALESCE(u.imgurl, ''),
+    (
+        SELECT COUNT(1) FROM comments c WHERE c.post_id = p.id AND c.parent_id IS NULL AND c.hidden_at IS NULL
+    ) AS comment_count
+FROM posts p
+JOIN users u ON u.id = p.user_id
+WHERE p.id IN (?`+strings.Repeat(`, ?`, len(ids)-1)+`)
+AND `+visible, append(args, visibleArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		var updatedAt sql.NullTime
+		var firstName, lastName string
+		if err := rows.Scan(&post.Id, &post.UserId, &post.Title, &post.Content, &post.Visibility, &post.ImageUrl, &post.CreatedAt, &updatedAt,
+			&post.GroupId, &post.QuoteOf, &firstName, &lastName, &post.CreatorImg, &post.CommentCount); err != nil {
+			return nil, err
+		}
+		if updatedAt.Valid {
+			post.UpdatedAt = &updatedAt.Time
+		}
+		post.Creator = firstName + " " + lastName
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err := attachMedia(db, posts); err != nil {
+		return nil, err
+	}
+	return posts, attachMentions(db, posts)
+}
+
+// existingPosts returns which of the posts still exist, hidden or not.
+func existingPosts(db *sql.DB, ids []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := db.Query(`SELECT id FROM posts WHERE id IN (?`+strings.Repeat(`, ?`, len(ids)-1)+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing[id] = true
+	}
+	return existing, rows.Err()
+}
+
+// countReshares fills in how many times each post was reposted and quoted, leaving out reshares hidden by a
+// moderator, and whether the viewer reposted it.
+func countReshares(db *sql.DB, viewerID string, posts []model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	args := []any{viewerID}
+	for _, post := range posts {
+		args = append(args, post.Id)
+	}
+	rows, err := db.Query(`
+		SELECT p.id,
+			(SELECT COUNT(*) FROM posts r WHERE r.repost_of = p.id AND r.hidden_at IS NULL),
+			(SELECT COUNT(*) FROM posts q WHERE q.quote_of = p.id AND q.hidden_at IS NULL),
+			EXISTS(SELECT 1 FROM posts r WHERE r.repost_of = p.id AND r.user_id = ?)
+		FROM posts p
+		WHERE p.id IN (?`+strings.Repeat(`, ?`, len(posts)-1)+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type reshares struct {
+		reposts, quotes int
+		reposted        bool
+	}
+	counts := make(map[string]reshares)
+	for rows.Next() {
+		var id string
+		var c reshares
+		if err := rows.Scan(&id, &c.reposts, &c.quotes, &c.reposted); err != nil {
+			return err
+		}
+		counts[id] = c
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range posts {
+		c := counts[posts[i].Id]
+		posts[i].RepostCount, posts[i].QuoteCount, posts[i].Reposted = c.reposts, c.quotes, c.reposted
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/pkg/hashtag"
+)
+
+// TagRepository reads the hashtags of posts and keeps the trending tags
+type TagRepository struct {
+	DB *sql.DB
+}
+
+// NewTagRepository creates and returns a new instance of TagRepository.
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{DB: db}
+}
+
+// postTags returns the tags of a post's title and content
+func postTags(post *model.Post) []string {
+	return hashtag.Parse(post.Title + "\n" + post.Content)
+}
+
+// setPostTags makes the tags of a post's own text match tags. Tags it already had keep their created_at,
+// new ones get at.
+func setPostTags(tx *sql.Tx, postID string, tags []string, at time.Time) error {
+	query := `DELETE FROM post_tags WHERE post_id = ? AND comment_id IS NULL`
+	args := []any{postID}
+	if len(tags) > 0 {
+		query += ` AND tag NOT IN (?` + strings.Repeat(`, ?`, len(tags)-1) + `)`
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO post_tags (post_id, comment_id, tag, created_at) VALUES (?, NULL, ?, ?)
+		`, postID, tag, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertCommentTags saves the tags used in a new comment, in the transaction creating it.
+func InsertCommentTags(tx *sql.Tx, postID, commentID, content string, createdAt time.Time) error {
+	for _, tag := range hashtag.Parse(content) {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO post_tags (post_id, comment_id, tag, created_at) VALUES (?, ?, ?, ?)
+		`, postID, commentID, tag, createdAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackfillTags indexes the tags of up to limit posts queued in tag_backfill and of their comments, dated like
+// the post or comment using them, and takes the posts off the queue. It returns how many posts it went through.
+func (r *TagRepository) BackfillTags(limit int) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT p.id, p.title, p.content FROM tag_backfill b JOIN posts p ON p.id = b.post_id LIMIT ?
+	`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		if err := rows.Scan(&post.Id, &post.Title, &post.Content); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		posts = append(posts, post)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, post := range posts {
+		for _, tag := range postTags(&post) {
+			if _, err := tx.Exec(`
+				INSERT OR IGNORE INTO post_tags (post_id, comment_id, tag, created_at)
+				SELECT id, NULL, ?, COALESCE(created_at, CURRENT_TIMESTAMP) FROM posts WHERE id = ?
+			`, tag, post.Id); err != nil {
+				return 0, err
+			}
+		}
+		if err := backfillCommentTags(tx, post.Id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`DELETE FROM tag_backfill WHERE post_id = ?`, post.Id); err != nil {
+			return 0, err
+		}
+	}
+	return len(posts), tx.Commit()
+}
+
+func backfillCommentTags(tx *sql.Tx, postID string) error {
+	rows, err := tx.Query(`SELECT id, content FROM comments WHERE post_id = ?`, postID)
+	if err != nil {
+		return err
+	}
+	contents := map[string]string{}
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		contents[id] = content
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, content := range contents {
+		for _, tag := range hashtag.Parse(content) {
+			if _, err := tx.Exec(`
+				INSERT OR IGNORE INTO post_tags (post_id, comment_id, tag, created_at)
+				SELECT post_id, id, ?, created_at FROM comments WHERE id = ?
+			`, tag, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FindUses returns the tags used since the given time by posts everyone can see and their visible comments.
+// Tags only seen by followers or group members are left out so trending does not give them away.
+func (r *TagRepository) FindUses(since time.Time) ([]model.TagUse, error) {
+	rows, err := r.DB.Query(`
+		SELECT t.tag, COALESCE(c.user_id, p.user_id), t.created_at
+		FROM post_tags t
+		JOIN posts p ON p.id = t.post_id
+		LEFT JOIN comments c ON c.id = t.comment_id
+		WHERE p.hidden_at IS NULL AND p.group_id IS NULL AND p.visibility = 'public'
+		AND (t.comment_id IS NULL OR c.hidden_at IS NULL)
+		AND julianday(t.created_at) >= julianday(?)
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uses []model.TagUse
+	for rows.Next() {
+		var use model.TagUse
+		if err := rows.Scan(&use.Tag, &use.UserID, &use.CreatedAt); err != nil {
+			return nil, err
+		}
+		uses = append(uses, use)
+	}
+	return uses, rows.Err()
+}
+
+// ReplaceTrending replaces the trending tags with tags, in one transaction.
+func (r *TagRepository) ReplaceTrending(tags []model.TrendingTag) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM trending_tags`); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+			INSERT INTO trending_tags (tag, users, score, computed_at) VALUES (?, ?, ?, ?)
+		`, tag.Tag, tag.Users, tag.Score, tag.ComputedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindTrending returns up to limit trending tags, highest score first.
+func (r *TagRepository) FindTrending(limit int) ([]model.TrendingTag, error) {
+	rows, err := r.DB.Query(`
+		SELECT tag, users, score, computed_at FROM trending_tags ORDER BY score DESC, tag LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []model.TrendingTag{}
+	for rows.Next() {
+		var tag model.TrendingTag
+		if err := rows.Scan(&tag.Tag, &tag.Users, &tag.Score, &tag.ComputedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+// TwoFactorRepository handles database operations for TOTP enrollment and login challenges
+type TwoFactorRepository struct {
+	DB *sql.DB
+}
+
+// NewTwoFactorRepository creates and returns a new instance of TwoFactorRepository.
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{DB: db}
+}
+
+// FindByUserID retrieves the TOTP enrollment of a user, or nil if the user never enrolled.
+func (r *TwoFactorRepository) FindByUserID(userID string) (*model.TwoFactor, error) {
+	var tf model.TwoFactor
+	var confirmedAt sql.NullTime
+	err := r.DB.QueryRow(`
+		SELECT user_id, secret, enabled, last_used_step, created_at, confirmed_at
+		FROM user_totp
+		WHERE user_id = ?
+	`, userID).Scan(&tf.UserID, &tf.Secret, &tf.Enabled, &tf.LastUsedStep, &tf.CreatedAt, &confirmedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		tf.ConfirmedAt = &confirmedAt.Time
+	}
+	return &tf, nil
+}
+
+// SavePending stores a new, not yet confirmed secret for a user, replacing any earlier pending one.
+func (r *TwoFactorRepository) SavePending(userID, secret string) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO user_totp (user_id, secret, enabled, last_used_step, created_at)
+		VALUES (?, ?, 0, 0, CURRENT_TIMESTAMP)
+		ON CONFLICT(user_id) DO UPDATE SET
+			secret = excluded.secret,
+			enabled = 0,
+			last_used_step = 0,
+			created_at = CURRENT_TIMESTAMP,
+			confirmed_at = NULL
+	`, userID, secret)
+	return err
+}
+
+// Enable marks a pending enrollment as confirmed using a transaction.
+func (r *TwoFactorRepository) Enable(tx *sql.Tx, userID string, step int64, confirmedAt time.Time) error {
+	_, err := tx.Exec(`
+		UPDATE user_totp
+		SET enabled = 1, last_used_step = ?, confirmed_at = ?
+		WHERE user_id = ?
+	`, step, confirmedAt, userID)
+	return err
+}
+
+// ReplaceRecoveryCodes removes all recovery codes of a user and stores the given hashes using a transaction.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, hash := range codeHashes {
+		if _, err := stmt.Exec(userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdvanceLastUsedStep records the time step of an accepted code.
+// It returns false when the step was already used, so the same code cannot be replayed.
+func (r *TwoFactorRepository) AdvanceLastUsedStep(userID string, step int64) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE user_totp
+		SET last_used_step = ?
+		WHERE user_id = ? AND enabled = 1 AND last_used_step < ?
+	`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used. It returns false if no such code exists.
+func (r *TwoFactorRepository) UseRecoveryCode(userID, codeHash string, usedAt time.Time) (bool, error) {
+	result, err := r.DB.Exec(`
+		UPDATE totp_recovery_codes
+		SET used_at = ?
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, usedAt, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// CountUnusedRecoveryCodes returns how many recovery codes a user has left.
+func (r *TwoFactorRepository) CountUnusedRecoveryCodes(userID string) (int, error) {
+	var count int
+	err := r.DB.QueryRow(`
+		SELECT COUNT(*) FROM totp_recovery_codes WHERE user_id = ? AND used_at IS NULL
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// Delete removes the TOTP enrollment, recovery codes and pending challenges of a user.
+func (r *TwoFactorRepository) Delete(userID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM login_challenges WHERE user_id = ?`,
+		`DELETE FROM totp_recovery_codes WHERE user_id = ?`,
+		`DELETE FROM user_totp WHERE user_id = ?`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CreateChallenge inserts a new login challenge.
+func (r *TwoFactorRepository) CreateChallenge(challenge *model.LoginChallenge) error {
+	_, err := r.DB.Exec(`
+		INSERT INTO login_challenges (id, user_id, attempts, expires_at, created_at)
+		VALUES (?, ?, 0, ?, ?)
+	`, challenge.ID, challenge.UserID, challenge.ExpiresAt, challenge.CreatedAt)
+	return err
+}
+
+// FindChallenge retrieves a login challenge by its ID, or nil if it does not exist.
+func (r *TwoFactorRepository) FindChallenge(id string) (*model.LoginChallenge, error) {
+	var c model.LoginChallenge
+	err := r.DB.QueryRow(`
+		SELECT id, user_id, attempts, expires_at, created_at
+		FROM login_challenges
+		WHERE id = ?
+	`, id).Scan(&c.ID, &c.UserID, &c.Attempts, &c.ExpiresAt, &c.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// IncrementChallengeAttempts records a failed code submission and returns the new attempt count.
+func (r *TwoFactorRepository) IncrementChallengeAttempts(id string) (int, error) {
+	if _, err := r.DB.Exec(`UPDATE login_challenges SET attempts = attempts + 1 WHERE id = ?`, id); err != nil {
+		return 0, err
+	}
+	var attempts int
+	err := r.DB.QueryRow(`SELECT attempts FROM login_challenges WHERE id = ?`, id).Scan(&attempts)
+	return attempts, err
+}
+
+// DeleteChallenge removes a login challenge.
+func (r *TwoFactorRepository) DeleteChallenge(id string) error {
+	_, err := r.DB.Exec(`DELETE FROM login_challenges WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import "database/sql"
+
+// draftUses is the condition that the draft d has the uploaded file in its gallery
+const draftUses = `EXISTS (
+        SELECT 1 FROM json_each(d.media) m
+        WHERE ? IN (json_extract(m.value, '$.url'), json_extract(m.value, '$.feed_url'), json_extract(m.value, '$.thumbnail_url'))
+    )`
+
+// editUses is the condition that the previous version e of a post had the uploaded file
+const editUses = `(e.post_image = ? OR EXISTS (
+        SELECT 1 FROM json_each(e.media) m
+        WHERE ? IN (json_extract(m.value, '$.url'), json_extract(m.value, '$.feed_url'), json_extract(m.value, '$.thumbnail_url'))
+    ))`
+
+// UploadInUse reports whether a profile, a post, the edit history of a post or a draft still refers to the
+// uploaded file at webPath. Uploads are stored under the hash of their content, so one file can belong to
+// several of them.
+func UploadInUse(db *sql.DB, webPath string) (bool, error) {
+	var used bool
+	err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM users WHERE imgurl = ?)
+		    OR EXISTS (SELECT 1 FROM posts WHERE post_image = ?)
+		    OR EXISTS (SELECT 1 FROM post_media WHERE url = ? OR feed_url = ? OR thumbnail_url = ?)
+		    OR EXISTS (SELECT 1 FROM post_edits e WHERE `+editUses+`)
+		    OR EXISTS (SELECT 1 FROM post_drafts d WHERE `+draftUses+`)
+	`, webPath, webPath, webPath, webPath, webPath, webPath, webPath, webPath).Scan(&used)
+	return used, err
+}
+
+// MediaAccess reports whether the viewer may see the uploaded file at webPath, and whether everyone may.
+// A file can be seen wherever it is used: avatars by everyone, post images by whoever can see one of the
+// posts using them, see visiblePostCondition, images of earlier versions of a post by whoever may see its
+// edit history, its author and the admins of its group, and images of drafts by their authors. Files
+// nothing uses are seen by no one.
+func MediaAccess(db *sql.DB, webPath, viewerID string) (visible, public bool, err error) {
+	uses := `(p.post_image = ? OR EXISTS (
+        SELECT 1 FROM post_media pm WHERE pm.post_id = p.id AND (pm.url = ? OR pm.feed_url = ? OR pm.thumbnail_url = ?)
+    ))`
+	visibleToViewer, visibleArgs := visiblePostCondition(viewerID)
+
+	args := []any{webPath, webPath, webPath, webPath, webPath, webPath, webPath, webPath, webPath}
+	args = append(args, visibleArgs...)
+	args = append(args, webPath, webPath, viewerID, viewerID, viewerID, webPath)
+	err = db.QueryRow(`
+SELECT
+    EXISTS (SELECT 1 FROM users WHERE imgurl = ?)
+    OR EXISTS (
+        SELECT 1 FROM posts p
+        WHERE `+uses+`
+        AND p.hidden_at IS NULL AND p.group_id IS NULL AND p.visibility = 'public'
+    ),
+    EXISTS (SELECT 1 FROM posts p WHERE `+uses+` AND `+visibleToViewer+`)
+    OR EXISTS (
+        SELECT 1 FROM post_edits e JOIN posts p ON p.id = e.post_id
+        WHERE `+editUses+`
+        AND (p.user_id = ? OR EXISTS (
+            SELECT 1 FROM group_members gm
+            WHERE gm.group_id = p.group_id AND gm.user_id = ? AND gm.role = 'admin' AND gm.status = 'active' AND gm.deleted_at IS NULL
+        ))
+    )
+    OR EXISTS (SELECT 1 FROM post_drafts d WHERE d.user_id = ? AND `+draftUses+`)
+`, args...).Scan(&public, &visible)
+	return visible || public, public, err
+}
@@ -62,3 +62,37 @@ func GetUserByNickname(db *sql.DB, nickname string) bool {
 
 	return false
 }
+
+// FindAccess retrieves the site-wide role and any suspension of a user.
+// It returns sql.ErrNoRows if the user does not exist.
+func (r *UserRepository) FindAccess(userID string) (*model.UserAccess, error) {
+	var access model.UserAccess
+	var suspendedAt, suspendedUntil sql.NullTime
+	var reason, suspendedBy sql.NullString
+	err := r.DB.QueryRow(`
+		SELECT role, suspended_at, suspended_until, suspension_reason, suspended_by
+		FROM users
+		WHERE id = ?
+	`, userID).Scan(&access.Role, &suspendedAt, &suspendedUntil, &reason, &suspendedBy)
+	if err != nil {
+		return nil, err
+	}
+	access.Suspension = scanSuspension(suspendedAt, suspendedUntil, reason, suspendedBy)
+	return &access, nil
+}
+
+// scanSuspension builds a suspension from the users columns, or nil if the user is not suspended
+func scanSuspension(suspendedAt, suspendedUntil sql.NullTime, reason, suspendedBy sql.NullString) *model.UserSuspension {
+	if !suspendedAt.Valid {
+		return nil
+	}
+	suspension := &model.UserSuspension{
+		SuspendedAt: suspendedAt.Time,
+		Reason:      reason.String,
+		SuspendedBy: suspendedBy.String,
+	}
+	if suspendedUntil.Valid {
+		suspension.Until = &suspendedUntil.Time
+	}
+	return suspension
+}
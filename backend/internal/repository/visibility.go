@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// visiblePostCondition returns the SQL condition, and its arguments, that holds for the rows of posts p
+// the viewer may see:
+//   - authors always see their own posts
+//   - group posts are seen by the active members of the group, whatever their visibility
+//   - public posts are seen by everyone
+//   - almostprivate posts are seen by the author's accepted followers
+//   - private posts are seen by the followers the author picked in private_posts
+//   - reposts are only seen by those who may also see the post they share, so resharing a post
+//     never shows it to anyone its author did not
+//
+// Posts hidden by a moderator are seen by no one.
+func visiblePostCondition(viewerID string) (string, []any) {
+	own, args := audienceCondition("p", viewerID)
+	original, originalArgs := audienceCondition("o", viewerID)
+	return `(` + own + `
+AND (p.repost_of IS NULL OR EXISTS (SELECT 1 FROM posts o WHERE o.id = p.repost_of AND ` + original + `)))`,
+		append(args, originalArgs...)
+}
+
+// audienceCondition holds for the rows of posts alias the viewer may see on their own, regardless of
+// what they repost.
+func audienceCondition(alias, viewerID string) (string, []any) {
+	return fmt.Sprintf(`(%[1]s.hidden_at IS NULL AND (
+    %[1]s.user_id = ?
+    OR (
+        %[1]s.group_id IS NOT NULL
+        AND EXISTS (
+            SELECT 1 FROM group_members gm
+            WHERE CAST(gm.group_id AS TEXT) = %[1]s.group_id
+              AND gm.user_id = ?
+              AND gm.status = 'active'
+              AND gm.deleted_at IS NULL
+        )
+    )
+    OR (%[1]s.group_id IS NULL AND %[1]s.visibility = 'public')
+    OR (
+        %[1]s.group_id IS NULL
+        AND %[1]s.visibility = 'almostprivate'
+        AND EXISTS (
+            SELECT 1 FROM followers f
+            WHERE f.follower_id = ?
+              AND f.followed_id = %[1]s.user_id
+              AND f.status = 'accepted'
+        )
+    )
+    OR (
+        %[1]s.group_id IS NULL
+        AND %[1]s.visibility = 'private'
+        AND EXISTS (
+            SELECT 1 FROM private_posts pp
+            WHERE pp.post_id = %[1]s.id
+              AND pp.user_id = ?
+        )
+    )
+))`, alias), []any{viewerID, viewerID, viewerID, viewerID}
+}
+
+// PostVisibleTo reports whether the post exists and the viewer may see it, and so comment on and react to it.
+func PostVisibleTo(db *sql.DB, postID, viewerID string) (bool, error) {
+	condition, args := visiblePostCondition(viewerID)
+	var visible bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM posts p WHERE p.id = ? AND `+condition+`)`,
+		append([]any{postID}, args...)...).Scan(&visible)
+	return visible, err
+}
@@ -2,17 +2,28 @@ package routes
 
 import (
 	"database/sql"
+	"log"
 	"net/http"
+	"os"
 	"strings"
 
+	"backend/internal/config"
 	"backend/internal/handler"
 	"backend/internal/middlewares"
+	"backend/internal/model"
+	"backend/internal/policy"
 	"backend/internal/repository"
 	"backend/internal/service"
+	"backend/internal/utils"
+	"backend/pkg/oidc"
 )
 
 // RegisterRoutes sets up the HTTP routes for the API endpoints.
-func RegisterRoutes(db *sql.DB) {
+func RegisterRoutes(db *sql.DB, cfg *config.Config) {
+	auditRepo := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepo)
+	auditHandler := &handler.AuditHandler{Service: auditService}
+
 	// Initialize User-related dependencies
 	userRepo := &repository.UserRepository{DB: db}
 	userService := &service.UserService{Repo: userRepo}
@@ -20,34 +31,136 @@ func RegisterRoutes(db *sql.DB) {
 
 	groupRepo := repository.NewGroupRepository(db)
 	groupService := service.NewGroupService(groupRepo)
-	groupHandler := &handler.GroupHandler{Service: groupService}
+	accessPolicy := policy.New(db)
+	groupHandler := &handler.GroupHandler{Service: groupService, Audit: auditService, Policy: accessPolicy}
+
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepo)
+	twoFactorHandler := &handler.TwoFactorHandler{Service: twoFactorService, Audit: auditService}
+
+	apiTokenRepo := repository.NewAPITokenRepository(db)
+	apiTokenService := service.NewAPITokenService(apiTokenRepo)
+	apiTokenHandler := &handler.APITokenHandler{Service: apiTokenService, Audit: auditService}
+
+	accountRepo := repository.NewAccountRepository(db)
+	accountService := service.NewAccountService(accountRepo)
+	accountService.Audit = auditService
+	accountHandler := &handler.AccountHandler{Service: accountService, Audit: auditService}
+
+	adminRepo := repository.NewAdminRepository(db)
+	adminService := service.NewAdminService(adminRepo)
+	adminHandler := &handler.AdminHandler{Service: adminService, Audit: auditService}
+
+	postRepo := repository.NewPostRepository(db)
+	postService := service.NewPostService(postRepo)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationService := service.NewNotificationService(notificationRepo)
+	notificationService.Push = handler.PushNotification
+	notificationHandler := &handler.NotificationHandler{Service: notificationService}
+
+	mentionService := service.NewMentionService(repository.NewMentionRepository(db), notificationService)
+	mentionHandler := &handler.MentionHandler{Service: mentionService}
+
+	postHandler := &handler.PostHandler{Service: postService, Mentions: mentionService}
+	repostHandler := &handler.RepostHandler{Service: service.NewRepostService(postRepo)}
+	bookmarkHandler := &handler.BookmarkHandler{Service: service.NewBookmarkService(repository.NewBookmarkRepository(db))}
+	pollHandler := &handler.PollHandler{Service: service.NewPollService(repository.NewPollRepository(db))}
+	draftHandler := &handler.DraftHandler{Service: service.NewDraftService(repository.NewDraftRepository(db)), Mentions: mentionService}
+
+	reportRepo := repository.NewReportRepository(db)
+	moderationService := service.NewModerationService(reportRepo, adminService)
+	reportHandler := &handler.ReportHandler{Service: moderationService, Audit: auditService, Policy: accessPolicy}
+
+	oidcProviders, err := oidc.ProvidersFromEnv(os.Getenv)
+	if err != nil {
+		log.Printf("Sign in with external providers disabled: %v", err)
+	}
+	oidcRepo := repository.NewOIDCRepository(db)
+	oidcService := service.NewOIDCService(oidcRepo, userService, oidcProviders)
+	oidcHandler := &handler.OIDCHandler{Service: oidcService, DB: db, FrontendURL: cfg.FrontendURL}
+
+	tagService := service.NewTagService(repository.NewTagRepository(db))
+	tagHandler := &handler.TagHandler{Service: tagService}
+
+	mediaHandler := &handler.MediaHandler{Uploads: utils.Uploads, Policy: accessPolicy}
+
+	// Uploaded files, to anyone for public ones and to signed in users who may see the rest
+	http.HandleFunc(utils.UploadsPath, middlewares.OptionalAuth(db, mediaHandler.Serve))
 
 	// Public routes (no authentication required)
+	http.HandleFunc("/api/csrf", handler.GetCSRFToken)
 	http.HandleFunc("/api/register", userHandler.Register)
 	http.HandleFunc("/api/login", handler.LoginHandler)
+	http.HandleFunc("/api/login/2fa", handler.LoginTwoFactorHandler)
 	http.HandleFunc("/api/logout", handler.LogoutHandler)
+	http.HandleFunc("/api/auth/oidc/", oidcHandler.Route)
 
 	// http.Handle("/api/profile/", middlewares.AuthMiddleware(db, userHandler.Profile))
-	http.Handle("/api/profile/", middlewares.AuthMiddleware(db, handler.ProfileHandler(db)))
-	http.HandleFunc("/api/users/available", middlewares.AuthMiddleware(db, handler.GetFollowSuggestions(db)))
-	http.HandleFunc("/api/users/follow", middlewares.AuthMiddleware(db, handler.FollowUser(db)))
-	http.HandleFunc("/api/follow/accept", middlewares.AuthMiddleware(db, handler.AcceptFollowRequest(db)))
-	http.HandleFunc("/api/follow/decline", middlewares.AuthMiddleware(db, handler.DeclineFollowRequest(db)))
-	http.HandleFunc("/api/follow/cancel", middlewares.AuthMiddleware(db, handler.CancelFollowRequest(db)))
-	http.HandleFunc("/api/follow-status/", middlewares.AuthMiddleware(db, handler.GetFollowStatus(db)))
-	http.HandleFunc("/api/followers/", middlewares.AuthMiddleware(db, handler.GetFollowers(db)))
-	http.HandleFunc("/api/following/", middlewares.AuthMiddleware(db, handler.GetFollowing(db)))
-	http.HandleFunc("/api/follow-relationship", middlewares.AuthMiddleware(db, handler.CheckFollowRelationship(db)))
-	http.HandleFunc("/ws", handler.WebSocketConnection(db))
-	http.HandleFunc("/api/users", middlewares.AuthMiddleware(db, handler.HandleUserStatuses(db)))
-	http.HandleFunc("/api/conversations", middlewares.AuthMiddleware(db, handler.PrivateConversations(db)))
+	http.Handle("/api/profile/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.ProfileHandler(db))))
+	http.HandleFunc("/api/users/available", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.GetFollowSuggestions(db))))
+	http.HandleFunc("/api/users/follow", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.FollowUser(db))))
+	http.HandleFunc("/api/follow/accept", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.AcceptFollowRequest(db))))
+	http.HandleFunc("/api/follow/decline", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.DeclineFollowRequest(db))))
+	http.HandleFunc("/api/follow/cancel", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.CancelFollowRequest(db))))
+	http.HandleFunc("/api/follow-status/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.GetFollowStatus(db))))
+	http.HandleFunc("/api/followers/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.GetFollowers(db))))
+	http.HandleFunc("/api/following/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.GetFollowing(db))))
+	http.HandleFunc("/api/follow-relationship", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.CheckFollowRelationship(db))))
+	http.HandleFunc("/ws", handler.WebSocketConnection(db, cfg))
+	http.HandleFunc("/api/users", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeMessage, handler.HandleUserStatuses(db))))
+	http.HandleFunc("/api/conversations", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeMessage, handler.PrivateConversations(db))))
+
+	// Session management
+	http.HandleFunc("/api/sessions", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.GetSessions(db))))
+	http.HandleFunc("/api/sessions/revoke-others", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.RevokeOtherSessions(db))))
+	http.HandleFunc("/api/sessions/", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.RevokeSession(db))))
+	http.HandleFunc("/api/identities", middlewares.AuthMiddleware(db, middlewares.RequireSession(oidcHandler.Identities)))
+	http.HandleFunc("/api/login-attempts", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.GetFailedLoginAttempts(db))))
+
+	// Two-factor authentication enrollment
+	http.HandleFunc("/api/2fa", middlewares.AuthMiddleware(db, middlewares.RequireSession(twoFactorHandler.Status)))
+	http.HandleFunc("/api/2fa/enroll", middlewares.AuthMiddleware(db, middlewares.RequireSession(twoFactorHandler.Enroll)))
+	http.HandleFunc("/api/2fa/confirm", middlewares.AuthMiddleware(db, middlewares.RequireSession(twoFactorHandler.Confirm)))
+	http.HandleFunc("/api/2fa/disable", middlewares.AuthMiddleware(db, middlewares.RequireSession(twoFactorHandler.Disable)))
+
+	// Personal API tokens, managed from a browser session only.
+	// Bearer requests reach the routes wrapped in RequireScope if the token has that scope.
+	http.HandleFunc("/api/tokens", middlewares.AuthMiddleware(db, middlewares.RequireSession(apiTokenHandler.Tokens)))
+	http.HandleFunc("/api/tokens/", middlewares.AuthMiddleware(db, middlewares.RequireSession(apiTokenHandler.Revoke)))
+
+	// Account deletion and data export, from a browser session only
+	http.HandleFunc("/api/account/deletion", middlewares.AuthMiddleware(db, middlewares.RequireSession(accountHandler.Deletion)))
+	http.HandleFunc("/api/account/exports", middlewares.AuthMiddleware(db, middlewares.RequireSession(accountHandler.Exports)))
+	http.HandleFunc("/api/account/exports/", middlewares.AuthMiddleware(db, middlewares.RequireSession(accountHandler.DownloadExport)))
+
+	// Site administration, for moderators and admins in a browser session
+	moderator := func(next http.HandlerFunc) http.HandlerFunc {
+		return middlewares.AuthMiddleware(db, middlewares.RequireSession(middlewares.RequireRole(model.RoleModerator, next)))
+	}
+	http.HandleFunc("/api/admin/users", moderator(adminHandler.Users))
+	http.HandleFunc("/api/admin/users/", moderator(adminHandler.User))
+	http.HandleFunc("/api/admin/posts/", moderator(adminHandler.DeletePost))
+	http.HandleFunc("/api/admin/comments/", moderator(adminHandler.DeleteComment))
+	http.HandleFunc("/api/moderation/reports", moderator(reportHandler.Queue))
+	http.HandleFunc("/api/moderation/reports/", moderator(reportHandler.Report))
+
+	// The audit log, for admins only
+	admin := func(next http.HandlerFunc) http.HandlerFunc {
+		return middlewares.AuthMiddleware(db, middlewares.RequireSession(middlewares.RequireRole(model.RoleAdmin, next)))
+	}
+	http.HandleFunc("/api/admin/audit", admin(auditHandler.Events))
+	http.HandleFunc("/api/admin/audit/export", admin(auditHandler.Export))
+
+	// Reporting content, and the warnings a user has received
+	http.HandleFunc("/api/reports/", middlewares.AuthMiddleware(db, middlewares.RequireSession(reportHandler.Create)))
+	http.HandleFunc("/api/warnings", middlewares.AuthMiddleware(db, middlewares.RequireSession(reportHandler.Warnings)))
 
 	groupsHandler := func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			middlewares.AuthMiddleware(db, http.HandlerFunc(groupHandler.GetGroups)).ServeHTTP(w, r)
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, groupHandler.GetGroups)).ServeHTTP(w, r)
 		case http.MethodPost:
-			middlewares.AuthMiddleware(db, http.HandlerFunc(groupHandler.CreateGroup)).ServeHTTP(w, r)
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeManageGroups, groupHandler.CreateGroup)).ServeHTTP(w, r)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -61,27 +174,51 @@ func RegisterRoutes(db *sql.DB) {
 		// Handle /api/groups/:id/join endpoint
 		if strings.Contains(path, "/join") {
 			if r.URL.Query().Get("action") == "accept" {
-				middlewares.AuthMiddleware(db, http.HandlerFunc(groupHandler.AcceptJoinRequest)).ServeHTTP(w, r)
+				middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeManageGroups, groupHandler.AcceptJoinRequest)).ServeHTTP(w, r)
 			} else {
-				middlewares.AuthMiddleware(db, http.HandlerFunc(groupHandler.JoinGroupRequest)).ServeHTTP(w, r)
+				middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeManageGroups, groupHandler.JoinGroupRequest)).ServeHTTP(w, r)
 			}
 			return
 		} else if strings.Contains(path, "/posts") {
-			middlewares.AuthMiddleware(db, http.HandlerFunc(handler.GetGroupPosts(db))).ServeHTTP(w, r)
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.GetGroupPosts(db))).ServeHTTP(w, r)
 			return
 		} else {
-			middlewares.AuthMiddleware(db, http.HandlerFunc(handler.GetGroup(db))).ServeHTTP(w, r)
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.GetGroup(db))).ServeHTTP(w, r)
 			return
 		}
 	})
 
-	http.HandleFunc("/api/follow-requests", middlewares.AuthMiddleware(db, handler.GetFollowRequests(db)))
-	http.HandleFunc("/api/profile/update", middlewares.AuthMiddleware(db, handler.UpdateProfileHandler(db)))
-	http.HandleFunc("/api/createpost", middlewares.AuthMiddleware(db, handler.CreatePost(db)))
+	http.HandleFunc("/api/follow-requests", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.GetFollowRequests(db))))
+	http.HandleFunc("/api/profile/update", middlewares.AuthMiddleware(db, middlewares.RequireSession(handler.UpdateProfileHandler(db))))
+	http.HandleFunc("/api/createpost", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, handler.CreatePost(db))))
+	http.HandleFunc("/api/drafts", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, draftHandler.Drafts)))
+	http.HandleFunc("/api/drafts/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, draftHandler.Draft)))
 
-	// Comment routes
-	http.HandleFunc("/api/posts/", middlewares.AuthMiddleware(db, handler.CommentHandler(db)))
-	http.HandleFunc("/api/feeds", middlewares.AuthMiddleware(db, handler.DashboardHandler(db)))
-	http.HandleFunc("/api/reaction", middlewares.AuthMiddleware(db, handler.HandleReaction(db)))
+	// Post routes: /api/posts/:id/comments, /api/posts/:id/edits, /api/posts/:id/repost, /api/posts/:id/vote and /api/posts/:id
+	http.HandleFunc("/api/posts/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/comments"):
+			middlewares.AuthMiddleware(db, middlewares.RequireReadWriteScope(model.ScopeReadFeed, model.ScopePost, handler.CommentHandler(db))).ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, "/edits"):
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, postHandler.Edits)).ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, "/repost"):
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, repostHandler.Repost)).ServeHTTP(w, r)
+		case strings.HasSuffix(r.URL.Path, "/vote"):
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, pollHandler.Vote)).ServeHTTP(w, r)
+		default:
+			middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, postHandler.Post)).ServeHTTP(w, r)
+		}
+	})
+	http.HandleFunc("/api/feeds", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, handler.DashboardHandler(db))))
+	http.HandleFunc("/api/tags/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, tagHandler.Posts)))
+	http.HandleFunc("/api/trending-tags", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, tagHandler.Trending)))
+	http.HandleFunc("/api/mentions/autocomplete", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, mentionHandler.Autocomplete)))
+	http.HandleFunc("/api/notifications", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopeReadFeed, notificationHandler.List)))
+	http.HandleFunc("/api/notifications/read", middlewares.AuthMiddleware(db, middlewares.RequireReadWriteScope(model.ScopeReadFeed, model.ScopePost, notificationHandler.Read)))
+	http.HandleFunc("/api/bookmarks", middlewares.AuthMiddleware(db, middlewares.RequireReadWriteScope(model.ScopeReadFeed, model.ScopePost, bookmarkHandler.Bookmarks)))
+	http.HandleFunc("/api/bookmarks/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, bookmarkHandler.Bookmark)))
+	http.HandleFunc("/api/collections", middlewares.AuthMiddleware(db, middlewares.RequireReadWriteScope(model.ScopeReadFeed, model.ScopePost, bookmarkHandler.Collections)))
+	http.HandleFunc("/api/collections/", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, bookmarkHandler.Collection)))
+	http.HandleFunc("/api/reaction", middlewares.AuthMiddleware(db, middlewares.RequireScope(model.ScopePost, handler.HandleReaction(db))))
 
 }
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"backend/pkg/blobstore"
+)
+
+// Constants controlling account deletion and data exports
+const (
+	AccountDeletionGracePeriod = 30 * 24 * time.Hour // Time a user has to change their mind
+	DataExportTTL              = 7 * 24 * time.Hour  // How long a finished archive can be downloaded
+	DataExportStaleAfter       = 30 * time.Minute    // Running exports older than this are assumed lost, e.g. to a restart
+	AccountMaintenancePeriod   = 15 * time.Minute    // How often exports are built and due accounts purged
+	DefaultExportDir           = "./pkg/db/data/exports"
+)
+
+// Errors returned by AccountService so handlers can map them to status codes
+var (
+	ErrDeletionAlreadyScheduled = errors.New("account deletion is already scheduled")
+	ErrDeletionNotScheduled     = errors.New("no account deletion is scheduled")
+	ErrExportInProgress         = errors.New("an export is already being prepared")
+	ErrExportNotFound           = errors.New("export not found")
+	ErrExportNotReady           = errors.New("export is not ready yet")
+)
+
+// AccountService handles account deletion with a grace period and exports of a user's data.
+//
+// Deleting the users row lets the ON DELETE CASCADE constraints remove everything else in the
+// database; uploaded files and export archives are stored outside it and are removed here.
+type AccountService struct {
+	Repo      *repository.AccountRepository
+	Uploads   *blobstore.Server // Where uploaded images are stored, see utils.Uploads
+	ExportDir string            // Where archives are written, outside anything served publicly
+	Now       func() time.Time  // Clock used for the grace period and export expiry, defaults to time.Now
+	Audit     *AuditService     // Records purged accounts, optional
+}
+
+// NewAccountService creates and returns a new instance of AccountService.
+func NewAccountService(repo *repository.AccountRepository) *AccountService {
+	return &AccountService{Repo: repo, Uploads: utils.Uploads, ExportDir: DefaultExportDir, Now: time.Now}
+}
+
+func (s *AccountService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// ScheduleDeletion marks an account for deletion once the grace period has passed.
+func (s *AccountService) ScheduleDeletion(userID string) (*model.AccountDeletion, error) {
+	existing, err := s.Repo.FindDeletion(userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDeletionAlreadyScheduled
+	}
+
+	now := s.now()
+	deletion := &model.AccountDeletion{
+		UserID:      userID,
+		RequestedAt: now,
+		PurgeAfter:  now.Add(AccountDeletionGracePeriod),
+	}
+	if err := s.Repo.CreateDeletion(deletion); err != nil {
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// Deletion returns the scheduled deletion of an account, or nil if there is none.
+func (s *AccountService) Deletion(userID string) (*model.AccountDeletion, error) {
+	return s.Repo.FindDeletion(userID)
+}
+
+// CancelDeletion keeps an account that was scheduled for deletion.
+func (s *AccountService) CancelDeletion(userID string) error {
+	cancelled, err := s.Repo.DeleteDeletion(userID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrDeletionNotScheduled
+	}
+	return nil
+}
+
+// PurgeDueAccounts deletes the accounts whose grace period has ended, along with their files.
+// It returns how many accounts were deleted.
+func (s *AccountService) PurgeDueAccounts() (int, error) {
+	now := s.now()
+	due, err := s.Repo.FindDueDeletions(now)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for _, deletion := range due {
+		// the file locations are gone with the rows, so collect them first
+		uploads, err := s.Repo.FindUploadPaths(deletion.UserID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		exports, err := s.Repo.FindExportsByUserID(deletion.UserID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		deleted, err := s.Repo.PurgeUser(deletion.UserID, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !deleted {
+			continue
+		}
+		purged++
+		if err := s.Audit.Record(model.AuditEvent{
+			Action:     model.AuditAccountPurged,
+			TargetType: model.AuditTargetUser,
+			TargetID:   deletion.UserID,
+		}, map[string]any{"requested_at": deletion.RequestedAt}, nil); err != nil {
+			errs = append(errs, err)
+		}
+
+		errs = append(errs, discardUploads(s.Repo.DB, s.Uploads, uploads))
+		for _, export := range exports {
+			errs = append(errs, removeFile(export.FilePath))
+		}
+	}
+	return purged, errors.Join(errs...)
+}
+
+// RequestExport queues an export of everything the user has stored.
+func (s *AccountService) RequestExport(userID string) (*model.DataExport, error) {
+	exports, err := s.Repo.FindExportsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.Status == model.DataExportPending || export.Status == model.DataExportRunning {
+			return nil, ErrExportInProgress
+		}
+	}
+
+	export := &model.DataExport{
+		ID:        utils.GenerateUUID(),
+		UserID:    userID,
+		Status:    model.DataExportPending,
+		CreatedAt: s.now(),
+	}
+	if err := s.Repo.CreateExport(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// Exports lists the user's exports, newest first.
+func (s *AccountService) Exports(userID string) ([]model.DataExport, error) {
+	return s.Repo.FindExportsByUserID(userID)
+}
+
+// ReadyExport returns one of the user's exports if its archive can be downloaded.
+func (s *AccountService) ReadyExport(userID, id string) (*model.DataExport, error) {
+	export, err := s.Repo.FindExport(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || (export.ExpiresAt != nil && !s.now().Before(*export.ExpiresAt)) {
+		return nil, ErrExportNotFound
+	}
+	if export.Status != model.DataExportReady {
+		return nil, ErrExportNotReady
+	}
+	return export, nil
+}
+
+// RunExport builds the archive of a pending export. It does nothing if another worker got to it first.
+func (s *AccountService) RunExport(id string) error {
+	claimed, err := s.Repo.ClaimExport(id, s.now())
+	if err != nil || !claimed {
+		return err
+	}
+
+	export, err := s.Repo.FindExportByID(id)
+	if err != nil || export == nil {
+		return err
+	}
+	return s.buildExport(export)
+}
+
+// RunPendingExports builds every queued export and fails the ones that stopped making progress.
+func (s *AccountService) RunPendingExports() error {
+	running, err := s.Repo.FindExportsByStatus(model.DataExportRunning)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	for _, export := range running {
+		if export.StartedAt != nil && now.Sub(*export.StartedAt) >= DataExportStaleAfter {
+			if err := s.Repo.FailExport(export.ID, now, now.Add(DataExportTTL)); err != nil {
+				return err
+			}
+		}
+	}
+
+	pending, err := s.Repo.FindExportsByStatus(model.DataExportPending)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, export := range pending {
+		errs = append(errs, s.RunExport(export.ID))
+	}
+	return errors.Join(errs...)
+}
+
+// PurgeExpiredExports removes the archives whose download window has closed.
+func (s *AccountService) PurgeExpiredExports() (int, error) {
+	expired, err := s.Repo.FindExpiredExports(s.now())
+	if err != nil {
+		return 0, err
+	}
+	for _, export := range expired {
+		if err := removeFile(export.FilePath); err != nil {
+			return 0, err
+		}
+		if err := s.Repo.DeleteExport(export.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// buildExport writes the archive of a claimed export and records the outcome
+func (s *AccountService) buildExport(export *model.DataExport) error {
+	size, filePath, err := s.writeArchiveFile(export)
+	now := s.now()
+	if err != nil {
+		if failErr := s.Repo.FailExport(export.ID, now, now.Add(DataExportTTL)); failErr != nil {
+			return errors.Join(err, failErr)
+		}
+		return fmt.Errorf("export %s failed: %w", export.ID, err)
+	}
+	return s.Repo.CompleteExport(export.ID, filePath, size, now, now.Add(DataExportTTL))
+}
+
+func (s *AccountService) writeArchiveFile(export *model.DataExport) (int64, string, error) {
+	if err := os.MkdirAll(s.ExportDir, 0o700); err != nil {
+		return 0, "", err
+	}
+	filePath := filepath.Join(s.ExportDir, export.ID+".zip")
+	tmpPath := filePath + ".tmp"
+
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, "", err
+	}
+	err = s.WriteArchive(f, export.UserID)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, "", err
+	}
+
+	// rename last so a download never sees a half-written archive
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return 0, "", err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, "", err
+	}
+	return info.Size(), filePath, nil
+}
+
+const exportReadme = `This archive contains the data stored for your account.
+
+profile.json      your profile
+posts.json        the posts you wrote, with the followers chosen for private posts
+comments.json     the comments you wrote
+reactions.json    the likes and dislikes you left on posts
+messages.json     the private messages you sent and received
+followers.json    the people following you and the people you follow, including pending requests
+groups.json       the groups you belong to or asked to join
+uploads/          your profile picture and post images, at the path given in img_url and image_url
+`
+
+// WriteArchive writes a ZIP archive of the user's data to w.
+func (s *AccountService) WriteArchive(w io.Writer, userID string) error {
+	repo := s.Repo
+	profile, err := repo.ExportProfile(userID)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("user %s not found", userID)
+	}
+	posts, err := repo.ExportPosts(userID)
+	if err != nil {
+		return err
+	}
+	comments, err := repo.ExportComments(userID)
+	if err != nil {
+		return err
+	}
+	reactions, err := repo.ExportReactions(userID)
+	if err != nil {
+		return err
+	}
+	messages, err := repo.ExportMessages(userID)
+	if err != nil {
+		return err
+	}
+	followers, err := repo.ExportFollowers(userID)
+	if err != nil {
+		return err
+	}
+	following, err := repo.ExportFollowing(userID)
+	if err != nil {
+		return err
+	}
+	groups, err := repo.ExportGroupMemberships(userID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", profile},
+		{"posts.json", emptyIfNil(posts)},
+		{"comments.json", emptyIfNil(comments)},
+		{"reactions.json", emptyIfNil(reactions)},
+		{"messages.json", emptyIfNil(messages)},
+		{"followers.json", map[string][]model.ExportFollow{
+			"followers": emptyIfNil(followers),
+			"following": emptyIfNil(following),
+		}},
+		{"groups.json", emptyIfNil(groups)},
+	}
+
+	readme, err := zw.Create("README.txt")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(readme, exportReadme); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := writeZipJSON(zw, file.name, file.data); err != nil {
+			return err
+		}
+	}
+
+	uploads := []string{profile.ImgURL}
+	for _, post := range posts {
+		uploads = append(uploads, post.ImageURL)
+	}
+	for _, webPath := range uploads {
+		if err := s.writeZipUpload(zw, webPath); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeZipJSON(zw *zip.Writer, name string, data any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// writeZipUpload copies an uploaded file into the archive under its web path.
+// Files that have gone missing from the store are skipped rather than failing the export.
+func (s *AccountService) writeZipUpload(zw *zip.Writer, webPath string) error {
+	key, ok := s.Uploads.KeyOf(webPath)
+	if !ok {
+		return nil
+	}
+	src, err := s.Uploads.Store.Get(context.Background(), key)
+	if errors.Is(err, blobstore.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.Create(path.Clean(strings.TrimPrefix(webPath, "/")))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// removeFile deletes a file, treating one that is already gone as success
+func removeFile(name string) error {
+	if name == "" {
+		return nil
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func emptyIfNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
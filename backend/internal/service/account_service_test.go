@@ -0,0 +1,249 @@
+package service
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newAccountTestService(t *testing.T) (*AccountService, *sql.DB) {
+	t.Helper()
+	db := dbtest.New(t)
+
+	s := NewAccountService(repository.NewAccountRepository(db))
+	s.Uploads = newTestUploads(t)
+	s.ExportDir = t.TempDir()
+	return s, db
+}
+
+// seedAccountData gives user-1 something in every table the export covers, plus an image on disk
+func seedAccountData(t *testing.T, s *AccountService, db *sql.DB) {
+	t.Helper()
+	insertTestUser(t, db, "user-1", "jane@example.com")
+	insertTestUser(t, db, "user-2", "sam@example.com")
+
+	statements := []string{
+		`UPDATE users SET imgurl = '/uploads/posts/avatar.png' WHERE id = 'user-1'`,
+		`INSERT INTO posts (id, user_id, title, content, visibility, post_image, created_at) VALUES ('post-1', 'user-1', 'Hello', 'First post', 'private', '/uploads/posts/photo.png', CURRENT_TIMESTAMP)`,
+		`INSERT INTO posts (id, user_id, title, content, visibility, post_image, created_at) VALUES ('post-2', 'user-2', 'Hi', 'Other post', 'public', '/uploads/posts/other.png', CURRENT_TIMESTAMP)`,
+		`INSERT INTO private_posts (post_id, user_id) VALUES ('post-1', 'user-2')`,
+		`INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at) VALUES ('comment-1', 'post-2', 'user-1', 'Nice', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		`INSERT INTO reactions (post_id, user_id, type) VALUES ('post-2', 'user-1', 'like')`,
+		`INSERT INTO messages (id, sender_id, receiver_id, content) VALUES ('message-1', 'user-1', 'user-2', 'Hey'), ('message-2', 'user-2', 'user-1', 'Hello')`,
+		`INSERT INTO followers (follower_id, followed_id, status) VALUES ('user-2', 'user-1', 'accepted'), ('user-1', 'user-2', 'requested')`,
+		`INSERT INTO groups (id, title, description, creator_id) VALUES (1, 'Hikers', '', 'user-2')`,
+		`INSERT INTO group_members (group_id, user_id, role, status) VALUES (1, 'user-1', 'member', 'active')`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("failed to seed %q: %v", statement, err)
+		}
+	}
+
+	for _, name := range []string{"avatar.png", "photo.png", "other.png"} {
+		file := uploadFile(s.Uploads, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("image "+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatalf("failed to count %q: %v", query, err)
+	}
+	return n
+}
+
+func TestAccountDeletionGracePeriodAndCancellation(t *testing.T) {
+	s, db := newAccountTestService(t)
+	seedAccountData(t, s, db)
+	clock := time.Now()
+	s.Now = func() time.Time { return clock }
+
+	if _, err := s.ScheduleDeletion("user-1"); err != nil {
+		t.Fatalf("ScheduleDeletion() failed: %v", err)
+	}
+	if _, err := s.ScheduleDeletion("user-1"); err != ErrDeletionAlreadyScheduled {
+		t.Errorf("expected ErrDeletionAlreadyScheduled, got %v", err)
+	}
+
+	clock = clock.Add(AccountDeletionGracePeriod - time.Minute)
+	if purged, err := s.PurgeDueAccounts(); err != nil || purged != 0 {
+		t.Fatalf("expected nothing to be purged during the grace period, got %d, %v", purged, err)
+	}
+
+	if err := s.CancelDeletion("user-1"); err != nil {
+		t.Fatalf("CancelDeletion() failed: %v", err)
+	}
+	if err := s.CancelDeletion("user-1"); err != ErrDeletionNotScheduled {
+		t.Errorf("expected ErrDeletionNotScheduled, got %v", err)
+	}
+	clock = clock.Add(time.Hour)
+	if purged, _ := s.PurgeDueAccounts(); purged != 0 {
+		t.Fatalf("expected a cancelled deletion not to purge the account")
+	}
+	if deletion, _ := s.Deletion("user-1"); deletion != nil {
+		t.Errorf("expected no scheduled deletion after cancelling, got %+v", deletion)
+	}
+}
+
+func TestPurgeDueAccountsCascadesAndRemovesUploads(t *testing.T) {
+	s, db := newAccountTestService(t)
+	seedAccountData(t, s, db)
+	clock := time.Now()
+	s.Now = func() time.Time { return clock }
+
+	if _, err := s.ScheduleDeletion("user-1"); err != nil {
+		t.Fatalf("ScheduleDeletion() failed: %v", err)
+	}
+	clock = clock.Add(AccountDeletionGracePeriod)
+	purged, err := s.PurgeDueAccounts()
+	if err != nil || purged != 1 {
+		t.Fatalf("expected one account to be purged, got %d, %v", purged, err)
+	}
+
+	checks := map[string]string{
+		"user":          `SELECT COUNT(*) FROM users WHERE id = 'user-1'`,
+		"posts":         `SELECT COUNT(*) FROM posts WHERE user_id = 'user-1'`,
+		"private posts": `SELECT COUNT(*) FROM private_posts`,
+		"comments":      `SELECT COUNT(*) FROM comments WHERE user_id = 'user-1'`,
+		"reactions":     `SELECT COUNT(*) FROM reactions WHERE user_id = 'user-1'`,
+		"messages":      `SELECT COUNT(*) FROM messages`,
+		"followers":     `SELECT COUNT(*) FROM followers`,
+		"memberships":   `SELECT COUNT(*) FROM group_members WHERE user_id = 'user-1'`,
+		"deletion":      `SELECT COUNT(*) FROM account_deletions`,
+	}
+	for name, query := range checks {
+		if n := countRows(t, db, query); n != 0 {
+			t.Errorf("expected %s to be deleted, %d left", name, n)
+		}
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM posts WHERE user_id = 'user-2'`); n != 1 {
+		t.Errorf("expected other users' posts to survive, got %d", n)
+	}
+
+	for _, name := range []string{"avatar.png", "photo.png"} {
+		if _, err := os.Stat(uploadFile(s.Uploads, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed from disk, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(uploadFile(s.Uploads, "other.png")); err != nil {
+		t.Errorf("expected other users' uploads to be kept, got %v", err)
+	}
+}
+
+func TestDataExportArchive(t *testing.T) {
+	s, db := newAccountTestService(t)
+	seedAccountData(t, s, db)
+	clock := time.Now()
+	s.Now = func() time.Time { return clock }
+
+	export, err := s.RequestExport("user-1")
+	if err != nil {
+		t.Fatalf("RequestExport() failed: %v", err)
+	}
+	if _, err := s.RequestExport("user-1"); err != ErrExportInProgress {
+		t.Errorf("expected ErrExportInProgress, got %v", err)
+	}
+	if _, err := s.ReadyExport("user-1", export.ID); err != ErrExportNotReady {
+		t.Errorf("expected ErrExportNotReady before the job ran, got %v", err)
+	}
+
+	if err := s.RunPendingExports(); err != nil {
+		t.Fatalf("RunPendingExports() failed: %v", err)
+	}
+	if _, err := s.ReadyExport("user-2", export.ID); err != ErrExportNotFound {
+		t.Errorf("expected another user's export to be hidden, got %v", err)
+	}
+	ready, err := s.ReadyExport("user-1", export.ID)
+	if err != nil {
+		t.Fatalf("ReadyExport() failed: %v", err)
+	}
+
+	archive, err := zip.OpenReader(ready.FilePath)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer archive.Close()
+	files := map[string]string{}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"README.txt", "profile.json", "posts.json", "comments.json", "reactions.json", "messages.json", "followers.json", "groups.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("expected %s in the archive", name)
+		}
+	}
+	if files["uploads/posts/photo.png"] != "image photo.png" || files["uploads/posts/avatar.png"] != "image avatar.png" {
+		t.Errorf("expected the user's uploads in the archive, got %v", keys(files))
+	}
+	if _, ok := files["uploads/posts/other.png"]; ok {
+		t.Errorf("expected other users' uploads to be left out")
+	}
+	if strings.Contains(files["profile.json"], "hash") {
+		t.Errorf("expected the password hash to be left out of the profile")
+	}
+
+	var posts []model.ExportPost
+	json.Unmarshal([]byte(files["posts.json"]), &posts)
+	if len(posts) != 1 || posts[0].ID != "post-1" || len(posts[0].AllowedFollowers) != 1 {
+		t.Errorf("expected post-1 with its allowed follower, got %+v", posts)
+	}
+	var messages []model.ExportMessage
+	json.Unmarshal([]byte(files["messages.json"]), &messages)
+	if len(messages) != 2 {
+		t.Errorf("expected sent and received messages, got %+v", messages)
+	}
+	var follows map[string][]model.ExportFollow
+	json.Unmarshal([]byte(files["followers.json"]), &follows)
+	if len(follows["followers"]) != 1 || len(follows["following"]) != 1 {
+		t.Errorf("expected one follower and one followed user, got %+v", follows)
+	}
+	var groups []model.ExportGroupMembership
+	json.Unmarshal([]byte(files["groups.json"]), &groups)
+	if len(groups) != 1 || groups[0].Title != "Hikers" || groups[0].Creator {
+		t.Errorf("expected the Hikers membership, got %+v", groups)
+	}
+
+	clock = clock.Add(DataExportTTL)
+	if _, err := s.ReadyExport("user-1", export.ID); err != ErrExportNotFound {
+		t.Errorf("expected an expired export to be gone, got %v", err)
+	}
+	if removed, err := s.PurgeExpiredExports(); err != nil || removed != 1 {
+		t.Fatalf("expected one expired export to be removed, got %d, %v", removed, err)
+	}
+	if _, err := os.Stat(ready.FilePath); !os.IsNotExist(err) {
+		t.Errorf("expected the archive to be removed from disk, got %v", err)
+	}
+}
+
+func keys(m map[string]string) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
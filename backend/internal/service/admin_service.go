@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"backend/pkg/blobstore"
+)
+
+// Constants controlling the administration API
+const (
+	DefaultAdminPageSize      = 50
+	MaxAdminPageSize          = 200
+	MaxSuspensionReasonLength = 500
+)
+
+// Errors returned by AdminService so handlers can map them to status codes
+var (
+	ErrAdminUserNotFound = errors.New("user not found")
+	ErrInvalidRole       = errors.New("role must be one of user, moderator or admin")
+	ErrInsufficientRole  = errors.New("this action requires a higher role")
+	ErrCannotManageUser  = errors.New("you can only manage users with a lower role than yours")
+	ErrInvalidSuspension = errors.New("a reason of at most 500 characters is required and the duration must not be negative")
+	ErrPostNotFound      = errors.New("post not found")
+	ErrCommentNotFound   = errors.New("comment not found")
+)
+
+// AdminService implements site administration: managing roles, suspending users and removing content.
+// Moderators and admins can only act on users ranked below them, so nobody can lock out a peer.
+type AdminService struct {
+	Repo    *repository.AdminRepository
+	Uploads *blobstore.Server // Where post images are stored, see utils.Uploads
+	Now     func() time.Time  // Clock used for suspensions, defaults to time.Now
+}
+
+// NewAdminService creates and returns a new instance of AdminService.
+func NewAdminService(repo *repository.AdminRepository) *AdminService {
+	return &AdminService{Repo: repo, Uploads: utils.Uploads, Now: time.Now}
+}
+
+func (s *AdminService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// ListUsers returns the users matching filter. The page size is clamped to MaxAdminPageSize.
+func (s *AdminService) ListUsers(filter repository.UserFilter) ([]model.AdminUser, error) {
+	if filter.Role != "" && model.RoleRank(filter.Role) < 0 {
+		return nil, ErrInvalidRole
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAdminPageSize
+	}
+	if filter.Limit > MaxAdminPageSize {
+		filter.Limit = MaxAdminPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.Now = s.now()
+	return s.Repo.ListUsers(filter)
+}
+
+// User returns one user.
+func (s *AdminService) User(userID string) (*model.AdminUser, error) {
+	user, err := s.Repo.FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrAdminUserNotFound
+	}
+	return user, nil
+}
+
+// manageable loads the target of an action and checks the actor outranks them
+func (s *AdminService) manageable(actor *model.User, targetID string) (*model.AdminUser, error) {
+	target, err := s.User(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == actor.ID || model.RoleRank(actor.Role) <= model.RoleRank(target.Role) {
+		return nil, ErrCannotManageUser
+	}
+	return target, nil
+}
+
+// SetRole changes a user's site-wide role. Only admins may do this.
+func (s *AdminService) SetRole(actor *model.User, targetID, role string) (*model.AdminUser, error) {
+	if !model.HasRole(actor.Role, model.RoleAdmin) {
+		return nil, ErrInsufficientRole
+	}
+	if model.RoleRank(role) < 0 {
+		return nil, ErrInvalidRole
+	}
+	if _, err := s.manageable(actor, targetID); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.SetRole(targetID, role); err != nil {
+		return nil, err
+	}
+	return s.User(targetID)
+}
+
+// EnsureAdmins makes the users with the given emails admins, so a fresh install has someone to
+// hand out roles. Emails without an account are ignored.
+func (s *AdminService) EnsureAdmins(emails []string) (int64, error) {
+	return s.Repo.PromoteByEmail(emails, model.RoleAdmin)
+}
+
+// Suspend locks a user out for duration, or until reinstated if duration is zero,
+// and ends all of their sessions.
+func (s *AdminService) Suspend(actor *model.User, targetID, reason string, duration time.Duration) (*model.AdminUser, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > MaxSuspensionReasonLength || duration < 0 {
+		return nil, ErrInvalidSuspension
+	}
+	if _, err := s.manageable(actor, targetID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	suspension := &model.UserSuspension{SuspendedAt: now, Reason: reason, SuspendedBy: actor.ID}
+	if duration > 0 {
+		until := now.Add(duration)
+		suspension.Until = &until
+	}
+	if err := s.Repo.Suspend(targetID, suspension); err != nil {
+		return nil, err
+	}
+	if _, err := s.Repo.DeleteSessions(targetID); err != nil {
+		return nil, err
+	}
+	return s.User(targetID)
+}
+
+// Reinstate lifts a user's suspension.
+func (s *AdminService) Reinstate(actor *model.User, targetID string) (*model.AdminUser, error) {
+	if _, err := s.manageable(actor, targetID); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Reinstate(targetID); err != nil {
+		return nil, err
+	}
+	return s.User(targetID)
+}
+
+// ForceLogout ends every session of a user and returns how many there were.
+func (s *AdminService) ForceLogout(actor *model.User, targetID string) (int64, error) {
+	if _, err := s.manageable(actor, targetID); err != nil {
+		return 0, err
+	}
+	return s.Repo.DeleteSessions(targetID)
+}
+
+// DeletePost removes a post with its comments, reactions and images.
+func (s *AdminService) DeletePost(postID string) error {
+	images, found, err := s.Repo.DeletePost(postID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPostNotFound
+	}
+	return discardUploads(s.Repo.DB, s.Uploads, images)
+}
+
+// DeleteComment removes a comment with its replies.
+func (s *AdminService) DeleteComment(commentID string) error {
+	found, err := s.Repo.DeleteComment(commentID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrCommentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newAdminTestService(t *testing.T) *AdminService {
+	t.Helper()
+	db := dbtest.New(t)
+	users := []struct{ id, email, role string }{
+		{"admin-1", "admin@example.com", model.RoleAdmin},
+		{"mod-1", "mod@example.com", model.RoleModerator},
+		{"mod-2", "mod2@example.com", model.RoleModerator},
+		{"user-1", "jane@example.com", model.RoleUser},
+	}
+	for _, u := range users {
+		insertTestUser(t, db, u.id, u.email)
+		mustExec(t, db, `UPDATE users SET role = ? WHERE id = ?`, u.role, u.id)
+		mustExec(t, db, `INSERT INTO sessions (id, user_id, expires_at) VALUES (?, ?, ?)`, "session-"+u.id, u.id, time.Now().Add(time.Hour))
+	}
+
+	s := NewAdminService(repository.NewAdminRepository(db))
+	s.Uploads = newTestUploads(t)
+	return s
+}
+
+func TestAdminSuspendRequiresHigherRole(t *testing.T) {
+	s := newAdminTestService(t)
+	moderator := &model.User{ID: "mod-1", Role: model.RoleModerator}
+
+	if _, err := s.Suspend(moderator, "mod-2", "Spamming", 0); err != ErrCannotManageUser {
+		t.Errorf("expected a moderator not to suspend another moderator, got %v", err)
+	}
+	if _, err := s.Suspend(moderator, "mod-1", "Spamming", 0); err != ErrCannotManageUser {
+		t.Errorf("expected a moderator not to suspend themselves, got %v", err)
+	}
+	if _, err := s.Suspend(moderator, "user-1", " ", 0); err != ErrInvalidSuspension {
+		t.Errorf("expected a reason to be required, got %v", err)
+	}
+
+	user, err := s.Suspend(moderator, "user-1", "Spamming", 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Suspend() failed: %v", err)
+	}
+	if user.Suspension == nil || user.Suspension.Until == nil || user.Suspension.SuspendedBy != "mod-1" {
+		t.Fatalf("expected a seven day suspension by mod-1, got %+v", user.Suspension)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM sessions WHERE user_id = 'user-1'`); n != 0 {
+		t.Errorf("expected the suspended user's sessions to be revoked, %d left", n)
+	}
+
+	suspended := true
+	users, err := s.ListUsers(repository.UserFilter{Suspended: &suspended})
+	if err != nil || len(users) != 1 || users[0].ID != "user-1" {
+		t.Errorf("expected only user-1 to be listed as suspended, got %+v, %v", users, err)
+	}
+
+	user, err = s.Reinstate(moderator, "user-1")
+	if err != nil || user.Suspension != nil {
+		t.Errorf("expected the suspension to be lifted, got %+v, %v", user, err)
+	}
+}
+
+func TestAdminSetRoleIsAdminOnly(t *testing.T) {
+	s := newAdminTestService(t)
+	admin := &model.User{ID: "admin-1", Role: model.RoleAdmin}
+	moderator := &model.User{ID: "mod-1", Role: model.RoleModerator}
+
+	if _, err := s.SetRole(moderator, "user-1", model.RoleModerator); err != ErrInsufficientRole {
+		t.Errorf("expected moderators not to change roles, got %v", err)
+	}
+	if _, err := s.SetRole(admin, "user-1", "owner"); err != ErrInvalidRole {
+		t.Errorf("expected an unknown role to be rejected, got %v", err)
+	}
+	if _, err := s.SetRole(admin, "admin-1", model.RoleUser); err != ErrCannotManageUser {
+		t.Errorf("expected an admin not to demote themselves, got %v", err)
+	}
+
+	user, err := s.SetRole(admin, "user-1", model.RoleModerator)
+	if err != nil || user.Role != model.RoleModerator {
+		t.Fatalf("expected user-1 to become a moderator, got %+v, %v", user, err)
+	}
+
+	users, err := s.ListUsers(repository.UserFilter{Query: "JANE@", Role: model.RoleModerator})
+	if err != nil || len(users) != 1 || users[0].ID != "user-1" {
+		t.Errorf("expected the search to find user-1, got %+v, %v", users, err)
+	}
+	if users, _ := s.ListUsers(repository.UserFilter{Query: "%"}); len(users) != 0 {
+		t.Errorf("expected LIKE wildcards in the query to match literally, got %+v", users)
+	}
+
+	if promoted, err := s.EnsureAdmins([]string{"Mod@Example.com", "nobody@example.com"}); err != nil || promoted != 1 {
+		t.Errorf("expected one user to be promoted, got %d, %v", promoted, err)
+	}
+}
+
+func TestAdminDeletePostRemovesImage(t *testing.T) {
+	s := newAdminTestService(t)
+	image := uploadFile(s.Uploads, "photo.png")
+	os.MkdirAll(filepath.Dir(image), 0o755)
+	os.WriteFile(image, []byte("image"), 0o644)
+	mustExec(t, s.Repo.DB, `INSERT INTO posts (id, user_id, title, content, visibility, post_image) VALUES ('post-1', 'user-1', 'Hi', 'Spam', 'public', '/uploads/posts/photo.png')`)
+	mustExec(t, s.Repo.DB, `INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at) VALUES ('comment-1', 'post-1', 'mod-1', 'Hm', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`)
+
+	if err := s.DeletePost("post-1"); err != nil {
+		t.Fatalf("DeletePost() failed: %v", err)
+	}
+	if _, err := os.Stat(image); !os.IsNotExist(err) {
+		t.Errorf("expected the post image to be removed, got %v", err)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM comments`); n != 0 {
+		t.Errorf("expected the post's comments to be deleted with it, %d left", n)
+	}
+	if err := s.DeletePost("post-1"); err != ErrPostNotFound {
+		t.Errorf("expected ErrPostNotFound, got %v", err)
+	}
+	if err := s.DeleteComment("comment-1"); err != ErrCommentNotFound {
+		t.Errorf("expected ErrCommentNotFound, got %v", err)
+	}
+}
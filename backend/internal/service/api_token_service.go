@@ -0,0 +1,169 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+)
+
+// Constants controlling personal access tokens
+const (
+	APITokenPrefix        = "snpat_"    // Marks a string as one of our tokens, e.g. for secret scanners
+	MaxAPITokensPerUser   = 20          // Tokens a user may hold at once
+	MaxAPITokenNameLength = 100         // Matches the api_tokens.name column
+	APITokenTouchInterval = time.Minute // Minimum time between last-used updates
+	apiTokenSecretSize    = 32
+	apiTokenDisplayLength = len(APITokenPrefix) + 6
+)
+
+// Errors returned by APITokenService so handlers can map them to status codes
+var (
+	ErrInvalidAPITokenName  = errors.New("token name is required and must be at most 100 characters")
+	ErrInvalidAPITokenScope = errors.New("at least one valid scope is required")
+	ErrInvalidAPITokenTTL   = errors.New("expiry must not be negative")
+	ErrTooManyAPITokens     = errors.New("token limit reached, revoke an unused token first")
+	ErrAPITokenNotFound     = errors.New("token not found")
+	ErrInvalidAPIToken      = errors.New("invalid or expired token")
+)
+
+// APITokenService manages personal access tokens and authenticates requests made with them.
+// Only the SHA-256 of a token is stored, so a token is shown to its owner exactly once.
+type APITokenService struct {
+	Repo *repository.APITokenRepository
+	Now  func() time.Time // Clock used for expiry and last-used tracking, defaults to time.Now
+}
+
+// NewAPITokenService creates and returns a new instance of APITokenService.
+func NewAPITokenService(repo *repository.APITokenRepository) *APITokenService {
+	return &APITokenService{Repo: repo, Now: time.Now}
+}
+
+func (s *APITokenService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Create issues a new token for a user and returns the plaintext token with its stored record.
+// A zero ttl creates a token that never expires.
+func (s *APITokenService) Create(userID, name string, scopes []string, ttl time.Duration) (string, *model.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > MaxAPITokenNameLength {
+		return "", nil, ErrInvalidAPITokenName
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	if ttl < 0 {
+		return "", nil, ErrInvalidAPITokenTTL
+	}
+
+	existing, err := s.Repo.FindByUserID(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(existing) >= MaxAPITokensPerUser {
+		return "", nil, ErrTooManyAPITokens
+	}
+
+	buf := make([]byte, apiTokenSecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	raw := APITokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	now := s.now()
+	token := &model.APIToken{
+		ID:        utils.GenerateUUID(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(raw),
+		Prefix:    raw[:apiTokenDisplayLength],
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.Repo.Create(token); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// List returns the tokens of a user. The plaintext tokens cannot be recovered.
+func (s *APITokenService) List(userID string) ([]model.APIToken, error) {
+	return s.Repo.FindByUserID(userID)
+}
+
+// Revoke deletes one of the user's tokens. Requests using it are rejected immediately.
+func (s *APITokenService) Revoke(userID, tokenID string) error {
+	deleted, err := s.Repo.Delete(userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a bearer token to its stored record and records its use.
+func (s *APITokenService) Authenticate(raw string) (*model.APIToken, error) {
+	if !strings.HasPrefix(raw, APITokenPrefix) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	token, err := s.Repo.FindByHash(hashToken(raw))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrInvalidAPIToken
+	}
+
+	now := s.now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	// throttled so that a busy script does not write on every request
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= APITokenTouchInterval {
+		if err := s.Repo.UpdateLastUsed(token.ID, now); err != nil {
+			return nil, err
+		}
+		token.LastUsedAt = &now
+	}
+	return token, nil
+}
+
+// normalizeScopes validates requested scopes and returns them deduplicated in canonical order.
+func normalizeScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, ErrInvalidAPITokenScope
+	}
+	for _, scope := range requested {
+		if !slices.Contains(model.APITokenScopes, scope) {
+			return nil, ErrInvalidAPITokenScope
+		}
+	}
+
+	var scopes []string
+	for _, scope := range model.APITokenScopes {
+		if slices.Contains(requested, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func TestAPITokenLifecycle(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	db := dbtest.New(t)
+	insertTestUser(t, db, "user-1", "jane@example.com")
+	insertTestUser(t, db, "user-2", "john@example.com")
+
+	s := NewAPITokenService(repository.NewAPITokenRepository(db))
+	s.Now = func() time.Time { return clock }
+
+	raw, token, err := s.Create("user-1", " deploy bot ", []string{model.ScopePost, model.ScopeReadFeed, model.ScopePost}, 0)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if !strings.HasPrefix(raw, APITokenPrefix) || !strings.HasPrefix(raw, token.Prefix) {
+		t.Errorf("unexpected token %q with prefix %q", raw, token.Prefix)
+	}
+	if token.Name != "deploy bot" {
+		t.Errorf("expected trimmed name, got %q", token.Name)
+	}
+	if strings.Join(token.Scopes, " ") != "feed:read posts:write" {
+		t.Errorf("expected deduplicated scopes in canonical order, got %v", token.Scopes)
+	}
+
+	var stored string
+	db.QueryRow(`SELECT token_hash FROM api_tokens WHERE id = ?`, token.ID).Scan(&stored)
+	if stored == raw || stored != hashToken(raw) {
+		t.Errorf("expected only the token hash to be stored, got %q", stored)
+	}
+
+	authenticated, err := s.Authenticate(raw)
+	if err != nil {
+		t.Fatalf("Authenticate() failed: %v", err)
+	}
+	if authenticated.UserID != "user-1" || authenticated.LastUsedAt == nil || !authenticated.LastUsedAt.Equal(clock) {
+		t.Errorf("unexpected authenticated token: %+v", authenticated)
+	}
+
+	if _, err := s.Authenticate(raw + "x"); err != ErrInvalidAPIToken {
+		t.Errorf("expected ErrInvalidAPIToken for an unknown token, got %v", err)
+	}
+	if err := s.Revoke("user-2", token.ID); err != ErrAPITokenNotFound {
+		t.Errorf("expected another user's revoke to fail with ErrAPITokenNotFound, got %v", err)
+	}
+	if err := s.Revoke("user-1", token.ID); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if _, err := s.Authenticate(raw); err != ErrInvalidAPIToken {
+		t.Errorf("expected revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestAPITokenExpiry(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	db := dbtest.New(t)
+	insertTestUser(t, db, "user-1", "jane@example.com")
+
+	s := NewAPITokenService(repository.NewAPITokenRepository(db))
+	s.Now = func() time.Time { return clock }
+
+	raw, _, err := s.Create("user-1", "nightly", []string{model.ScopeReadFeed}, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	clock = clock.Add(23 * time.Hour)
+	if _, err := s.Authenticate(raw); err != nil {
+		t.Errorf("expected token to be valid before expiry, got %v", err)
+	}
+	clock = clock.Add(time.Hour)
+	if _, err := s.Authenticate(raw); err != ErrInvalidAPIToken {
+		t.Errorf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestCreateAPITokenValidation(t *testing.T) {
+	db := dbtest.New(t)
+	insertTestUser(t, db, "user-1", "jane@example.com")
+	s := NewAPITokenService(repository.NewAPITokenRepository(db))
+
+	tests := []struct {
+		name      string
+		tokenName string
+		scopes    []string
+		ttl       time.Duration
+		want      error
+	}{
+		{"missing name", "  ", []string{model.ScopeReadFeed}, 0, ErrInvalidAPITokenName},
+		{"name too long", strings.Repeat("a", MaxAPITokenNameLength+1), []string{model.ScopeReadFeed}, 0, ErrInvalidAPITokenName},
+		{"no scopes", "bot", nil, 0, ErrInvalidAPITokenScope},
+		{"unknown scope", "bot", []string{model.ScopeReadFeed, "admin"}, 0, ErrInvalidAPITokenScope},
+		{"negative ttl", "bot", []string{model.ScopeReadFeed}, -time.Hour, ErrInvalidAPITokenTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := s.Create("user-1", tt.tokenName, tt.scopes, tt.ttl); err != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	for i := 0; i < MaxAPITokensPerUser; i++ {
+		if _, _, err := s.Create("user-1", "bot", []string{model.ScopeReadFeed}, 0); err != nil {
+			t.Fatalf("Create() failed: %v", err)
+		}
+	}
+	if _, _, err := s.Create("user-1", "bot", []string{model.ScopeReadFeed}, 0); err != ErrTooManyAPITokens {
+		t.Errorf("expected ErrTooManyAPITokens, got %v", err)
+	}
+}
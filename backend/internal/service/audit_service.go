@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// Constants controlling audit log queries
+const (
+	DefaultAuditPageSize = 100
+	MaxAuditPageSize     = 500
+	auditExportBatchSize = 1000
+)
+
+// AuditService records security and moderation events and lets admins search them.
+// A nil *AuditService records nothing, so services can leave it unset.
+type AuditService struct {
+	Repo *repository.AuditRepository
+	Now  func() time.Time // Clock used for event timestamps, defaults to time.Now
+}
+
+// NewAuditService creates and returns a new instance of AuditService.
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{Repo: repo, Now: time.Now}
+}
+
+func (s *AuditService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Record appends event to the log with the fields that differ between before and after as its diff.
+// Either map may be nil, for things that were created or removed.
+func (s *AuditService) Record(event model.AuditEvent, before, after map[string]any) error {
+	if s == nil {
+		return nil
+	}
+	diff, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	event.Diff = diff
+	event.CreatedAt = s.now()
+	return s.Repo.Insert(&event)
+}
+
+// auditDiff returns a JSON object holding a from/to pair for each field whose value changed
+func auditDiff(before, after map[string]any) (json.RawMessage, error) {
+	changes := map[string]model.AuditChange{}
+	for field, from := range before {
+		changes[field] = model.AuditChange{From: from, To: after[field]}
+	}
+	for field, to := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = model.AuditChange{From: nil, To: to}
+		}
+	}
+	for field, change := range changes {
+		// compare the encoded values so a nil pointer equals nil and times compare by instant
+		from, err := json.Marshal(change.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := json.Marshal(change.To)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(from, to) {
+			delete(changes, field)
+		}
+	}
+	return json.Marshal(changes)
+}
+
+// List returns a page of events matching filter, newest first.
+func (s *AuditService) List(filter repository.AuditFilter) ([]model.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditPageSize
+	}
+	if filter.Limit > MaxAuditPageSize {
+		filter.Limit = MaxAuditPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.Repo.List(filter)
+}
+
+// WriteCSV writes every event matching filter to w as CSV, newest first, and returns how many were written.
+// Limit and Offset in filter are ignored.
+func (s *AuditService) WriteCSV(w io.Writer, filter repository.AuditFilter) (int, error) {
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "created_at", "action", "actor_id", "target_type", "target_id", "ip_address", "user_agent", "diff"})
+
+	written := 0
+	filter.Limit, filter.Offset = auditExportBatchSize, 0
+	for {
+		// page by ID so events recorded during the export do not shift the batches
+		events, err := s.Repo.List(filter)
+		if err != nil {
+			return written, err
+		}
+		for _, event := range events {
+			out.Write([]string{
+				strconv.FormatInt(event.ID, 10),
+				event.CreatedAt.UTC().Format(time.RFC3339),
+				event.Action,
+				event.ActorID,
+				event.TargetType,
+				event.TargetID,
+				event.IPAddress,
+				event.UserAgent,
+				string(event.Diff),
+			})
+		}
+		written += len(events)
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return written, err
+		}
+		if len(events) < auditExportBatchSize {
+			return written, nil
+		}
+		filter.BeforeID = events[len(events)-1].ID
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newAuditTestService(t *testing.T, clock *time.Time) *AuditService {
+	t.Helper()
+	db := dbtest.New(t)
+	s := NewAuditService(repository.NewAuditRepository(db))
+	s.Now = func() time.Time { return *clock }
+	return s
+}
+
+func TestAuditRecordStoresOnlyChangedFields(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := newAuditTestService(t, &clock)
+
+	event := model.AuditEvent{
+		Action:     model.AuditRoleChanged,
+		ActorID:    "admin-1",
+		TargetType: model.AuditTargetUser,
+		TargetID:   "user-1",
+		IPAddress:  "203.0.113.7",
+	}
+	before := map[string]any{"role": model.RoleUser, "suspension": (*model.UserSuspension)(nil)}
+	after := map[string]any{"role": model.RoleModerator, "suspension": nil, "note": "promoted"}
+	if err := s.Record(event, before, after); err != nil {
+		t.Fatalf("Record() failed: %v", err)
+	}
+
+	events, err := s.List(repository.AuditFilter{ActorID: "admin-1"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one event, got %+v, %v", events, err)
+	}
+	var diff map[string]model.AuditChange
+	if err := json.Unmarshal(events[0].Diff, &diff); err != nil {
+		t.Fatalf("diff is not JSON: %s", events[0].Diff)
+	}
+	if len(diff) != 2 || diff["role"].From != model.RoleUser || diff["role"].To != model.RoleModerator || diff["note"].From != nil {
+		t.Errorf("expected role and note to be the only changes, got %s", events[0].Diff)
+	}
+	if events[0].IPAddress != "203.0.113.7" || !events[0].CreatedAt.Equal(clock) {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+
+	if err := (*AuditService)(nil).Record(event, nil, nil); err != nil {
+		t.Errorf("expected a nil service to record nothing, got %v", err)
+	}
+}
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	clock := time.Now()
+	s := newAuditTestService(t, &clock)
+	s.Record(model.AuditEvent{Action: model.AuditLogin, ActorID: "user-1"}, nil, map[string]any{"method": "password"})
+
+	if _, err := s.Repo.DB.Exec(`UPDATE audit_events SET actor_id = 'someone-else'`); err == nil {
+		t.Error("expected audit events not to be updated")
+	}
+	if _, err := s.Repo.DB.Exec(`DELETE FROM audit_events`); err == nil {
+		t.Error("expected audit events not to be deleted")
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM audit_events WHERE actor_id = 'user-1'`); n != 1 {
+		t.Errorf("expected the event to be untouched, found %d", n)
+	}
+}
+
+func TestAuditFiltersAndCSVExport(t *testing.T) {
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := newAuditTestService(t, &clock)
+
+	// more than one export batch of logins, then one suspension a day later
+	for i := 0; i < auditExportBatchSize+5; i++ {
+		s.Record(model.AuditEvent{Action: model.AuditLogin, ActorID: "user-1"}, nil, nil)
+	}
+	clock = clock.Add(24 * time.Hour)
+	s.Record(model.AuditEvent{Action: model.AuditUserSuspended, ActorID: "mod-1", TargetType: model.AuditTargetUser, TargetID: "user-1"},
+		nil, map[string]any{"reason": "Spam, mostly"})
+
+	since := clock.Add(-time.Hour)
+	events, err := s.List(repository.AuditFilter{Since: &since})
+	if err != nil || len(events) != 1 || events[0].Action != model.AuditUserSuspended {
+		t.Errorf("expected only the suspension after since, got %d events, %v", len(events), err)
+	}
+	if events, _ := s.List(repository.AuditFilter{Action: model.AuditLogin}); len(events) != DefaultAuditPageSize {
+		t.Errorf("expected a default page of logins, got %d", len(events))
+	}
+
+	var out bytes.Buffer
+	written, err := s.WriteCSV(&out, repository.AuditFilter{})
+	if err != nil || written != auditExportBatchSize+6 {
+		t.Fatalf("expected every event to be exported, got %d, %v", written, err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil || len(records) != written+1 {
+		t.Fatalf("expected a header and one row per event, got %d rows, %v", len(records), err)
+	}
+	if newest := records[1]; newest[2] != model.AuditUserSuspended || newest[8] != `{"reason":{"from":null,"to":"Spam, mostly"}}` {
+		t.Errorf("expected the suspension first with its diff, got %q", newest)
+	}
+}
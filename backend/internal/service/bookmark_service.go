@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+)
+
+// Errors returned by BookmarkService so handlers can map them to status codes
+var (
+	ErrBookmarkNotFound      = errors.New("post not saved")
+	ErrCollectionNotFound    = errors.New("collection not found")
+	ErrInvalidCollectionName = errors.New("collection names must have between 1 and 50 characters")
+	ErrCollectionNameTaken   = errors.New("you already have a collection with this name")
+)
+
+// BookmarkService saves posts for later and groups them in collections
+type BookmarkService struct {
+	Repo *repository.BookmarkRepository
+	Now  func() time.Time // Clock used for created_at, defaults to time.Now
+}
+
+// NewBookmarkService creates and returns a new instance of BookmarkService.
+func NewBookmarkService(repo *repository.BookmarkRepository) *BookmarkService {
+	return &BookmarkService{Repo: repo, Now: time.Now}
+}
+
+func (s *BookmarkService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Save bookmarks a post the user can see, and adds it to one of their collections unless collectionID is empty.
+// Saving a repost saves the post it shares.
+func (s *BookmarkService) Save(userID, postID, collectionID string) error {
+	postID, err := s.savable(userID, postID)
+	if err != nil {
+		return err
+	}
+	if collectionID != "" {
+		if _, err := s.owned(userID, collectionID); err != nil {
+			return err
+		}
+	}
+	return s.Repo.Save(userID, postID, collectionID, s.now())
+}
+
+// Remove deletes the user's bookmark of a post, taking it out of all their collections, or only takes it out
+// of one of their collections when collectionID is set.
+func (s *BookmarkService) Remove(userID, postID, collectionID string) error {
+	postID, err := s.original(postID)
+	if err != nil {
+		return err
+	}
+	var removed bool
+	if collectionID != "" {
+		if _, err := s.owned(userID, collectionID); err != nil {
+			return err
+		}
+		removed, err = s.Repo.RemoveFromCollection(collectionID, postID)
+	} else {
+		removed, err = s.Repo.Remove(userID, postID)
+	}
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// Saved returns a page of the posts the viewer bookmarked, or of a collection the viewer may browse when
+// collectionID is set, and the cursor of the next page, nil after the last one. Posts the viewer may no
+// longer see are left out.
+func (s *BookmarkService) Saved(viewerID, collectionID string, after *repository.FeedCursor, limit int) ([]model.Post, *repository.FeedCursor, error) {
+	if collectionID != "" {
+		collection, err := s.Repo.FindCollection(collectionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if collection == nil || (collection.Private && collection.UserID != viewerID) {
+			return nil, nil, ErrCollectionNotFound
+		}
+	}
+	// one extra post tells whether there is another page
+	posts, err := s.Repo.FindSaved(viewerID, collectionID, after, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(posts) <= limit {
+		return posts, nil, nil
+	}
+	page := posts[:limit]
+	last := page[limit-1]
+	return page, &repository.FeedCursor{CreatedAt: *last.SavedAt, ID: last.Id}, nil
+}
+
+// Collections returns the owner's collections, leaving out private ones unless the viewer is the owner.
+func (s *BookmarkService) Collections(viewerID, ownerID string) ([]model.Collection, error) {
+	return s.Repo.FindCollections(ownerID, viewerID, ownerID == viewerID)
+}
+
+// CreateCollection creates a collection for the user.
+func (s *BookmarkService) CreateCollection(userID, name string, private bool) (*model.Collection, error) {
+	collection := &model.Collection{
+		ID:        utils.GenerateUUID(),
+		UserID:    userID,
+		Private:   private,
+		CreatedAt: s.now(),
+	}
+	if err := s.setName(collection, name); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateCollection(collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// UpdateCollection renames one of the user's collections and changes its privacy. Nil fields are left as they are.
+func (s *BookmarkService) UpdateCollection(userID, collectionID string, name *string, private *bool) (*model.Collection, error) {
+	collection, err := s.owned(userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if name != nil {
+		if err := s.setName(collection, *name); err != nil {
+			return nil, err
+		}
+	}
+	if private != nil {
+		collection.Private = *private
+	}
+	if err := s.Repo.UpdateCollection(collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// DeleteCollection deletes one of the user's collections. The posts in it stay bookmarked.
+func (s *BookmarkService) DeleteCollection(userID, collectionID string) error {
+	if _, err := s.owned(userID, collectionID); err != nil {
+		return err
+	}
+	return s.Repo.DeleteCollection(collectionID)
+}
+
+func (s *BookmarkService) setName(collection *model.Collection, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > model.MaxCollectionNameLength {
+		return ErrInvalidCollectionName
+	}
+	taken, err := s.Repo.CollectionNameTaken(collection.UserID, name, collection.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrCollectionNameTaken
+	}
+	collection.Name = name
+	return nil
+}
+
+// owned returns one of the user's collections. Other users' collections are not found.
+func (s *BookmarkService) owned(userID, collectionID string) (*model.Collection, error) {
+	collection, err := s.Repo.FindCollection(collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil || collection.UserID != userID {
+		return nil, ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+// savable returns the ID of the post to bookmark for postID, the post itself or the one it reposts,
+// if the user may see it.
+func (s *BookmarkService) savable(userID, postID string) (string, error) {
+	postID, err := s.original(postID)
+	if err != nil {
+		return "", err
+	}
+	visible, err := repository.PostVisibleTo(s.Repo.DB, postID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !visible {
+		return "", ErrPostNotFound
+	}
+	return postID, nil
+}
+
+// original returns the ID of the post a repost shares, or postID for other posts and posts that do not exist.
+func (s *BookmarkService) original(postID string) (string, error) {
+	post, err := repository.NewPostRepository(s.Repo.DB).FindByID(postID)
+	if err != nil {
+		return "", err
+	}
+	if post != nil && post.RepostOf != "" {
+		return post.RepostOf, nil
+	}
+	return postID, nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newBookmarkTestService(t *testing.T) (*BookmarkService, *sql.DB) {
+	t.Helper()
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "reader", "friend"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+	mustExec(t, db, `INSERT INTO followers (follower_id, followed_id, status) VALUES ('friend', 'author', 'accepted'), ('reader', 'author', 'accepted')`)
+	mustExec(t, db, `INSERT INTO groups (id, title, creator_id) VALUES (1, 'Hikers', 'author')`)
+	mustExec(t, db, `INSERT INTO group_members (group_id, user_id, role, status) VALUES (1, 'author', 'admin', 'active'), (1, 'reader', 'member', 'active')`)
+	for i, id := range []string{"post-1", "post-2", "post-3"} {
+		mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES (?, 'author', 'T', 'C', 'public', ?)`,
+			id, time.Date(2024, 6, 1, i, 0, 0, 0, time.UTC))
+	}
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility) VALUES ('friends-post', 'author', 'T', 'C', 'almostprivate')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, group_id) VALUES ('group-post', 'author', 'T', 'C', 'public', '1')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, repost_of) VALUES ('repost', 'friend', '', '', 'public', 'post-3')`)
+
+	s := NewBookmarkService(repository.NewBookmarkRepository(db))
+	now := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { now = now.Add(time.Minute); return now }
+	return s, db
+}
+
+// savedIDs returns the IDs of every saved post the viewer may see, paging through them two at a time
+func savedIDs(t *testing.T, s *BookmarkService, viewerID, collectionID string) []string {
+	t.Helper()
+	var ids []string
+	var after *repository.FeedCursor
+	for {
+		page, next, err := s.Saved(viewerID, collectionID, after, 2)
+		if err != nil {
+			t.Fatalf("Saved() failed: %v", err)
+		}
+		for _, post := range page {
+			if !post.Saved && collectionID == "" {
+				t.Errorf("expected saved post %s to be flagged", post.Id)
+			}
+			ids = append(ids, post.Id)
+		}
+		if next == nil {
+			return ids
+		}
+		after = next
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	s, db := newBookmarkTestService(t)
+
+	for _, id := range []string{"post-1", "friends-post", "group-post", "repost", "post-2"} {
+		if err := s.Save("reader", id, ""); err != nil {
+			t.Fatalf("Save(%s) failed: %v", id, err)
+		}
+	}
+	// saving again keeps the bookmark where it was
+	if err := s.Save("reader", "post-1", ""); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if err := s.Save("friend", "group-post", ""); err != ErrPostNotFound {
+		t.Errorf("expected posts the user may not see to be ErrPostNotFound, got %v", err)
+	}
+
+	// the repost saves the post it shares
+	got := savedIDs(t, s, "reader", "")
+	want := []string{"post-2", "post-3", "group-post", "friends-post", "post-1"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	feed, err := repository.GetPosts("reader", db, nil, 10)
+	if err != nil {
+		t.Fatalf("GetPosts() failed: %v", err)
+	}
+	for _, post := range *feed {
+		saved := post.Id == "post-1" || post.Id == "post-2" || post.Id == "post-3" || post.Id == "friends-post"
+		if post.Saved != saved {
+			t.Errorf("expected %s to be saved: %v", post.Id, saved)
+		}
+		if post.Id == "repost" && (post.Original == nil || !post.Original.Saved) {
+			t.Errorf("expected the reposted post to be flagged saved")
+		}
+	}
+	groupPosts, err := repository.GetGroupPosts("1", "reader", db)
+	if err != nil || len(groupPosts) != 1 || !groupPosts[0].Saved {
+		t.Errorf("expected the saved group post to be flagged, got %+v, %v", groupPosts, err)
+	}
+
+	// posts the reader may no longer see drop out of the list without being deleted
+	mustExec(t, db, `DELETE FROM followers WHERE follower_id = 'reader'`)
+	mustExec(t, db, `UPDATE group_members SET status = 'left' WHERE user_id = 'reader'`)
+	if got := savedIDs(t, s, "reader", ""); len(got) != 3 {
+		t.Errorf("expected only the public posts, got %v", got)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM bookmarks WHERE user_id = 'reader'`); n != 5 {
+		t.Errorf("expected the bookmarks to be kept, got %d", n)
+	}
+
+	if err := s.Remove("reader", "repost", ""); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if err := s.Remove("reader", "post-3", ""); err != ErrBookmarkNotFound {
+		t.Errorf("expected ErrBookmarkNotFound, got %v", err)
+	}
+}
+
+func TestBookmarkCollections(t *testing.T) {
+	s, db := newBookmarkTestService(t)
+
+	trips, err := s.CreateCollection("reader", " Trips ", false)
+	if err != nil || trips.Name != "Trips" {
+		t.Fatalf("CreateCollection() failed: %+v, %v", trips, err)
+	}
+	secret, err := s.CreateCollection("reader", "Secret", true)
+	if err != nil {
+		t.Fatalf("CreateCollection() failed: %v", err)
+	}
+	if _, err := s.CreateCollection("reader", "trips", true); err != ErrCollectionNameTaken {
+		t.Errorf("expected ErrCollectionNameTaken, got %v", err)
+	}
+	if _, err := s.CreateCollection("friend", "trips", true); err != nil {
+		t.Errorf("expected names to be unique per user only, got %v", err)
+	}
+	if _, err := s.CreateCollection("reader", "  ", true); err != ErrInvalidCollectionName {
+		t.Errorf("expected ErrInvalidCollectionName, got %v", err)
+	}
+
+	for _, id := range []string{"post-1", "group-post"} {
+		if err := s.Save("reader", id, trips.ID); err != nil {
+			t.Fatalf("Save(%s) failed: %v", id, err)
+		}
+	}
+	if err := s.Save("reader", "post-2", secret.ID); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if err := s.Save("friend", "post-2", secret.ID); err != ErrCollectionNotFound {
+		t.Errorf("expected other users' collections not to be found, got %v", err)
+	}
+
+	// others browse public collections, without the posts they may not see
+	if got := savedIDs(t, s, "friend", trips.ID); len(got) != 1 || got[0] != "post-1" {
+		t.Errorf("expected only the public post, got %v", got)
+	}
+	if _, _, err := s.Saved("friend", secret.ID, nil, 10); err != ErrCollectionNotFound {
+		t.Errorf("expected private collections not to be found, got %v", err)
+	}
+	collections, err := s.Collections("friend", "reader")
+	if err != nil || len(collections) != 1 || collections[0].ID != trips.ID || collections[0].PostCount != 1 {
+		t.Errorf("expected the public collection with the visible post counted, got %+v, %v", collections, err)
+	}
+	if collections, _ := s.Collections("reader", "reader"); len(collections) != 2 || collections[0].Name != "Secret" {
+		t.Errorf("expected the owner to see every collection by name, got %+v", collections)
+	}
+
+	if err := s.Remove("reader", "post-1", trips.ID); err != nil {
+		t.Fatalf("Remove() from collection failed: %v", err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM bookmarks WHERE post_id = 'post-1'`); n != 1 {
+		t.Errorf("expected the post to stay bookmarked, got %d", n)
+	}
+	if err := s.Remove("reader", "group-post", ""); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM bookmark_collection_posts WHERE collection_id = '`+trips.ID+`'`); n != 0 {
+		t.Errorf("expected removing the bookmark to empty the collection, got %d", n)
+	}
+
+	private := true
+	if _, err := s.UpdateCollection("friend", trips.ID, nil, &private); err != ErrCollectionNotFound {
+		t.Errorf("expected ErrCollectionNotFound, got %v", err)
+	}
+	name := "secret"
+	if _, err := s.UpdateCollection("reader", trips.ID, &name, &private); err != ErrCollectionNameTaken {
+		t.Errorf("expected ErrCollectionNameTaken, got %v", err)
+	}
+	if updated, err := s.UpdateCollection("reader", trips.ID, nil, &private); err != nil || !updated.Private || updated.Name != "Trips" {
+		t.Errorf("expected the collection to become private, got %+v, %v", updated, err)
+	}
+
+	if err := s.DeleteCollection("reader", secret.ID); err != nil {
+		t.Fatalf("DeleteCollection() failed: %v", err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM bookmarks WHERE post_id = 'post-2'`); n != 1 {
+		t.Errorf("expected the posts of a deleted collection to stay bookmarked, got %d", n)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/policy"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"backend/pkg/blobstore"
+)
+
+// Errors returned by DraftService so handlers can map them to status codes
+var (
+	ErrDraftNotFound    = errors.New("draft not found")
+	ErrDraftChanged     = errors.New("the draft changed while it was being published, try again")
+	ErrPublishAtPassed  = errors.New("posts can only be scheduled for a time in the future")
+	ErrNotGroupMember   = errors.New("only members of the group can post in it")
+	ErrQuoteUnavailable = errors.New("the quoted post is no longer available")
+)
+
+// ScheduledPostsPeriod is how often scheduled posts that are due get published
+const ScheduledPostsPeriod = time.Minute
+
+// DraftService keeps posts their authors are not done with, and publishes them on request or when their
+// scheduled time comes
+type DraftService struct {
+	Repo    *repository.DraftRepository
+	Posts   *repository.PostRepository
+	Policy  *policy.Policy    // Decides who may post in groups when drafts are saved and again when published
+	Uploads *blobstore.Server // Where draft images are stored, see utils.Uploads
+	Now     func() time.Time  // Clock used for created_at, updated_at and due posts, defaults to time.Now
+}
+
+// NewDraftService creates and returns a new instance of DraftService.
+func NewDraftService(repo *repository.DraftRepository) *DraftService {
+	return &DraftService{
+		Repo:    repo,
+		Posts:   repository.NewPostRepository(repo.DB),
+		Policy:  policy.New(repo.DB),
+		Uploads: utils.Uploads,
+		Now:     time.Now,
+	}
+}
+
+func (s *DraftService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Drafts returns the user's drafts and scheduled posts, most recently changed first.
+func (s *DraftService) Drafts(userID string) ([]model.PostDraft, error) {
+	return s.Repo.FindByUser(userID)
+}
+
+// Draft returns one of the user's drafts. Other users' drafts are not found.
+func (s *DraftService) Draft(userID, draftID string) (*model.PostDraft, error) {
+	draft, err := s.Repo.FindByID(draftID)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil || draft.UserID != userID {
+		return nil, ErrDraftNotFound
+	}
+	return draft, nil
+}
+
+// Create saves a new draft of draft.UserID, which the caller has checked like a post if it is scheduled.
+func (s *DraftService) Create(draft *model.PostDraft) error {
+	now := s.now()
+	draft.ID = utils.GenerateUUID()
+	draft.CreatedAt, draft.UpdatedAt = now, now
+	if err := s.check(draft, now); err != nil {
+		return err
+	}
+	return s.Repo.Create(draft)
+}
+
+// Update replaces before with after and clears the error of a failed scheduled post. Images the draft
+// no longer uses are deleted.
+func (s *DraftService) Update(before, after *model.PostDraft) error {
+	now := s.now()
+	after.UpdatedAt = now
+	after.PublishError = ""
+	if err := s.check(after, now); err != nil {
+		return err
+	}
+	if err := s.Repo.Update(after); err != nil {
+		return err
+	}
+
+	kept := map[string]bool{}
+	for _, url := range mediaURLs(after.Media) {
+		kept[url] = true
+	}
+	var unused []string
+	for _, url := range mediaURLs(before.Media) {
+		if !kept[url] {
+			unused = append(unused, url)
+		}
+	}
+	return s.DiscardImages(unused...)
+}
+
+// Delete removes one of the user's drafts with the images only it used.
+func (s *DraftService) Delete(userID, draftID string) error {
+	draft, err := s.Draft(userID, draftID)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.Delete(draft.ID); err != nil {
+		return err
+	}
+	return s.DiscardImages(mediaURLs(draft.Media)...)
+}
+
+// Publish turns a draft, which the caller has checked like a post, into a post right away.
+func (s *DraftService) Publish(draft *model.PostDraft) (*model.Post, error) {
+	return s.publish(draft)
+}
+
+// PublishDue publishes the scheduled posts that are due and returns them. A post that can no longer be
+// published, such as a group post of someone who has left the group or a poll that would already be closed,
+// goes back to being a draft with the reason in PublishError.
+func (s *DraftService) PublishDue() ([]model.Post, error) {
+	drafts, err := s.Repo.FindDue(s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	var published []model.Post
+	var errs []error
+	for i := range drafts {
+		post, err := s.publish(&drafts[i])
+		switch err {
+		case nil:
+			published = append(published, *post)
+		case ErrDraftChanged:
+			// the author changed it meanwhile, a later run publishes the new version if it is still due
+		case ErrNotGroupMember, ErrQuoteUnavailable, ErrReshareTooWide, ErrPollClosed:
+			errs = append(errs, s.Repo.Unschedule(&drafts[i], err.Error()))
+		default:
+			errs = append(errs, err)
+		}
+	}
+	return published, errors.Join(errs...)
+}
+
+// DiscardImages removes uploaded draft images that nothing uses anymore.
+func (s *DraftService) DiscardImages(webPaths ...string) error {
+	return discardUploads(s.Repo.DB, s.Uploads, webPaths)
+}
+
+func (s *DraftService) publish(draft *model.PostDraft) (*model.Post, error) {
+	if err := s.checkGroup(draft.UserID, draft.GroupID); err != nil {
+		return nil, err
+	}
+	post := draft.Post()
+	post.Id = utils.GenerateUUID()
+	post.CreatedAt = s.now()
+	if post.Poll != nil && post.Poll.ClosesAt != nil && !post.Poll.ClosesAt.After(post.CreatedAt) {
+		return nil, ErrPollClosed
+	}
+	if err := s.checkQuote(draft.UserID, &post); err != nil {
+		return nil, err
+	}
+
+	published, err := s.Repo.Publish(draft, &post)
+	if err != nil {
+		return nil, err
+	}
+	if !published {
+		return nil, ErrDraftChanged
+	}
+	return &post, nil
+}
+
+// check validates what the caller cannot: that the user may post in the draft's group and, for a scheduled
+// post, that its time is still to come and that it may quote the post it quotes.
+func (s *DraftService) check(draft *model.PostDraft, now time.Time) error {
+	if err := s.checkGroup(draft.UserID, draft.GroupID); err != nil {
+		return err
+	}
+	if draft.PublishAt == nil {
+		return nil
+	}
+	if !draft.PublishAt.After(now) {
+		return ErrPublishAtPassed
+	}
+	post := draft.Post()
+	if err := s.checkQuote(draft.UserID, &post); err != nil {
+		return err
+	}
+	draft.QuoteOf = post.QuoteOf
+	return nil
+}
+
+func (s *DraftService) checkGroup(userID, groupID string) error {
+	if groupID == "" {
+		return nil
+	}
+	id, err := strconv.ParseUint(groupID, 10, 32)
+	if err != nil {
+		return ErrNotGroupMember
+	}
+	allowed, err := s.Policy.CanPostInGroup(userID, uint(id))
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrNotGroupMember
+	}
+	return nil
+}
+
+// checkQuote resolves the post a quote shares, see RepostService.Quote.
+func (s *DraftService) checkQuote(userID string, post *model.Post) error {
+	if post.QuoteOf == "" {
+		return nil
+	}
+	err := NewRepostService(s.Posts).Quote(userID, post)
+	if err == ErrPostNotFound {
+		return ErrQuoteUnavailable
+	}
+	return err
+}
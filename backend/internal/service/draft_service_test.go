@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/blobstore"
+	"backend/pkg/db/dbtest"
+)
+
+func newDraftTestService(t *testing.T) (*DraftService, *time.Time) {
+	t.Helper()
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "member", "stranger"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+	mustExec(t, db, `INSERT INTO groups (id, title, description, creator_id) VALUES (1, 'Hikers', 'Weekend hikes', 'author')`)
+	mustExec(t, db, `INSERT INTO group_members (group_id, user_id, role, status) VALUES (1, 'author', 'admin', 'active'), (1, 'member', 'member', 'active')`)
+
+	s := NewDraftService(repository.NewDraftRepository(db))
+	s.Uploads = newTestUploads(t)
+	clock := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return clock }
+	return s, &clock
+}
+
+func newDraft(userID, title string) *model.PostDraft {
+	return &model.PostDraft{UserID: userID, Title: title, Content: "Meet at the trailhead at nine", Visibility: "public"}
+}
+
+func TestDrafts(t *testing.T) {
+	s, clock := newDraftTestService(t)
+
+	draft := &model.PostDraft{UserID: "author", Title: "Hike", Visibility: "private"}
+	if err := s.Create(draft); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if _, err := s.Draft("stranger", draft.ID); err != ErrDraftNotFound {
+		t.Errorf("expected other users' drafts not to be found, got %v", err)
+	}
+	if drafts, err := s.Drafts("stranger"); err != nil || len(drafts) != 0 {
+		t.Errorf("expected no drafts for another user, got %+v, %v", drafts, err)
+	}
+
+	*clock = clock.Add(time.Minute)
+	edited := *draft
+	edited.Title, edited.Content, edited.Visibility = "Saturday hike", "Meet at the trailhead at nine", "public"
+	if err := s.Update(draft, &edited); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM posts`); n != 0 {
+		t.Fatalf("expected drafts to stay out of posts, got %d", n)
+	}
+
+	// a publish based on an outdated copy would lose the edit
+	if _, err := s.Publish(draft); err != ErrDraftChanged {
+		t.Errorf("expected ErrDraftChanged, got %v", err)
+	}
+	current, err := s.Draft("author", draft.ID)
+	if err != nil || current.Title != "Saturday hike" {
+		t.Fatalf("expected the edited draft, got %+v, %v", current, err)
+	}
+	post, err := s.Publish(current)
+	if err != nil {
+		t.Fatalf("Publish() failed: %v", err)
+	}
+	if published, _ := s.Posts.FindByID(post.Id); published == nil || published.Title != "Saturday hike" || !published.CreatedAt.Equal(*clock) {
+		t.Errorf("expected the draft to be published now, got %+v", published)
+	}
+	if _, err := s.Draft("author", draft.ID); err != ErrDraftNotFound {
+		t.Errorf("expected the draft to be gone once published, got %v", err)
+	}
+}
+
+func TestScheduledPosts(t *testing.T) {
+	s, clock := newDraftTestService(t)
+	at := func(d time.Duration) *time.Time {
+		t := clock.Add(d)
+		return &t
+	}
+
+	past := newDraft("author", "Too late")
+	past.PublishAt = at(-time.Minute)
+	if err := s.Create(past); err != ErrPublishAtPassed {
+		t.Errorf("expected ErrPublishAtPassed, got %v", err)
+	}
+	outsider := newDraft("stranger", "Not my group")
+	outsider.GroupID = "1"
+	if err := s.Create(outsider); err != ErrNotGroupMember {
+		t.Errorf("expected ErrNotGroupMember, got %v", err)
+	}
+
+	soon := newDraft("author", "Soon")
+	soon.PublishAt = at(time.Hour)
+	later := newDraft("author", "Later")
+	later.PublishAt = at(2 * time.Hour)
+	groupPost := newDraft("member", "Group hike")
+	groupPost.GroupID, groupPost.PublishAt = "1", at(time.Hour)
+	for _, draft := range []*model.PostDraft{soon, later, groupPost} {
+		if err := s.Create(draft); err != nil {
+			t.Fatalf("Create(%s) failed: %v", draft.Title, err)
+		}
+	}
+	// membership is checked again when the post goes out
+	mustExec(t, s.Repo.DB, `UPDATE group_members SET status = 'left' WHERE user_id = 'member'`)
+
+	if published, err := s.PublishDue(); err != nil || len(published) != 0 {
+		t.Fatalf("expected nothing due yet, got %+v, %v", published, err)
+	}
+	*clock = clock.Add(90 * time.Minute)
+	published, err := s.PublishDue()
+	if err != nil || len(published) != 1 || published[0].Title != "Soon" {
+		t.Fatalf("expected the due post to be published, got %+v, %v", published, err)
+	}
+	// a restarted scheduler does not publish it twice
+	if again, err := s.PublishDue(); err != nil || len(again) != 0 {
+		t.Errorf("expected nothing left to publish, got %+v, %v", again, err)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM posts`); n != 1 {
+		t.Errorf("expected one post, got %d", n)
+	}
+
+	failed, err := s.Draft("member", groupPost.ID)
+	if err != nil || failed.PublishAt != nil || failed.PublishError != ErrNotGroupMember.Error() {
+		t.Errorf("expected the group post to go back to the drafts with the reason, got %+v, %v", failed, err)
+	}
+	if drafts, _ := s.Drafts("author"); len(drafts) != 1 || drafts[0].ID != later.ID {
+		t.Errorf("expected the later post to stay scheduled, got %+v", drafts)
+	}
+}
+
+func TestDraftImages(t *testing.T) {
+	s, _ := newDraftTestService(t)
+	ctx := context.Background()
+	key := blobstore.ContentKey("posts", []byte("image"), ".png")
+	if err := s.Uploads.Store.Put(ctx, key, []byte("image"), "image/png"); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	url := s.Uploads.URL(key)
+
+	draft := newDraft("author", "Photos")
+	draft.Media = []model.PostMedia{{ID: "media-1", URL: url}}
+	if err := s.Create(draft); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	if used, err := repository.UploadInUse(s.Repo.DB, url); err != nil || !used {
+		t.Errorf("expected the draft's image to be in use, got %v, %v", used, err)
+	}
+	if visible, _, err := repository.MediaAccess(s.Repo.DB, url, "author"); err != nil || !visible {
+		t.Errorf("expected the author to see the draft's image, got %v, %v", visible, err)
+	}
+	if visible, _, _ := repository.MediaAccess(s.Repo.DB, url, "stranger"); visible {
+		t.Error("expected other users not to see the draft's image")
+	}
+
+	if err := s.Delete("author", draft.ID); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if ok, _ := s.Uploads.Store.Exists(ctx, key); ok {
+		t.Error("expected the image to be deleted with the draft")
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// Constants controlling the ranked feed
+const (
+	RankedFeedWindow  = 14 * 24 * time.Hour // Only posts this recent are ranked and scored
+	AffinityWindow    = 90 * 24 * time.Hour // Interactions older than this no longer count towards affinity
+	FeedRankingPeriod = 5 * time.Minute     // How often post scores and affinities are rebuilt
+)
+
+// DefaultRankWeights are the weights of the ranked feed. A post's score is
+//
+//	(1 + Engagement*engagement + Affinity*affinity + Group*inGroup) / (1 + ageHours/RecencyHalfLife)
+//
+// where engagement and affinity grow from 0 towards 1 as likes, comments and interactions add up.
+var DefaultRankWeights = repository.RankWeights{
+	Like:               1,
+	Dislike:            0.5,
+	Comment:            2,
+	EngagementMidpoint: 10,
+	AffinityMidpoint:   5,
+	Engagement:         2,
+	Affinity:           3,
+	Group:              1,
+	RecencyHalfLife:    12 * time.Hour,
+}
+
+// FeedRankingService precomputes the inputs of the ranked feed and reads the feed ranked as of a point in time
+type FeedRankingService struct {
+	Repo    *repository.FeedRankingRepository
+	Weights repository.RankWeights
+	Now     func() time.Time // Clock used for ranking and refreshes, defaults to time.Now
+}
+
+// NewFeedRankingService creates and returns a new instance of FeedRankingService.
+func NewFeedRankingService(repo *repository.FeedRankingRepository) *FeedRankingService {
+	return &FeedRankingService{Repo: repo, Weights: DefaultRankWeights, Now: time.Now}
+}
+
+func (s *FeedRankingService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Refresh rebuilds the engagement of recent posts and every user's affinity with the authors they interact with.
+func (s *FeedRankingService) Refresh() error {
+	now := s.now()
+	return s.Repo.RefreshScores(now.Add(-RankedFeedWindow), now.Add(-AffinityWindow), s.Weights, now)
+}
+
+// Ranked returns a page of the user's ranked feed and the cursor of the next page, nil after the last one.
+// A nil cursor starts a new ranking as of now.
+func (s *FeedRankingService) Ranked(viewerID string, cursor *repository.RankedCursor, limit int) ([]model.Post, *repository.RankedCursor, error) {
+	if cursor == nil {
+		cursor = &repository.RankedCursor{AsOf: s.now()}
+	}
+	// one extra post tells whether there is another page
+	posts, err := s.Repo.GetRankedPosts(viewerID, cursor.AsOf.Add(-RankedFeedWindow), cursor.AsOf, s.Weights, cursor.Offset, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(posts) <= limit {
+		return posts, nil, nil
+	}
+	return posts[:limit], &repository.RankedCursor{AsOf: cursor.AsOf, Offset: cursor.Offset + limit}, nil
+}
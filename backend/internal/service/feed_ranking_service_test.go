@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func TestRankedFeed(t *testing.T) {
+	db := dbtest.New(t)
+	for _, id := range []string{"viewer", "friend", "popular", "stranger", "fan-1", "fan-2", "fan-3", "fan-4"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	post := func(id, author, visibility, groupID string, age time.Duration) {
+		var group any
+		if groupID != "" {
+			group = groupID
+		}
+		if _, err := db.Exec(`INSERT INTO posts (id, user_id, title, content, visibility, group_id, created_at) VALUES (?, ?, 'T', 'C', ?, ?, ?)`,
+			id, author, visibility, group, now.Add(-age)); err != nil {
+			t.Fatalf("failed to insert post %s: %v", id, err)
+		}
+	}
+	post("fresh", "stranger", "public", "", time.Hour)
+	post("popular", "popular", "public", "", 6*time.Hour)
+	post("friend", "friend", "public", "", 20*time.Hour)
+	post("group", "stranger", "public", "1", 2*time.Hour)
+	post("unseen", "stranger", "almostprivate", "", time.Hour)
+	post("old", "popular", "public", "", 20*24*time.Hour)
+
+	mustExec(t, db, `INSERT INTO groups (id, title, description, creator_id) VALUES (1, 'Hikers', '', 'stranger')`)
+	mustExec(t, db, `INSERT INTO group_members (group_id, user_id, role, status) VALUES (1, 'viewer', 'member', 'active')`)
+	for i := 1; i <= 4; i++ {
+		fan := fmt.Sprintf("fan-%d", i)
+		if i <= 3 {
+			mustExec(t, db, `INSERT INTO reactions (post_id, user_id, type) VALUES ('popular', ?, 'like')`, fan)
+		}
+		mustExec(t, db, `INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at) VALUES (?, 'popular', ?, 'Nice', ?, ?)`,
+			"comment-"+fan, fan, now, now)
+	}
+	for i := 0; i < 15; i++ {
+		mustExec(t, db, `INSERT INTO messages (id, sender_id, receiver_id, content, created_at) VALUES (?, 'viewer', 'friend', 'Hi', ?)`,
+			fmt.Sprintf("message-%d", i), now.Add(-24*time.Hour))
+	}
+
+	s := NewFeedRankingService(repository.NewFeedRankingRepository(db))
+	s.Now = func() time.Time { return now }
+	// refreshing again replaces the scores rather than adding to them
+	for i := 0; i < 2; i++ {
+		if err := s.Refresh(); err != nil {
+			t.Fatalf("Refresh() failed: %v", err)
+		}
+	}
+
+	first, next, err := s.Ranked("viewer", nil, 2)
+	if err != nil {
+		t.Fatalf("Ranked() failed: %v", err)
+	}
+	if next == nil {
+		t.Fatal("expected a cursor for the second page")
+	}
+	second, last, err := s.Ranked("viewer", next, 2)
+	if err != nil {
+		t.Fatalf("Ranked() failed: %v", err)
+	}
+	if last != nil {
+		t.Errorf("expected the second page to be the last, got cursor %+v", last)
+	}
+
+	var got []string
+	for _, p := range append(first, second...) {
+		got = append(got, p.Id)
+	}
+	want := []string{"group", "popular", "friend", "fresh"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expected the ranking %v, got %v", want, got)
+	}
+
+	popular, friend, group := first[1].Rank, second[0].Rank, first[0].Rank
+	if popular.Likes != 3 || popular.Comments != 4 || popular.Engagement <= 0.5 {
+		t.Errorf("expected the popular post's engagement to come from its reactions and comments, got %+v", popular)
+	}
+	if friend.Affinity != 0.75 {
+		t.Errorf("expected the viewer's messages to give an affinity of 0.75 with the friend, got %+v", friend)
+	}
+	// julianday() keeps ages to about a millisecond
+	if !group.GroupMember || math.Abs(group.AgeHours-2) > 0.001 || math.Abs(group.Recency-6.0/7) > 0.001 {
+		t.Errorf("unexpected rank for the group post %+v", group)
+	}
+	if second[1].Rank.Engagement != 0 || second[1].Rank.Affinity != 0 {
+		t.Errorf("expected no boosts for the fresh post, got %+v", second[1].Rank)
+	}
+
+	if _, err := repository.ParseRankedCursor(next.String()); err != nil {
+		t.Errorf("expected the cursor to round trip, got %v", err)
+	}
+}
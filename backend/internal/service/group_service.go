@@ -2,6 +2,7 @@ package service
 
 import (
 	"backend/internal/model"
+	"backend/internal/policy"
 	"backend/internal/repository"
 	"fmt"
 )
@@ -20,6 +21,26 @@ func (s *GroupService) GetAllGroups() ([]model.Group, error) {
 	return s.Repo.FindAll()
 }
 
+// GetVisibleGroups retrieves the groups the user may see, leaving out the secret groups they are not part of.
+func (s *GroupService) GetVisibleGroups(userID string) ([]model.Group, error) {
+	groups, err := s.Repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := s.Repo.FindMemberStatuses(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var visible []model.Group
+	for i := range groups {
+		if policy.GroupVisible(&groups[i], userID, statuses[groups[i].ID]) {
+			visible = append(visible, groups[i])
+		}
+	}
+	return visible, nil
+}
+
 func (s *GroupService) CreateGroup(title, description, privacySetting string, creatorID string) (*model.Group, error) {
 	// Start a transaction within the service layer
 	tx, err := s.Repo.DB.Begin() // Access DB from repository
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// Reasons stored with failed login attempts
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureInvalidTwoFactor   = "invalid_2fa_code"
+	LoginFailureLocked             = "locked"
+)
+
+// Constants controlling login throttling
+const (
+	LoginAttemptWindow      = time.Hour        // Failures older than this are forgotten
+	AccountFreeAttempts     = 3                // Failures per account before backoff starts
+	IPFreeAttempts          = 10               // Failures per IP before backoff starts, higher for shared networks
+	LoginBaseBackoff        = time.Second      // Delay after the first throttled failure, doubled each time
+	LoginMaxBackoff         = 15 * time.Minute // Upper bound for the backoff delay
+	AccountLockoutThreshold = 10               // Failures per account that lock it for LoginMaxBackoff
+	FailedAttemptsPageSize  = 50               // Attempts returned to the account owner
+)
+
+// LoginAttemptService tracks login attempts per account and per IP and decides when to throttle them
+type LoginAttemptService struct {
+	Repo  *repository.LoginAttemptRepository
+	Now   func() time.Time // Clock used for throttling, defaults to time.Now
+	Audit *AuditService    // Records failed attempts, optional
+}
+
+// NewLoginAttemptService creates and returns a new instance of LoginAttemptService.
+func NewLoginAttemptService(repo *repository.LoginAttemptRepository) *LoginAttemptService {
+	return &LoginAttemptService{Repo: repo, Now: time.Now}
+}
+
+func (s *LoginAttemptService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// RetryAfter returns how long a login for this email from this IP must wait, or zero if it may proceed.
+// Emails are tracked whether or not an account exists, so the answer does not reveal which emails are registered.
+func (s *LoginAttemptService) RetryAfter(email, ipAddress string) (time.Duration, error) {
+	now := s.now()
+	since := now.Add(-LoginAttemptWindow)
+
+	accountFailures, err := s.Repo.RecentAccountFailures(email, since)
+	if err != nil {
+		return 0, err
+	}
+	ipFailures, err := s.Repo.RecentIPFailures(ipAddress, since)
+	if err != nil {
+		return 0, err
+	}
+
+	accountWait := throttleDelay(accountFailures, AccountFreeAttempts, now)
+	if len(accountFailures) >= AccountLockoutThreshold {
+		if until := accountFailures[0].Add(LoginMaxBackoff); now.Before(until) {
+			accountWait = until.Sub(now)
+		}
+	}
+	ipWait := throttleDelay(ipFailures, IPFreeAttempts, now)
+
+	if ipWait > accountWait {
+		return ipWait, nil
+	}
+	return accountWait, nil
+}
+
+// RecordFailure stores a failed attempt and adds it to the audit log. userID may be empty when the
+// email has no account.
+func (s *LoginAttemptService) RecordFailure(email, userID, ipAddress, userAgent, reason string) error {
+	err := s.Repo.Insert(&model.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Reason:    reason,
+		CreatedAt: s.now().UTC(),
+	})
+	auditErr := s.Audit.Record(model.AuditEvent{
+		Action:     model.AuditLoginFailed,
+		ActorID:    userID,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+	}, nil, map[string]any{"email": email, "reason": reason})
+	return errors.Join(err, auditErr)
+}
+
+// RecordSuccess stores a completed login, which resets the backoff for the account.
+func (s *LoginAttemptService) RecordSuccess(email, userID, ipAddress, userAgent string) error {
+	return s.Repo.Insert(&model.LoginAttempt{
+		Email:     email,
+		UserID:    userID,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		Success:   true,
+		CreatedAt: s.now().UTC(),
+	})
+}
+
+// FailedAttempts returns the recent failed attempts against a user's account for the owner to review.
+func (s *LoginAttemptService) FailedAttempts(userID string) ([]model.LoginAttempt, error) {
+	return s.Repo.FindFailedByUserID(userID, FailedAttemptsPageSize)
+}
+
+// throttleDelay applies exponential backoff once the failures (newest first) exceed the free allowance
+func throttleDelay(failures []time.Time, free int, now time.Time) time.Duration {
+	if len(failures) < free {
+		return 0
+	}
+
+	delay := LoginMaxBackoff
+	if exp := len(failures) - free; exp < 32 {
+		if d := LoginBaseBackoff << exp; d > 0 && d < LoginMaxBackoff {
+			delay = d
+		}
+	}
+
+	until := failures[0].Add(delay)
+	if !now.Before(until) {
+		return 0
+	}
+	return until.Sub(now)
+}
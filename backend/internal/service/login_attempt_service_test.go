@@ -0,0 +1,172 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newLoginAttemptService(t *testing.T, clock *time.Time) *LoginAttemptService {
+	t.Helper()
+	db := dbtest.New(t)
+	insertTestUser(t, db, "user-1", "jane@example.com")
+
+	s := NewLoginAttemptService(repository.NewLoginAttemptRepository(db))
+	s.Now = func() time.Time { return *clock }
+	return s
+}
+
+func TestThrottleDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	failuresAt := func(n int, last time.Time) []time.Time {
+		times := make([]time.Time, n)
+		for i := range times {
+			times[i] = last
+		}
+		return times
+	}
+
+	tests := []struct {
+		name     string
+		failures []time.Time
+		free     int
+		want     time.Duration
+	}{
+		{"below free allowance", failuresAt(2, now), 3, 0},
+		{"first throttled failure", failuresAt(3, now), 3, LoginBaseBackoff},
+		{"doubles per failure", failuresAt(5, now), 3, 4 * LoginBaseBackoff},
+		{"capped at max backoff", failuresAt(40, now), 3, LoginMaxBackoff},
+		{"backoff already elapsed", failuresAt(4, now.Add(-time.Minute)), 3, 0},
+		{"partially elapsed", failuresAt(5, now.Add(-time.Second)), 3, 3 * LoginBaseBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := throttleDelay(tt.failures, tt.free, now); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRetryAfterAccountBackoffAndLockout(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	s := newLoginAttemptService(t, &clock)
+
+	for i := 0; i < AccountFreeAttempts-1; i++ {
+		s.RecordFailure("jane@example.com", "user-1", "10.0.0.1", "test", LoginFailureInvalidCredentials)
+	}
+	if wait, _ := s.RetryAfter("jane@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("expected no wait below the free allowance, got %v", wait)
+	}
+
+	s.RecordFailure("jane@example.com", "user-1", "10.0.0.1", "test", LoginFailureInvalidCredentials)
+	if wait, _ := s.RetryAfter("jane@example.com", "10.0.0.2"); wait != LoginBaseBackoff {
+		t.Fatalf("expected account backoff from any IP, got %v", wait)
+	}
+
+	// rejected attempts during a lockout do not extend it
+	s.RecordFailure("jane@example.com", "user-1", "10.0.0.1", "test", LoginFailureLocked)
+	clock = clock.Add(LoginBaseBackoff)
+	if wait, _ := s.RetryAfter("jane@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("expected backoff to have elapsed, got %v", wait)
+	}
+
+	for i := AccountFreeAttempts; i < AccountLockoutThreshold; i++ {
+		s.RecordFailure("jane@example.com", "user-1", "10.0.0.1", "test", LoginFailureInvalidCredentials)
+	}
+	if wait, _ := s.RetryAfter("jane@example.com", "10.0.0.1"); wait != LoginMaxBackoff {
+		t.Fatalf("expected account lockout of %v, got %v", LoginMaxBackoff, wait)
+	}
+
+	clock = clock.Add(LoginMaxBackoff)
+	if wait, _ := s.RetryAfter("jane@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("expected lockout to expire, got %v", wait)
+	}
+}
+
+func TestRetryAfterResetsOnSuccess(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	s := newLoginAttemptService(t, &clock)
+
+	for i := 0; i < AccountFreeAttempts; i++ {
+		s.RecordFailure("jane@example.com", "user-1", "10.0.0.1", "test", LoginFailureInvalidCredentials)
+	}
+	s.RecordSuccess("jane@example.com", "user-1", "10.0.0.1", "test")
+
+	if wait, _ := s.RetryAfter("jane@example.com", "10.0.0.1"); wait != 0 {
+		t.Errorf("expected a successful login to reset the account backoff, got %v", wait)
+	}
+}
+
+func TestRetryAfterTracksUnknownEmailsAndIPs(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	s := newLoginAttemptService(t, &clock)
+
+	// unknown emails are throttled exactly like existing ones
+	for i := 0; i < AccountFreeAttempts; i++ {
+		s.RecordFailure("nobody@example.com", "", "10.0.0.1", "test", LoginFailureInvalidCredentials)
+	}
+	if wait, _ := s.RetryAfter("nobody@example.com", "10.0.0.9"); wait != LoginBaseBackoff {
+		t.Errorf("expected unknown email to be throttled, got %v", wait)
+	}
+
+	// spraying many accounts from one IP trips the per-IP limit
+	for i := AccountFreeAttempts; i < IPFreeAttempts; i++ {
+		s.RecordFailure("other@example.com", "", "10.0.0.1", "test", LoginFailureInvalidCredentials)
+	}
+	if wait, _ := s.RetryAfter("fresh@example.com", "10.0.0.1"); wait != LoginBaseBackoff {
+		t.Errorf("expected IP backoff, got %v", wait)
+	}
+	if wait, _ := s.RetryAfter("fresh@example.com", "10.0.0.2"); wait != 0 {
+		t.Errorf("expected another IP to be unaffected, got %v", wait)
+	}
+}
+
+func TestFailedAttemptsOnlyListsOwnAccount(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	s := newLoginAttemptService(t, &clock)
+
+	s.RecordFailure("jane@example.com", "user-1", "10.0.0.1", "curl", LoginFailureInvalidCredentials)
+	s.RecordSuccess("jane@example.com", "user-1", "10.0.0.1", "firefox")
+	s.RecordFailure("nobody@example.com", "", "10.0.0.1", "curl", LoginFailureInvalidCredentials)
+
+	attempts, err := s.FailedAttempts("user-1")
+	if err != nil {
+		t.Fatalf("FailedAttempts() failed: %v", err)
+	}
+	if len(attempts) != 1 {
+		t.Fatalf("expected 1 failed attempt, got %d", len(attempts))
+	}
+	if attempts[0].UserAgent != "curl" || attempts[0].Reason != LoginFailureInvalidCredentials {
+		t.Errorf("unexpected attempt: %+v", attempts[0])
+	}
+}
+
+func TestRecordFailureIsAudited(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	s := newLoginAttemptService(t, &clock)
+	s.Audit = NewAuditService(repository.NewAuditRepository(s.Repo.DB))
+
+	s.RecordFailure("jane@example.com", "user-1", "10.0.0.1", "curl", LoginFailureInvalidTwoFactor)
+	s.RecordFailure("nobody@example.com", "", "10.0.0.2", "curl", LoginFailureLocked)
+	s.RecordSuccess("jane@example.com", "user-1", "10.0.0.1", "firefox")
+
+	events, err := s.Audit.List(repository.AuditFilter{Action: model.AuditLoginFailed})
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 failed logins in the audit log, got %d", len(events))
+	}
+	if e := events[1]; e.ActorID != "user-1" || e.TargetID != "user-1" || e.IPAddress != "10.0.0.1" || !strings.Contains(string(e.Diff), LoginFailureInvalidTwoFactor) {
+		t.Errorf("unexpected event for the rejected code: %+v", e)
+	}
+	if e := events[0]; e.ActorID != "" || !strings.Contains(string(e.Diff), "nobody@example.com") || !strings.Contains(string(e.Diff), LoginFailureLocked) {
+		t.Errorf("unexpected event for the throttled login: %+v", e)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"strings"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/mention"
+)
+
+// Limits of mention suggestions
+const (
+	DefaultMentionSuggestions = 8
+	MaxMentionSuggestions     = 20
+)
+
+// MentionService notifies users of the mentions they may see and suggests users to mention
+type MentionService struct {
+	Repo          *repository.MentionRepository
+	Notifications *NotificationService
+}
+
+// NewMentionService creates and returns a new instance of MentionService.
+func NewMentionService(repo *repository.MentionRepository, notifications *NotificationService) *MentionService {
+	return &MentionService{Repo: repo, Notifications: notifications}
+}
+
+// NotifyPost notifies the users mentioned in a post's own text since it was created or last edited.
+func (s *MentionService) NotifyPost(postID string) error {
+	return s.notify(s.Repo.UnnotifiedInPost(postID))
+}
+
+// NotifyComment notifies the users mentioned in a new comment.
+func (s *MentionService) NotifyComment(commentID string) error {
+	return s.notify(s.Repo.UnnotifiedInComment(commentID))
+}
+
+// NotifyMessage notifies the users mentioned in a new chat message.
+func (s *MentionService) NotifyMessage(messageID string) error {
+	return s.notify(s.Repo.UnnotifiedInMessage(messageID))
+}
+
+// notify tells each mentioned user about the mention, unless they mentioned themselves or cannot see
+// where they were mentioned. Those mentions stay unnotified, so a user who is mentioned in a post
+// they cannot see yet is told once an edit lets them see it.
+func (s *MentionService) notify(mentions []model.Mention, err error) error {
+	if err != nil {
+		return err
+	}
+	for _, m := range mentions {
+		if m.UserID == m.AuthorID {
+			continue
+		}
+		visible, err := s.Repo.CanSee(m)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			continue
+		}
+		if err := s.Notifications.Notify(&model.Notification{
+			UserID:    m.UserID,
+			Type:      model.NotificationMention,
+			ActorID:   m.AuthorID,
+			MentionID: m.ID,
+			PostID:    m.PostID,
+			CommentID: m.CommentID,
+			MessageID: m.MessageID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Suggest returns users whose nickname starts with prefix, with an optional leading @, for the viewer
+// to mention, people the viewer follows first. Prefixes that cannot start a nickname give no users.
+func (s *MentionService) Suggest(viewerID, prefix string, limit int) ([]model.MentionSuggestion, error) {
+	if limit < 1 {
+		limit = DefaultMentionSuggestions
+	}
+	prefix = strings.TrimPrefix(prefix, "@")
+	if !mention.ValidPrefix(prefix) {
+		return []model.MentionSuggestion{}, nil
+	}
+	return s.Repo.Suggest(viewerID, prefix, min(limit, MaxMentionSuggestions))
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newMentionTestService(t *testing.T) (*MentionService, *sql.DB, *[]model.Notification) {
+	t.Helper()
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "alice", "bob", "Bob", "carol"} {
+		insertTestUser(t, db, id, id+"-"+time.Now().Format("150405.000000000")+"@example.com")
+	}
+	mustExec(t, db, `UPDATE users SET nickname = id`)
+	mustExec(t, db, `UPDATE users SET nickname = 'Carol_C' WHERE id = 'carol'`)
+	mustExec(t, db, `INSERT INTO followers (follower_id, followed_id, status) VALUES ('author', 'carol', 'accepted'), ('bob', 'author', 'accepted')`)
+
+	var pushed []model.Notification
+	notifications := NewNotificationService(repository.NewNotificationRepository(db))
+	notifications.Push = func(n model.Notification) { pushed = append(pushed, n) }
+	return NewMentionService(repository.NewMentionRepository(db), notifications), db, &pushed
+}
+
+func TestPostMentions(t *testing.T) {
+	s, db, pushed := newMentionTestService(t)
+	posts := repository.NewPostRepository(db)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	post := &model.Post{Id: "post-1", UserId: "author", Title: "Weekend plans",
+		Content: "With @ALICE, @Bob, @carol_c and @author, not @nobody", Visibility: "private",
+		AllowedFollowers: []string{"bob"}, CreatedAt: now}
+	if err := posts.Create(post); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+	found, err := posts.FindByID("post-1")
+	if err != nil {
+		t.Fatalf("FindByID() failed: %v", err)
+	}
+	mentioned := map[string]string{}
+	for _, m := range found.Mentions {
+		mentioned[m.Nickname] = m.UserID
+	}
+	// the exact case wins between bob and Bob, a single match is taken whatever its case
+	want := map[string]string{"ALICE": "alice", "Bob": "Bob", "carol_c": "carol", "author": "author"}
+	if len(mentioned) != len(want) {
+		t.Fatalf("expected mentions %v, got %v", want, mentioned)
+	}
+	for nickname, userID := range want {
+		if mentioned[nickname] != userID {
+			t.Errorf("expected @%s to mention %s, got %q", nickname, userID, mentioned[nickname])
+		}
+	}
+
+	if err := s.NotifyPost("post-1"); err != nil {
+		t.Fatalf("NotifyPost() failed: %v", err)
+	}
+	// only bob was picked to see the private post, and he is not the Bob mentioned
+	if len(*pushed) != 0 {
+		t.Fatalf("expected nobody who can see the post to be mentioned, got %+v", *pushed)
+	}
+
+	// opening the post up notifies the users mentioned before, once
+	edited := now.Add(time.Hour)
+	found.Visibility, found.AllowedFollowers, found.UpdatedAt = "public", nil, &edited
+	if err := posts.Update(found, &model.PostEdit{ID: "edit-1", PostID: "post-1", EditedAt: edited}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.NotifyPost("post-1"); err != nil {
+			t.Fatalf("NotifyPost() failed: %v", err)
+		}
+	}
+	notified := map[string]bool{}
+	for _, n := range *pushed {
+		if n.Type != model.NotificationMention || n.ActorID != "author" || n.PostID != "post-1" || n.ActorName != "Test User" {
+			t.Errorf("unexpected notification %+v", n)
+		}
+		notified[n.UserID] = true
+	}
+	if len(*pushed) != 3 || !notified["alice"] || !notified["Bob"] || !notified["carol"] {
+		t.Errorf("expected alice, Bob and carol to be notified once, got %+v", *pushed)
+	}
+
+	// a user dropped from the text loses the mention and its notification
+	found.Content, found.UpdatedAt = "Only @alice now", &edited
+	if err := posts.Update(found, &model.PostEdit{ID: "edit-2", PostID: "post-1", EditedAt: edited}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM notifications`); n != 1 {
+		t.Errorf("expected only alice's notification to be left, got %d", n)
+	}
+
+	list, unread, err := s.Notifications.List("alice", 0)
+	if err != nil || len(list) != 1 || unread != 1 {
+		t.Fatalf("expected one unread notification, got %v, %d, %v", list, unread, err)
+	}
+	if marked, err := s.Notifications.MarkRead("bob", nil); err != nil || marked != 0 {
+		t.Errorf("expected nothing to mark for someone else, got %d, %v", marked, err)
+	}
+	if marked, err := s.Notifications.MarkRead("alice", []string{list[0].ID}); err != nil || marked != 1 {
+		t.Errorf("expected the notification to be marked read, got %d, %v", marked, err)
+	}
+	if list, unread, _ := s.Notifications.List("alice", 0); unread != 0 || list[0].ReadAt == nil {
+		t.Errorf("expected no unread notifications, got %d", unread)
+	}
+}
+
+func TestCommentAndMessageMentions(t *testing.T) {
+	s, db, pushed := newMentionTestService(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility) VALUES ('friends', 'author', 'T', 'C', 'almostprivate')`)
+	mustExec(t, db, `INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at) VALUES ('comment-1', 'friends', 'author', '@bob @alice', ?, ?)`, now, now)
+	mustExec(t, db, `INSERT INTO messages (id, sender_id, receiver_id, content) VALUES ('message-1', 'author', 'alice', '@alice tell @carol_c')`)
+
+	tx, _ := db.Begin()
+	if err := repository.InsertCommentMentions(tx, "friends", "comment-1", "author", "@bob @alice", now); err != nil {
+		t.Fatalf("InsertCommentMentions() failed: %v", err)
+	}
+	if err := repository.InsertMessageMentions(tx, "message-1", "author", "@alice tell @carol_c", now); err != nil {
+		t.Fatalf("InsertMessageMentions() failed: %v", err)
+	}
+	tx.Commit()
+
+	if err := s.NotifyComment("comment-1"); err != nil {
+		t.Fatalf("NotifyComment() failed: %v", err)
+	}
+	if err := s.NotifyMessage("message-1"); err != nil {
+		t.Fatalf("NotifyMessage() failed: %v", err)
+	}
+	// bob follows the author so sees the post, alice does not; carol is mentioned in a chat she is not part of
+	if len(*pushed) != 2 || (*pushed)[0].UserID != "bob" || (*pushed)[0].CommentID != "comment-1" ||
+		(*pushed)[1].UserID != "alice" || (*pushed)[1].MessageID != "message-1" {
+		t.Errorf("expected bob's comment and alice's message notifications, got %+v", *pushed)
+	}
+
+	mentions, err := repository.FindMessageMentions(db, []string{"message-1"})
+	if err != nil || len(mentions["message-1"]) != 2 {
+		t.Errorf("expected both mentions to be kept for rendering, got %v, %v", mentions, err)
+	}
+}
+
+func TestMentionSuggestions(t *testing.T) {
+	s, db, _ := newMentionTestService(t)
+	mustExec(t, db, `UPDATE users SET nickname = 'bobby' WHERE id = 'bob'`)
+
+	suggestions, err := s.Suggest("author", "@B", 0)
+	if err != nil {
+		t.Fatalf("Suggest() failed: %v", err)
+	}
+	var got []string
+	for _, user := range suggestions {
+		got = append(got, user.Nickname)
+	}
+	// bobby follows the author, Bob does not
+	if len(got) != 2 || got[0] != "bobby" || got[1] != "Bob" {
+		t.Errorf("expected followers first, got %v", got)
+	}
+
+	suggestions, _ = s.Suggest("author", "c", 0)
+	if len(suggestions) != 1 || !suggestions[0].Following {
+		t.Errorf("expected the followed user, got %+v", suggestions)
+	}
+	// LIKE wildcards are matched literally
+	for _, prefix := range []string{"", "%", "a_"} {
+		if suggestions, _ := s.Suggest("author", prefix, 0); len(suggestions) != 0 {
+			t.Errorf("expected no match for %q, got %+v", prefix, suggestions)
+		}
+	}
+	if suggestions, _ := s.Suggest("author", "aut", 0); len(suggestions) != 0 {
+		t.Errorf("expected the user asking to be left out, got %+v", suggestions)
+	}
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+)
+
+// Constants controlling reports and the moderation queue
+const (
+	MaxReportDetailsLength  = 1000
+	MaxResolutionNoteLength = 1000
+	ReportPreviewLength     = 200
+	DefaultReportPageSize   = 50
+	MaxReportPageSize       = 200
+)
+
+// Errors returned by ModerationService so handlers can map them to status codes
+var (
+	ErrInvalidReportTarget  = errors.New("target type must be one of post, comment, message, group or user")
+	ErrInvalidReportReason  = errors.New("reason must be one of spam, harassment, hate, violence, nudity, misinformation or other")
+	ErrReportTooLong        = errors.New("details and notes must be at most 1000 characters")
+	ErrReportTargetNotFound = errors.New("reported content not found")
+	ErrCannotReportSelf     = errors.New("you cannot report yourself or your own content")
+	ErrAlreadyReported      = errors.New("you have already reported this")
+	ErrReportNotFound       = errors.New("report not found")
+	ErrReportClaimed        = errors.New("report is claimed by another moderator")
+	ErrReportResolved       = errors.New("report is already resolved")
+	ErrInvalidResolution    = errors.New("this resolution does not apply to the report")
+)
+
+// ModerationService takes reports from users and lets moderators work through them.
+// Resolving a report resolves every other open report about the same target with it.
+type ModerationService struct {
+	Repo  *repository.ReportRepository
+	Admin *AdminService    // Used to suspend authors, with the same rank rules as the admin API
+	Now   func() time.Time // Clock used for report timestamps, defaults to time.Now
+}
+
+// NewModerationService creates and returns a new instance of ModerationService.
+func NewModerationService(repo *repository.ReportRepository, admin *AdminService) *ModerationService {
+	return &ModerationService{Repo: repo, Admin: admin, Now: time.Now}
+}
+
+func (s *ModerationService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Report files a report about a piece of content or a user.
+func (s *ModerationService) Report(reporterID, targetType, targetID, reason, details string) (*model.Report, error) {
+	details = strings.TrimSpace(details)
+	switch {
+	case !slices.Contains([]string{model.ReportTargetPost, model.ReportTargetComment, model.ReportTargetMessage, model.ReportTargetGroup, model.ReportTargetUser}, targetType):
+		return nil, ErrInvalidReportTarget
+	case !slices.Contains(model.ReportReasons, reason):
+		return nil, ErrInvalidReportReason
+	case len(details) > MaxReportDetailsLength:
+		return nil, ErrReportTooLong
+	}
+
+	target, err := s.Repo.FindTarget(targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	// a message can only be reported by the people in the conversation
+	if target == nil || target.Hidden || (targetType == model.ReportTargetMessage && reporterID != target.AuthorID && reporterID != target.RecipientID) {
+		return nil, ErrReportTargetNotFound
+	}
+	if target.AuthorID == reporterID {
+		return nil, ErrCannotReportSelf
+	}
+
+	exists, err := s.Repo.HasUnresolved(reporterID, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrAlreadyReported
+	}
+
+	report := &model.Report{
+		ID:           utils.GenerateUUID(),
+		ReporterID:   reporterID,
+		TargetType:   targetType,
+		TargetID:     targetID,
+		TargetUserID: target.AuthorID,
+		Reason:       reason,
+		Details:      details,
+		Status:       model.ReportOpen,
+		CreatedAt:    s.now(),
+	}
+	if err := s.Repo.Create(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Queue lists reports for moderators, each with a preview of what was reported.
+func (s *ModerationService) Queue(filter repository.ReportFilter) ([]model.Report, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultReportPageSize
+	}
+	if filter.Limit > MaxReportPageSize {
+		filter.Limit = MaxReportPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	reports, err := s.Repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reports {
+		if err := s.attachTarget(&reports[i]); err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
+
+// Get returns one report with a preview of what was reported.
+func (s *ModerationService) Get(id string) (*model.Report, error) {
+	report, err := s.Repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	return report, s.attachTarget(report)
+}
+
+func (s *ModerationService) attachTarget(report *model.Report) error {
+	target, err := s.Repo.FindTarget(report.TargetType, report.TargetID)
+	if err != nil || target == nil {
+		return err
+	}
+	if preview := []rune(target.Preview); len(preview) > ReportPreviewLength {
+		target.Preview = string(preview[:ReportPreviewLength]) + "…"
+	}
+	report.Target = target
+	return nil
+}
+
+// Claim assigns a report to the moderator so others do not work on it at the same time.
+func (s *ModerationService) Claim(moderator *model.User, id string) (*model.Report, error) {
+	claimed, err := s.Repo.Claim(id, moderator.ID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		switch {
+		case report.Status == model.ReportResolved:
+			return nil, ErrReportResolved
+		case report.ClaimedBy != moderator.ID:
+			return nil, ErrReportClaimed
+		}
+	}
+	return report, nil
+}
+
+// Release hands a claimed report back to the queue.
+func (s *ModerationService) Release(moderator *model.User, id string) (*model.Report, error) {
+	released, err := s.Repo.Release(id, moderator.ID)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !released {
+		switch report.Status {
+		case model.ReportResolved:
+			return nil, ErrReportResolved
+		case model.ReportClaimed:
+			return nil, ErrReportClaimed
+		}
+	}
+	return report, nil
+}
+
+// Resolve acts on a report. The report must be open or claimed by the moderator.
+// Suspensions last suspendFor, or until reinstated if it is zero.
+func (s *ModerationService) Resolve(moderator *model.User, id, resolution, note string, suspendFor time.Duration) (*model.Report, error) {
+	note = strings.TrimSpace(note)
+	if len(note) > MaxResolutionNoteLength {
+		return nil, ErrReportTooLong
+	}
+
+	report, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case report.Status == model.ReportResolved:
+		return nil, ErrReportResolved
+	case report.Status == model.ReportClaimed && report.ClaimedBy != moderator.ID:
+		return nil, ErrReportClaimed
+	}
+
+	now := s.now()
+	switch resolution {
+	case model.ResolutionDismiss:
+
+	case model.ResolutionHide:
+		if report.TargetType == model.ReportTargetUser {
+			return nil, ErrInvalidResolution
+		}
+		// content deleted since it was reported needs no hiding
+		if _, err := s.Repo.HideTarget(report.TargetType, report.TargetID, now); err != nil {
+			return nil, err
+		}
+
+	case model.ResolutionWarn:
+		if report.TargetUserID == "" {
+			return nil, ErrInvalidResolution
+		}
+		reason := note
+		if reason == "" {
+			reason = "Your " + report.TargetType + " was reported for " + report.Reason
+		}
+		warning := &model.UserWarning{
+			ID:        utils.GenerateUUID(),
+			UserID:    report.TargetUserID,
+			ReportID:  report.ID,
+			Reason:    reason,
+			CreatedAt: now,
+		}
+		if err := s.Repo.CreateWarning(warning, moderator.ID); err != nil {
+			return nil, err
+		}
+
+	case model.ResolutionSuspend:
+		if report.TargetUserID == "" {
+			return nil, ErrInvalidResolution
+		}
+		reason := note
+		if reason == "" {
+			reason = "Reported for " + report.Reason
+		}
+		if _, err := s.Admin.Suspend(moderator, report.TargetUserID, reason, suspendFor); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, ErrInvalidResolution
+	}
+
+	if _, err := s.Repo.ResolveTarget(report.TargetType, report.TargetID, resolution, note, moderator.ID, now); err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// Warnings lists the warnings issued to a user.
+func (s *ModerationService) Warnings(userID string) ([]model.UserWarning, error) {
+	return s.Repo.FindWarningsByUserID(userID)
+}
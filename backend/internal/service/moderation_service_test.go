@@ -0,0 +1,136 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newModerationTestService(t *testing.T) *ModerationService {
+	t.Helper()
+	db := dbtest.New(t)
+	users := []struct{ id, email, role string }{
+		{"mod-1", "mod@example.com", model.RoleModerator},
+		{"mod-2", "mod2@example.com", model.RoleModerator},
+		{"user-1", "jane@example.com", model.RoleUser},
+		{"user-2", "john@example.com", model.RoleUser},
+		{"user-3", "kim@example.com", model.RoleUser},
+	}
+	for _, u := range users {
+		insertTestUser(t, db, u.id, u.email)
+		mustExec(t, db, `UPDATE users SET role = ?, imgurl = '', nickname = '', about = '' WHERE id = ?`, u.role, u.id)
+	}
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, post_image) VALUES ('post-1', 'user-1', 'Deal', 'Cheap watches', 'public', '')`)
+	mustExec(t, db, `INSERT INTO messages (id, sender_id, receiver_id, content) VALUES ('message-1', 'user-1', 'user-2', 'Buy now')`)
+
+	return NewModerationService(repository.NewReportRepository(db), NewAdminService(repository.NewAdminRepository(db)))
+}
+
+func TestReportValidation(t *testing.T) {
+	s := newModerationTestService(t)
+
+	if _, err := s.Report("user-2", "photo", "post-1", "spam", ""); err != ErrInvalidReportTarget {
+		t.Errorf("expected an unknown target type to be rejected, got %v", err)
+	}
+	if _, err := s.Report("user-2", model.ReportTargetPost, "post-1", "boring", ""); err != ErrInvalidReportReason {
+		t.Errorf("expected an unknown reason to be rejected, got %v", err)
+	}
+	if _, err := s.Report("user-2", model.ReportTargetPost, "missing", "spam", ""); err != ErrReportTargetNotFound {
+		t.Errorf("expected a missing post to be rejected, got %v", err)
+	}
+	if _, err := s.Report("user-1", model.ReportTargetPost, "post-1", "spam", ""); err != ErrCannotReportSelf {
+		t.Errorf("expected authors not to report their own post, got %v", err)
+	}
+	if _, err := s.Report("user-3", model.ReportTargetMessage, "message-1", "spam", ""); err != ErrReportTargetNotFound {
+		t.Errorf("expected outsiders not to report a private message, got %v", err)
+	}
+	if _, err := s.Report("user-2", model.ReportTargetMessage, "message-1", "spam", ""); err != nil {
+		t.Errorf("expected the recipient to report a message, got %v", err)
+	}
+
+	report, err := s.Report("user-2", model.ReportTargetPost, "post-1", "spam", " Bot account ")
+	if err != nil {
+		t.Fatalf("Report() failed: %v", err)
+	}
+	if report.TargetUserID != "user-1" || report.Details != "Bot account" || report.Status != model.ReportOpen {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if _, err := s.Report("user-2", model.ReportTargetPost, "post-1", "harassment", ""); err != ErrAlreadyReported {
+		t.Errorf("expected a second open report to be rejected, got %v", err)
+	}
+}
+
+func TestModerationClaimAndHide(t *testing.T) {
+	s := newModerationTestService(t)
+	first, _ := s.Report("user-2", model.ReportTargetPost, "post-1", "spam", "")
+	second, _ := s.Report("user-3", model.ReportTargetPost, "post-1", "other", "")
+	mod1 := &model.User{ID: "mod-1", Role: model.RoleModerator}
+	mod2 := &model.User{ID: "mod-2", Role: model.RoleModerator}
+
+	report, err := s.Claim(mod1, first.ID)
+	if err != nil || report.ClaimedBy != "mod-1" || report.Target == nil || report.Target.Preview != "Deal: Cheap watches" {
+		t.Fatalf("expected mod-1 to claim the report with a preview, got %+v, %v", report, err)
+	}
+	if _, err := s.Claim(mod2, first.ID); err != ErrReportClaimed {
+		t.Errorf("expected a claimed report not to be claimed again, got %v", err)
+	}
+	if _, err := s.Resolve(mod2, first.ID, model.ResolutionDismiss, "", 0); err != ErrReportClaimed {
+		t.Errorf("expected only the claiming moderator to resolve, got %v", err)
+	}
+	if _, err := s.Resolve(mod1, first.ID, "delete", "", 0); err != ErrInvalidResolution {
+		t.Errorf("expected an unknown resolution to be rejected, got %v", err)
+	}
+
+	report, err = s.Resolve(mod1, first.ID, model.ResolutionHide, "Spam", 0)
+	if err != nil || report.Status != model.ReportResolved || !report.Target.Hidden {
+		t.Fatalf("expected the report to be resolved and the post hidden, got %+v, %v", report, err)
+	}
+	if sibling, _ := s.Get(second.ID); sibling.Status != model.ReportResolved || sibling.Resolution != model.ResolutionHide {
+		t.Errorf("expected the other report on the post to be resolved with it, got %+v", sibling)
+	}
+	if _, err := s.Claim(mod2, first.ID); err != ErrReportResolved {
+		t.Errorf("expected a resolved report not to be claimed, got %v", err)
+	}
+
+	posts, err := repository.GetPosts("user-2", s.Repo.DB, nil, 20)
+	if err != nil || len(*posts) != 0 {
+		t.Errorf("expected the hidden post to be left out of the feed, got %+v, %v", posts, err)
+	}
+	if _, err := s.Report("user-2", model.ReportTargetPost, "post-1", "spam", ""); err != ErrReportTargetNotFound {
+		t.Errorf("expected hidden content not to be reported again, got %v", err)
+	}
+}
+
+func TestModerationWarnAndSuspend(t *testing.T) {
+	s := newModerationTestService(t)
+	moderator := &model.User{ID: "mod-1", Role: model.RoleModerator}
+
+	warned, _ := s.Report("user-2", model.ReportTargetUser, "user-1", "harassment", "")
+	if _, err := s.Resolve(moderator, warned.ID, model.ResolutionHide, "", 0); err != ErrInvalidResolution {
+		t.Errorf("expected users not to be hidden, got %v", err)
+	}
+	if _, err := s.Resolve(moderator, warned.ID, model.ResolutionWarn, "", 0); err != nil {
+		t.Fatalf("Resolve(warn) failed: %v", err)
+	}
+	warnings, err := s.Warnings("user-1")
+	if err != nil || len(warnings) != 1 || warnings[0].ReportID != warned.ID {
+		t.Errorf("expected user-1 to have one warning, got %+v, %v", warnings, err)
+	}
+
+	peer, _ := s.Report("user-2", model.ReportTargetUser, "mod-2", "harassment", "")
+	if _, err := s.Resolve(moderator, peer.ID, model.ResolutionSuspend, "", 0); err != ErrCannotManageUser {
+		t.Errorf("expected a moderator not to suspend another moderator, got %v", err)
+	}
+
+	suspended, _ := s.Report("user-3", model.ReportTargetPost, "post-1", "spam", "")
+	if _, err := s.Resolve(moderator, suspended.ID, model.ResolutionSuspend, "", 24*time.Hour); err != nil {
+		t.Fatalf("Resolve(suspend) failed: %v", err)
+	}
+	user, err := s.Admin.User("user-1")
+	if err != nil || user.Suspension == nil || user.Suspension.Reason != "Reported for spam" {
+		t.Errorf("expected the post's author to be suspended, got %+v, %v", user, err)
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+)
+
+// Limits of the notifications list
+const (
+	DefaultNotifications = 20
+	MaxNotifications     = 100
+)
+
+// NotificationService creates notifications, pushes them to users who are online and lists them
+type NotificationService struct {
+	Repo *repository.NotificationRepository
+	Push func(model.Notification) // Delivers a new notification right away, e.g. over a WebSocket; optional
+	Now  func() time.Time         // Clock used for created_at and read_at, defaults to time.Now
+}
+
+// NewNotificationService creates and returns a new instance of NotificationService.
+func NewNotificationService(repo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{Repo: repo, Now: time.Now}
+}
+
+func (s *NotificationService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Notify saves n, giving it an ID and creation time, and pushes it to its user.
+func (s *NotificationService) Notify(n *model.Notification) error {
+	n.ID = utils.GenerateUUID()
+	n.CreatedAt = s.now()
+	if err := s.Repo.Create(n); err != nil {
+		return err
+	}
+	if s.Push != nil {
+		s.Push(*n)
+	}
+	return nil
+}
+
+// List returns up to limit of the user's notifications, newest first, and how many are unread.
+func (s *NotificationService) List(userID string, limit int) ([]model.Notification, int, error) {
+	if limit < 1 {
+		limit = DefaultNotifications
+	}
+	notifications, err := s.Repo.FindByUser(userID, min(limit, MaxNotifications))
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.Repo.CountUnread(userID)
+	return notifications, unread, err
+}
+
+// MarkRead marks the user's notifications with the given IDs as read, or all of them when ids is empty.
+func (s *NotificationService) MarkRead(userID string, ids []string) (int64, error) {
+	return s.Repo.MarkRead(userID, ids, s.now())
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/getusers"
+	"backend/pkg/oidc"
+)
+
+// Constants controlling sign in with external providers
+const (
+	OIDCLoginStateTTL   = 10 * time.Minute // How long the user has to finish authenticating at the provider
+	OIDCSignupTTL       = 30 * time.Minute // How long a new user has to complete their profile
+	oidcSignupTokenSize = 32
+)
+
+// Errors returned by OIDCService so handlers can map them to status codes
+var (
+	ErrUnknownOIDCProvider  = errors.New("unknown sign-in provider")
+	ErrOIDCStateInvalid     = errors.New("sign-in request expired or invalid, please try again")
+	ErrOIDCEmailNotVerified = errors.New("the provider did not confirm your email address is verified")
+	ErrOIDCSignupNotFound   = errors.New("sign-up expired, please sign in with the provider again")
+)
+
+// OIDCLoginResult is the outcome of a completed provider login. Exactly one of UserID and
+// Signup is set: the account to log in, or the profile a new user still has to complete.
+type OIDCLoginResult struct {
+	UserID      string
+	Signup      *model.OIDCPendingSignup
+	SignupToken string
+}
+
+// OIDCService signs users in through external OIDC providers.
+//
+// Identities are matched by the provider's subject first. An unknown identity is linked to the
+// account with the same email only if the provider says the email is verified; otherwise it
+// would let anyone who controls a provider account with a victim's address take over their
+// account. Without a matching account a pending signup is created, because providers do not
+// share the date of birth needed for the MinAge check.
+type OIDCService struct {
+	Repo      *repository.OIDCRepository
+	Users     *UserService
+	Providers map[string]oidc.Provider
+	Now       func() time.Time // Clock used for expiry, defaults to time.Now
+}
+
+// NewOIDCService creates and returns a new instance of OIDCService.
+func NewOIDCService(repo *repository.OIDCRepository, users *UserService, providers []oidc.Provider) *OIDCService {
+	byName := make(map[string]oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &OIDCService{Repo: repo, Users: users, Providers: byName, Now: time.Now}
+}
+
+func (s *OIDCService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// ProviderNames returns the configured providers in alphabetical order.
+func (s *OIDCService) ProviderNames() []string {
+	names := make([]string, 0, len(s.Providers))
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin starts an authorization code flow with PKCE and returns the provider URL to
+// redirect to and the state the callback must present.
+func (s *OIDCService) BeginLogin(providerName string) (string, string, error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownOIDCProvider
+	}
+
+	state, err := oidc.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		return "", "", err
+	}
+
+	now := s.now()
+	// abandoned logins and signups are cleaned up here rather than by a separate job
+	if err := s.Repo.PurgeExpired(now); err != nil {
+		return "", "", err
+	}
+	err = s.Repo.CreateLoginState(&model.OIDCLoginState{
+		ID:           hashToken(state),
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(OIDCLoginStateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// CompleteLogin handles the provider's callback. The state is consumed whether or not the login succeeds.
+func (s *OIDCService) CompleteLogin(ctx context.Context, providerName, state, code string) (*OIDCLoginResult, error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+
+	pending, err := s.Repo.TakeLoginState(hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if pending == nil || pending.Provider != providerName || !now.Before(pending.ExpiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	identity, err := provider.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.Repo.FindIdentity(providerName, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		if err := s.Repo.TouchIdentity(linked.ID, now); err != nil {
+			return nil, err
+		}
+		return &OIDCLoginResult{UserID: linked.UserID}, nil
+	}
+
+	email := strings.TrimSpace(strings.ToLower(identity.Email))
+	if email == "" || !identity.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := getusers.GetUserByEmail(s.Repo.DB, email)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		err = s.Repo.LinkIdentity(&model.UserIdentity{
+			UserID:      user.ID,
+			Provider:    providerName,
+			Subject:     identity.Subject,
+			Email:       email,
+			CreatedAt:   now,
+			LastLoginAt: &now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &OIDCLoginResult{UserID: user.ID}, nil
+	}
+
+	buf := make([]byte, oidcSignupTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	signup := &model.OIDCPendingSignup{
+		ID:        hashToken(token),
+		Provider:  providerName,
+		Subject:   identity.Subject,
+		Email:     email,
+		FirstName: identity.GivenName,
+		LastName:  identity.FamilyName,
+		Picture:   identity.Picture,
+		ExpiresAt: now.Add(OIDCSignupTTL),
+	}
+	if signup.FirstName == "" && signup.LastName == "" {
+		signup.FirstName, signup.LastName, _ = strings.Cut(identity.Name, " ")
+	}
+	if err := s.Repo.CreatePendingSignup(signup); err != nil {
+		return nil, err
+	}
+	return &OIDCLoginResult{Signup: signup, SignupToken: token}, nil
+}
+
+// PendingSignup returns the profile a signup token was issued for.
+func (s *OIDCService) PendingSignup(token string) (*model.OIDCPendingSignup, error) {
+	signup, err := s.Repo.FindPendingSignup(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if signup == nil || !s.now().Before(signup.ExpiresAt) {
+		return nil, ErrOIDCSignupNotFound
+	}
+	return signup, nil
+}
+
+// CompleteSignup creates the account for a pending signup from the fields in profile, which must
+// include the date of birth. Names left empty fall back to those the provider supplied. On
+// validation errors the signup stays pending so the user can correct the form.
+func (s *OIDCService) CompleteSignup(token string, profile *model.User) (*RegistrationErrors, error) {
+	signup, err := s.PendingSignup(token)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Email = signup.Email
+	if strings.TrimSpace(profile.FirstName) == "" {
+		profile.FirstName = signup.FirstName
+	}
+	if strings.TrimSpace(profile.LastName) == "" {
+		profile.LastName = signup.LastName
+	}
+
+	validationErrors, err := s.Users.RegisterExternalUser(profile)
+	if validationErrors != nil || err != nil {
+		return validationErrors, err
+	}
+
+	now := s.now()
+	err = s.Repo.LinkIdentity(&model.UserIdentity{
+		UserID:      profile.ID,
+		Provider:    signup.Provider,
+		Subject:     signup.Subject,
+		Email:       signup.Email,
+		CreatedAt:   now,
+		LastLoginAt: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nil, s.Repo.DeletePendingSignup(signup.ID)
+}
+
+// Identities returns the external identities linked to a user.
+func (s *OIDCService) Identities(userID string) ([]model.UserIdentity, error) {
+	return s.Repo.FindIdentitiesByUserID(userID)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/oidc"
+	"backend/pkg/oidc/oidctest"
+)
+
+func newOIDCTestService(t *testing.T) (*OIDCService, *oidctest.Issuer) {
+	t.Helper()
+	db := dbtest.New(t)
+	_, err := db.Exec(`INSERT INTO users (id, email, fname, lname, dob, password, imgurl, nickname, about) VALUES ('user-1', 'jane@example.com', 'Jane', 'Doe', '2000-01-01', 'hash', '', '', '')`)
+	if err != nil {
+		t.Fatalf("failed to insert test user: %v", err)
+	}
+
+	issuer := oidctest.NewIssuer("social-network")
+	t.Cleanup(issuer.Close)
+	provider := oidc.NewClient(oidc.Config{
+		Name:        "mock",
+		Issuer:      issuer.URL,
+		ClientID:    issuer.ClientID,
+		RedirectURL: "http://localhost:8080/api/auth/oidc/mock/callback",
+		HTTPClient:  issuer.Client(),
+	})
+
+	s := NewOIDCService(repository.NewOIDCRepository(db), &UserService{Repo: &repository.UserRepository{DB: db}}, []oidc.Provider{provider})
+	return s, issuer
+}
+
+// login runs the whole redirect dance for identity and returns the callback's state and code
+func login(t *testing.T, s *OIDCService, issuer *oidctest.Issuer, identity oidc.Identity) (string, string) {
+	t.Helper()
+	authURL, state, err := s.BeginLogin("mock")
+	if err != nil {
+		t.Fatalf("BeginLogin() failed: %v", err)
+	}
+	callback, err := issuer.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("Authorize() failed: %v", err)
+	}
+	u, _ := url.Parse(callback)
+	if u.Query().Get("state") != state {
+		t.Fatalf("expected state %q in callback, got %q", state, u.Query().Get("state"))
+	}
+	return state, u.Query().Get("code")
+}
+
+func TestOIDCLinksExistingUserByVerifiedEmail(t *testing.T) {
+	s, issuer := newOIDCTestService(t)
+	ctx := context.Background()
+
+	state, code := login(t, s, issuer, oidc.Identity{Subject: "sub-1", Email: "Jane@Example.com", EmailVerified: false})
+	if _, err := s.CompleteLogin(ctx, "mock", state, code); err != ErrOIDCEmailNotVerified {
+		t.Fatalf("expected an unverified email not to be linked, got %v", err)
+	}
+
+	state, code = login(t, s, issuer, oidc.Identity{Subject: "sub-1", Email: "Jane@Example.com", EmailVerified: true})
+	result, err := s.CompleteLogin(ctx, "mock", state, code)
+	if err != nil {
+		t.Fatalf("CompleteLogin() failed: %v", err)
+	}
+	if result.UserID != "user-1" || result.Signup != nil {
+		t.Fatalf("expected to log in as user-1, got %+v", result)
+	}
+
+	// once linked, the subject is enough even if the provider email changes
+	state, code = login(t, s, issuer, oidc.Identity{Subject: "sub-1", Email: "new@example.com"})
+	result, err = s.CompleteLogin(ctx, "mock", state, code)
+	if err != nil || result.UserID != "user-1" {
+		t.Fatalf("expected the linked identity to log in as user-1, got %+v, %v", result, err)
+	}
+
+	if _, err := s.CompleteLogin(ctx, "mock", state, code); err != ErrOIDCStateInvalid {
+		t.Errorf("expected a reused state to be rejected, got %v", err)
+	}
+
+	identities, _ := s.Identities("user-1")
+	if len(identities) != 1 || identities[0].Provider != "mock" {
+		t.Errorf("expected one linked identity, got %+v", identities)
+	}
+}
+
+func TestOIDCSignupRequiresDateOfBirth(t *testing.T) {
+	s, issuer := newOIDCTestService(t)
+	ctx := context.Background()
+
+	state, code := login(t, s, issuer, oidc.Identity{Subject: "sub-2", Email: "sam@example.com", EmailVerified: true, Name: "Sam Smith"})
+	result, err := s.CompleteLogin(ctx, "mock", state, code)
+	if err != nil {
+		t.Fatalf("CompleteLogin() failed: %v", err)
+	}
+	if result.Signup == nil || result.SignupToken == "" {
+		t.Fatalf("expected a pending signup, got %+v", result)
+	}
+	if result.Signup.FirstName != "Sam" || result.Signup.LastName != "Smith" {
+		t.Errorf("expected names split from the name claim, got %q %q", result.Signup.FirstName, result.Signup.LastName)
+	}
+
+	tooYoung := time.Now().AddDate(-(MinAge - 1), 0, 0)
+	validationErrors, err := s.CompleteSignup(result.SignupToken, &model.User{DOB: tooYoung})
+	if err != nil || validationErrors == nil || validationErrors.DateOfBirth == "" {
+		t.Fatalf("expected a date of birth validation error, got %+v, %v", validationErrors, err)
+	}
+
+	profile := &model.User{DOB: time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)}
+	validationErrors, err = s.CompleteSignup(result.SignupToken, profile)
+	if err != nil || validationErrors != nil {
+		t.Fatalf("CompleteSignup() failed: %+v, %v", validationErrors, err)
+	}
+	if profile.ID == "" || profile.Email != "sam@example.com" {
+		t.Errorf("expected the account to be created from the pending signup, got %+v", profile)
+	}
+
+	if _, err := s.CompleteSignup(result.SignupToken, &model.User{DOB: profile.DOB}); err != ErrOIDCSignupNotFound {
+		t.Errorf("expected the signup token to be single use, got %v", err)
+	}
+
+	state, code = login(t, s, issuer, oidc.Identity{Subject: "sub-2"})
+	result, err = s.CompleteLogin(ctx, "mock", state, code)
+	if err != nil || result.UserID != profile.ID {
+		t.Errorf("expected the new account to be linked, got %+v, %v", result, err)
+	}
+}
+
+func TestOIDCLoginStateExpires(t *testing.T) {
+	s, issuer := newOIDCTestService(t)
+	clock := time.Now()
+	s.Now = func() time.Time { return clock }
+
+	state, code := login(t, s, issuer, oidc.Identity{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true})
+	clock = clock.Add(OIDCLoginStateTTL)
+	if _, err := s.CompleteLogin(context.Background(), "mock", state, code); err != ErrOIDCStateInvalid {
+		t.Errorf("expected an expired state to be rejected, got %v", err)
+	}
+
+	if _, _, err := s.BeginLogin("unknown"); err != ErrUnknownOIDCProvider {
+		t.Errorf("expected ErrUnknownOIDCProvider, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// Errors returned by PollService so handlers can map them to status codes
+var (
+	ErrPollNotFound = errors.New("poll not found")
+	ErrPollClosed   = errors.New("the poll is closed")
+	ErrAlreadyVoted = errors.New("you already voted in this poll")
+	ErrNotVoted     = errors.New("vote not found")
+	ErrInvalidVote  = errors.New("choose one option of the poll, or several different ones if it is multiple choice")
+)
+
+// PollService records the votes of the users who can see a post in its poll
+type PollService struct {
+	Repo *repository.PollRepository
+	Now  func() time.Time // Clock used for created_at and to close polls, defaults to time.Now
+}
+
+// NewPollService creates and returns a new instance of PollService.
+func NewPollService(repo *repository.PollRepository) *PollService {
+	return &PollService{Repo: repo, Now: time.Now}
+}
+
+func (s *PollService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Poll returns the poll of a post the viewer can see, with the results the viewer may see.
+func (s *PollService) Poll(viewerID, postID string) (*model.Poll, error) {
+	visible, err := repository.PostVisibleTo(s.Repo.DB, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrPostNotFound
+	}
+	poll, err := s.Repo.FindPoll(postID, viewerID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if poll == nil {
+		return nil, ErrPollNotFound
+	}
+	return poll, nil
+}
+
+// Vote records the user's vote for options of an open poll, exactly one unless it is multiple choice, and
+// returns the poll with the new results. In a multiple choice poll the user may add options to their vote later.
+func (s *PollService) Vote(userID, postID string, optionIDs []string) (*model.Poll, error) {
+	poll, err := s.Poll(userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.Closed {
+		return nil, ErrPollClosed
+	}
+	if len(optionIDs) == 0 || (!poll.MultipleChoice && len(optionIDs) > 1) {
+		return nil, ErrInvalidVote
+	}
+	for i, id := range optionIDs {
+		known := slices.ContainsFunc(poll.Options, func(o model.PollOption) bool { return o.ID == id })
+		if !known || slices.Contains(optionIDs[:i], id) {
+			return nil, ErrInvalidVote
+		}
+	}
+
+	voted, err := s.Repo.Vote(postID, userID, optionIDs, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if !voted {
+		return nil, ErrAlreadyVoted
+	}
+	return s.Poll(userID, postID)
+}
+
+// Unvote takes back the user's vote for an option of an open poll, or their whole vote when optionID is
+// empty, and returns the poll with the new results.
+func (s *PollService) Unvote(userID, postID, optionID string) (*model.Poll, error) {
+	poll, err := s.Poll(userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.Closed {
+		return nil, ErrPollClosed
+	}
+	removed, err := s.Repo.Unvote(postID, userID, optionID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, ErrNotVoted
+	}
+	return s.Poll(userID, postID)
+}
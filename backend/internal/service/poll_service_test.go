@@ -0,0 +1,158 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newPollTestService(t *testing.T) (*PollService, *sql.DB, *time.Time) {
+	t.Helper()
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "voter", "other", "stranger"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+
+	// the feed closes polls by the real time, so the clock starts from it
+	clock := time.Now().Truncate(time.Second)
+	closesAt := clock.Add(time.Hour)
+	posts := repository.NewPostRepository(db)
+	for _, post := range []*model.Post{
+		{Id: "lunch", Visibility: "public", Poll: &model.Poll{Options: []model.PollOption{
+			{ID: "pizza", Text: "Pizza"}, {ID: "sushi", Text: "Sushi"},
+		}}},
+		{Id: "trip", Visibility: "public", Poll: &model.Poll{MultipleChoice: true, HideResults: true, ClosesAt: &closesAt,
+			Options: []model.PollOption{{ID: "lake", Text: "Lake"}, {ID: "hills", Text: "Hills"}, {ID: "coast", Text: "Coast"}}}},
+		{Id: "secret", Visibility: "private", AllowedFollowers: []string{"voter"}, Poll: &model.Poll{Options: []model.PollOption{
+			{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"},
+		}}},
+	} {
+		post.UserId, post.Title, post.Content, post.CreatedAt = "author", "Question", "What do you think?", clock
+		if err := posts.Create(post); err != nil {
+			t.Fatalf("failed to create post %s: %v", post.Id, err)
+		}
+	}
+	mustExec(t, db, `INSERT INTO followers (follower_id, followed_id, status) VALUES ('voter', 'author', 'accepted')`)
+
+	s := NewPollService(repository.NewPollRepository(db))
+	s.Now = func() time.Time { return clock }
+	return s, db, &clock
+}
+
+func votes(poll *model.Poll) map[string]int {
+	counts := map[string]int{}
+	for _, option := range poll.Options {
+		counts[option.ID] = option.Votes
+	}
+	return counts
+}
+
+func TestPollVotes(t *testing.T) {
+	s, _, _ := newPollTestService(t)
+
+	poll, err := s.Vote("voter", "lunch", []string{"pizza"})
+	if err != nil {
+		t.Fatalf("Vote() failed: %v", err)
+	}
+	if !poll.Voted || !poll.Options[0].Voted || poll.Voters != 1 || votes(poll)["pizza"] != 1 {
+		t.Errorf("expected the vote to be counted, got %+v", poll)
+	}
+	if _, err := s.Vote("voter", "lunch", []string{"sushi"}); err != ErrAlreadyVoted {
+		t.Errorf("expected one vote per user, got %v", err)
+	}
+	for _, optionIDs := range [][]string{nil, {"pizza", "sushi"}, {"lake"}} {
+		if _, err := s.Vote("other", "lunch", optionIDs); err != ErrInvalidVote {
+			t.Errorf("Vote(%v): expected ErrInvalidVote, got %v", optionIDs, err)
+		}
+	}
+	if _, err := s.Vote("stranger", "secret", []string{"yes"}); err != ErrPostNotFound {
+		t.Errorf("expected polls of posts the user may not see not to be found, got %v", err)
+	}
+	if poll, err := s.Vote("voter", "secret", []string{"yes"}); err != nil || poll.Voters != 1 {
+		t.Errorf("expected the allowed follower to vote, got %+v, %v", poll, err)
+	}
+
+	if _, err := s.Vote("voter", "trip", []string{"lake", "lake"}); err != ErrInvalidVote {
+		t.Errorf("expected ErrInvalidVote, got %v", err)
+	}
+	if _, err := s.Vote("voter", "trip", []string{"lake", "coast"}); err != nil {
+		t.Fatalf("Vote() failed: %v", err)
+	}
+	if poll, err := s.Vote("voter", "trip", []string{"hills"}); err != nil || poll.Voters != 1 || votes(poll)["hills"] != 1 {
+		t.Errorf("expected options to be added to a multiple choice vote, got %+v, %v", poll, err)
+	}
+	if _, err := s.Vote("voter", "trip", []string{"lake"}); err != ErrAlreadyVoted {
+		t.Errorf("expected ErrAlreadyVoted, got %v", err)
+	}
+
+	poll, err = s.Unvote("voter", "trip", "coast")
+	if err != nil || votes(poll)["coast"] != 0 || !poll.Voted {
+		t.Errorf("expected the vote for one option to be taken back, got %+v, %v", poll, err)
+	}
+	if poll, err = s.Unvote("voter", "trip", ""); err != nil || poll.Voted || poll.Voters != 0 {
+		t.Errorf("expected the whole vote to be taken back, got %+v, %v", poll, err)
+	}
+	if _, err := s.Unvote("voter", "trip", ""); err != ErrNotVoted {
+		t.Errorf("expected ErrNotVoted, got %v", err)
+	}
+}
+
+func TestPollResults(t *testing.T) {
+	s, db, clock := newPollTestService(t)
+
+	if _, err := s.Vote("voter", "trip", []string{"lake"}); err != nil {
+		t.Fatalf("Vote() failed: %v", err)
+	}
+	// results stay hidden until the user votes, but not from the author
+	poll, err := s.Poll("other", "trip")
+	if err != nil || !poll.ResultsHidden || poll.Voters != 0 || votes(poll)["lake"] != 0 {
+		t.Errorf("expected the results to be hidden, got %+v, %v", poll, err)
+	}
+	if poll, _ := s.Poll("author", "trip"); poll.ResultsHidden || votes(poll)["lake"] != 1 {
+		t.Errorf("expected the author to see the results, got %+v", poll)
+	}
+
+	// feeds show the results too, including on reposts
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, repost_of) VALUES ('repost', 'voter', '', '', 'public', 'lunch')`)
+	if _, err := s.Vote("other", "lunch", []string{"sushi"}); err != nil {
+		t.Fatalf("Vote() failed: %v", err)
+	}
+	posts, err := repository.GetPosts("other", db, nil, 10)
+	if err != nil {
+		t.Fatalf("GetPosts() failed: %v", err)
+	}
+	for _, post := range *posts {
+		switch post.Id {
+		case "lunch":
+			if post.Poll == nil || !post.Poll.Voted || votes(post.Poll)["sushi"] != 1 {
+				t.Errorf("expected the poll with the viewer's vote, got %+v", post.Poll)
+			}
+		case "trip":
+			if post.Poll == nil || !post.Poll.ResultsHidden {
+				t.Errorf("expected the results to be hidden in the feed, got %+v", post.Poll)
+			}
+		case "repost":
+			if post.Poll != nil || post.Original == nil || post.Original.Poll == nil || post.Original.Poll.Voters != 1 {
+				t.Errorf("expected the reposted poll on the original, got %+v", post)
+			}
+		}
+	}
+
+	*clock = clock.Add(2 * time.Hour)
+	if _, err := s.Vote("other", "trip", []string{"hills"}); err != ErrPollClosed {
+		t.Errorf("expected ErrPollClosed, got %v", err)
+	}
+	if _, err := s.Unvote("voter", "trip", ""); err != ErrPollClosed {
+		t.Errorf("expected votes of closed polls to stay, got %v", err)
+	}
+	if poll, _ := s.Poll("other", "trip"); !poll.Closed || poll.ResultsHidden || votes(poll)["lake"] != 1 {
+		t.Errorf("expected the results of a closed poll to be shown, got %+v", poll)
+	}
+	if _, err := s.Poll("author", "repost"); err != ErrPollNotFound {
+		t.Errorf("expected ErrPollNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"backend/pkg/blobstore"
+)
+
+// ErrNotPostManager is returned when someone other than the author or a group admin tries to change a post
+var ErrNotPostManager = errors.New("only the author or an admin of the group can change this post")
+
+// PostService edits and deletes posts for their authors and, for group posts, the group's admins
+type PostService struct {
+	Repo    *repository.PostRepository
+	Uploads *blobstore.Server // Where post images are stored, see utils.Uploads
+	Now     func() time.Time  // Clock used for updated_at, defaults to time.Now
+}
+
+// NewPostService creates and returns a new instance of PostService.
+func NewPostService(repo *repository.PostRepository) *PostService {
+	return &PostService{Repo: repo, Uploads: utils.Uploads, Now: time.Now}
+}
+
+func (s *PostService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Manageable returns the post if the user may edit or delete it.
+func (s *PostService) Manageable(user *model.User, postID string) (*model.Post, error) {
+	post, err := s.Repo.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+	if post.UserId == user.ID {
+		return post, nil
+	}
+	if post.GroupId.Valid {
+		isAdmin, err := s.Repo.IsGroupAdmin(post.GroupId.String, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if isAdmin {
+			return post, nil
+		}
+	}
+	return nil, ErrNotPostManager
+}
+
+// Update replaces before with after, which the caller has validated, and keeps before in the edit history.
+// Images the post no longer uses are deleted from disk. A quote's audience can only be changed to one no
+// wider than the quoted post's, see checkReshareAudience.
+func (s *PostService) Update(editorID string, before, after *model.Post) error {
+	if after.QuoteOf != "" && after.Visibility != before.Visibility {
+		original, err := s.Repo.FindByID(after.QuoteOf)
+		if err != nil {
+			return err
+		}
+		if original != nil {
+			if err := checkReshareAudience(original, after); err != nil {
+				return err
+			}
+		}
+	}
+
+	now := s.now()
+	after.UpdatedAt = &now
+
+	after.AllowedFollowers = slices.Clone(after.AllowedFollowers)
+	if after.Visibility != "private" {
+		after.AllowedFollowers = nil
+	}
+	slices.Sort(after.AllowedFollowers)
+	after.AllowedFollowers = slices.Compact(after.AllowedFollowers)
+
+	previous := &model.PostEdit{
+		ID:               utils.GenerateUUID(),
+		PostID:           before.Id,
+		EditorID:         editorID,
+		Title:            before.Title,
+		Content:          before.Content,
+		Visibility:       before.Visibility,
+		ImageURL:         before.ImageUrl.String,
+		Media:            before.Media,
+		AllowedFollowers: emptyIfNil(before.AllowedFollowers),
+		EditedAt:         now,
+	}
+	if err := s.Repo.Update(after, previous); err != nil {
+		return err
+	}
+
+	kept := map[string]bool{after.ImageUrl.String: true}
+	for _, url := range mediaURLs(after.Media) {
+		kept[url] = true
+	}
+	var unused []string
+	for _, image := range append([]string{before.ImageUrl.String}, mediaURLs(before.Media)...) {
+		if !kept[image] {
+			unused = append(unused, image)
+		}
+	}
+	return s.DiscardImages(unused...)
+}
+
+// Delete removes a post with everything attached to it, including its image file.
+func (s *PostService) Delete(user *model.User, postID string) error {
+	if _, err := s.Manageable(user, postID); err != nil {
+		return err
+	}
+	images, found, err := s.Repo.Delete(postID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrPostNotFound
+	}
+	return s.DiscardImages(images...)
+}
+
+// Edits returns the previous versions of a post, newest first, to the users who may change it.
+func (s *PostService) Edits(user *model.User, postID string) ([]model.PostEdit, error) {
+	if _, err := s.Manageable(user, postID); err != nil {
+		return nil, err
+	}
+	return s.Repo.FindEdits(postID)
+}
+
+// DiscardImages removes uploaded post images that no post or profile uses, such as the ones uploaded
+// with an edit that failed.
+func (s *PostService) DiscardImages(webPaths ...string) error {
+	return discardUploads(s.Repo.DB, s.Uploads, webPaths)
+}
+
+func mediaURLs(media []model.PostMedia) []string {
+	var urls []string
+	for _, m := range media {
+		urls = append(urls, m.Files()...)
+	}
+	return urls
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+	"backend/pkg/blobstore"
+	"backend/pkg/db/dbtest"
+)
+
+func newPostTestService(t *testing.T) *PostService {
+	t.Helper()
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "group-admin", "follower-1", "follower-2", "stranger"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+	mustExec(t, db, `INSERT INTO groups (id, title, creator_id) VALUES (1, 'Hikers', 'group-admin')`)
+	mustExec(t, db, `INSERT INTO group_members (group_id, user_id, role, status) VALUES (1, 'group-admin', 'admin', 'active')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, post_image) VALUES ('post-1', 'author', 'Weekend', 'Going to the lake', 'private', '/uploads/posts/lake.png')`)
+	mustExec(t, db, `INSERT INTO private_posts (post_id, user_id, created_at) VALUES ('post-1', 'follower-1', '2024-01-01 00:00:00')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, group_id) VALUES ('post-2', 'author', 'Route', 'Trail map', 'public', '1')`)
+
+	s := NewPostService(repository.NewPostRepository(db))
+	s.Uploads = newTestUploads(t)
+	return s
+}
+
+func TestPostManageableByAuthorAndGroupAdmins(t *testing.T) {
+	s := newPostTestService(t)
+
+	if _, err := s.Manageable(&model.User{ID: "author"}, "post-1"); err != nil {
+		t.Errorf("expected the author to manage their post, got %v", err)
+	}
+	if _, err := s.Manageable(&model.User{ID: "group-admin"}, "post-1"); err != ErrNotPostManager {
+		t.Errorf("expected group admins not to manage posts outside their group, got %v", err)
+	}
+	if _, err := s.Manageable(&model.User{ID: "group-admin"}, "post-2"); err != nil {
+		t.Errorf("expected a group admin to manage posts in their group, got %v", err)
+	}
+	if _, err := s.Manageable(&model.User{ID: "stranger"}, "post-2"); err != ErrNotPostManager {
+		t.Errorf("expected other users to be refused, got %v", err)
+	}
+	if _, err := s.Manageable(&model.User{ID: "author"}, "missing"); err != ErrPostNotFound {
+		t.Errorf("expected ErrPostNotFound, got %v", err)
+	}
+}
+
+// mustExec runs a fixture statement and fails the test if it does not apply, so that a broken fixture
+// cannot pass for a row the viewer is not allowed to see
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("failed to run %q: %v", query, err)
+	}
+}
+
+// newTestUploads returns an upload store in a temporary directory
+func newTestUploads(t *testing.T) *blobstore.Server {
+	return blobstore.NewServer(blobstore.NewLocal(t.TempDir()), utils.UploadsPath, nil)
+}
+
+// uploadFile returns where a test upload store keeps the post image called name
+func uploadFile(uploads *blobstore.Server, name string) string {
+	return filepath.Join(uploads.Store.(*blobstore.Local).Dir, "posts", name)
+}
+
+func TestPostUpdateKeepsHistoryAndRewritesAudience(t *testing.T) {
+	s := newPostTestService(t)
+	oldImage := uploadFile(s.Uploads, "lake.png")
+	os.MkdirAll(filepath.Dir(oldImage), 0o755)
+	os.WriteFile(oldImage, []byte("image"), 0o644)
+	author := &model.User{ID: "author"}
+
+	before, err := s.Manageable(author, "post-1")
+	if err != nil {
+		t.Fatalf("Manageable() failed: %v", err)
+	}
+	after := *before
+	after.Title = "Weekend plans"
+	after.AllowedFollowers = []string{"follower-2", "follower-1", "follower-2"}
+	after.ImageUrl = sql.NullString{}
+	if err := s.Update(author.ID, before, &after); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	if _, err := os.Stat(oldImage); err != nil {
+		t.Errorf("expected the removed image to stay for the edit history, got %v", err)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM private_posts WHERE post_id = 'post-1'`); n != 2 {
+		t.Errorf("expected two followers in the audience, got %d", n)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM private_posts WHERE user_id = 'follower-1' AND created_at = '2024-01-01 00:00:00'`); n != 1 {
+		t.Error("expected the follower who stayed in the audience to keep their row")
+	}
+
+	updated, _ := s.Manageable(author, "post-1")
+	if updated.Title != "Weekend plans" || updated.UpdatedAt == nil || updated.ImageUrl.Valid {
+		t.Errorf("unexpected post after the edit %+v", updated)
+	}
+
+	// making the post public empties the audience
+	public := *updated
+	public.Visibility = "public"
+	if err := s.Update(author.ID, updated, &public); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM private_posts WHERE post_id = 'post-1'`); n != 0 {
+		t.Errorf("expected a public post to have no private audience, got %d rows", n)
+	}
+
+	edits, err := s.Edits(author, "post-1")
+	if err != nil || len(edits) != 2 {
+		t.Fatalf("expected two earlier versions, got %+v, %v", edits, err)
+	}
+	if edits[0].Title != "Weekend plans" || len(edits[0].AllowedFollowers) != 2 {
+		t.Errorf("expected the newest edit to hold the second version, got %+v", edits[0])
+	}
+	if oldest := edits[1]; oldest.Title != "Weekend" || oldest.ImageURL != "/uploads/posts/lake.png" || len(oldest.AllowedFollowers) != 1 {
+		t.Errorf("expected the oldest edit to hold the original post, got %+v", oldest)
+	}
+	if _, err := s.Edits(&model.User{ID: "stranger"}, "post-1"); err != ErrNotPostManager {
+		t.Errorf("expected the history to be limited to the author, got %v", err)
+	}
+}
+
+func TestPostDeleteRemovesImage(t *testing.T) {
+	s := newPostTestService(t)
+	image := uploadFile(s.Uploads, "lake.png")
+	os.MkdirAll(filepath.Dir(image), 0o755)
+	os.WriteFile(image, []byte("image"), 0o644)
+	mustExec(t, s.Repo.DB, `INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at) VALUES ('comment-1', 'post-1', 'follower-1', 'Fun', ?, ?)`, time.Now(), time.Now())
+
+	if err := s.Delete(&model.User{ID: "stranger"}, "post-1"); err != ErrNotPostManager {
+		t.Errorf("expected other users not to delete the post, got %v", err)
+	}
+	if err := s.Delete(&model.User{ID: "author"}, "post-1"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := os.Stat(image); !os.IsNotExist(err) {
+		t.Errorf("expected the image to be deleted, got %v", err)
+	}
+	if n := countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM comments`) + countRows(t, s.Repo.DB, `SELECT COUNT(*) FROM private_posts`); n != 0 {
+		t.Errorf("expected the post's comments and audience to go with it, %d rows left", n)
+	}
+	if err := s.Delete(&model.User{ID: "group-admin"}, "post-2"); err != nil {
+		t.Errorf("expected a group admin to delete a group post, got %v", err)
+	}
+}
+
+func TestPostDeleteKeepsSharedUploads(t *testing.T) {
+	s := newPostTestService(t)
+	ctx := context.Background()
+	key := blobstore.ContentKey("posts", []byte("image"), ".png")
+	if err := s.Uploads.Store.Put(ctx, key, []byte("image"), "image/png"); err != nil {
+		t.Fatalf("Put() failed: %v", err)
+	}
+	// the same picture uploaded twice is stored once
+	mustExec(t, s.Repo.DB, `UPDATE posts SET post_image = ? WHERE id IN ('post-1', 'post-2')`, s.Uploads.URL(key))
+
+	if err := s.Delete(&model.User{ID: "author"}, "post-1"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if ok, _ := s.Uploads.Store.Exists(ctx, key); !ok {
+		t.Fatal("expected the image to stay while another post uses it")
+	}
+	if err := s.Delete(&model.User{ID: "author"}, "post-2"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if ok, _ := s.Uploads.Store.Exists(ctx, key); ok {
+		t.Error("expected the image to be deleted with the last post using it")
+	}
+}
+
+func TestFeedPagesWithCursor(t *testing.T) {
+	db := dbtest.New(t)
+	insertTestUser(t, db, "author", "author@example.com")
+	insertTestUser(t, db, "reader", "reader@example.com")
+	// rows written by CURRENT_TIMESTAMP and by Go sort together, and post-b and post-c share a timestamp
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES ('post-a', 'author', 'A', 'a', 'public', '2024-03-01 11:00:00')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES ('post-b', 'author', 'B', 'b', 'public', ?)`, base)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES ('post-c', 'author', 'C', 'c', 'public', ?)`, base)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES ('post-d', 'author', 'D', 'd', 'public', '2024-03-01 13:00:00')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES ('post-e', 'author', 'E', 'e', 'private', ?)`, base.Add(2*time.Hour))
+
+	var got []string
+	var after *repository.FeedCursor
+	for page := 0; page < 3; page++ {
+		posts, err := repository.GetPosts("reader", db, after, 2)
+		if err != nil {
+			t.Fatalf("GetPosts() failed: %v", err)
+		}
+		for _, post := range *posts {
+			if post.Creator != "Test User" {
+				t.Errorf("expected the creator's name from the users table, got %q", post.Creator)
+			}
+			got = append(got, post.Id)
+		}
+		if len(*posts) < 2 {
+			break
+		}
+		last := (*posts)[len(*posts)-1]
+		cursor, err := repository.ParseFeedCursor(repository.FeedCursor{CreatedAt: last.CreatedAt, ID: last.Id}.String())
+		if err != nil {
+			t.Fatalf("ParseFeedCursor() failed: %v", err)
+		}
+		after = cursor
+	}
+
+	want := []string{"post-d", "post-c", "post-b", "post-a"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if _, err := repository.ParseFeedCursor("not-a-cursor"); err != repository.ErrInvalidFeedCursor {
+		t.Errorf("expected ErrInvalidFeedCursor, got %v", err)
+	}
+}
+
+func TestPostVisibility(t *testing.T) {
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "follower", "requested", "followed", "picked", "member", "pending-member", "stranger"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+	mustExec(t, db, `INSERT INTO followers (follower_id, followed_id, status) VALUES ('follower', 'author', 'accepted'), ('requested', 'author', 'requested'), ('author', 'followed', 'accepted'), ('picked', 'author', 'accepted')`)
+	mustExec(t, db, `INSERT INTO groups (id, title, creator_id) VALUES (1, 'Hikers', 'member')`)
+	mustExec(t, db, `INSERT INTO group_members (group_id, user_id, role, status) VALUES (1, 'member', 'admin', 'active'), (1, 'pending-member', 'member', 'pending')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility) VALUES ('public', 'author', 'T', 'C', 'public'), ('almostprivate', 'author', 'T', 'C', 'almostprivate'), ('private', 'author', 'T', 'C', 'private')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, group_id) VALUES ('group', 'author', 'T', 'C', 'public', '1')`)
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, hidden_at) VALUES ('hidden', 'author', 'T', 'C', 'public', CURRENT_TIMESTAMP)`)
+	mustExec(t, db, `INSERT INTO private_posts (post_id, user_id, created_at) VALUES ('private', 'picked', CURRENT_TIMESTAMP)`)
+
+	tests := []struct {
+		post    string
+		viewer  string
+		visible bool
+	}{
+		{"public", "author", true},
+		{"public", "stranger", true},
+		{"almostprivate", "author", true},
+		{"almostprivate", "follower", true},
+		{"almostprivate", "picked", true},
+		{"almostprivate", "requested", false},
+		{"almostprivate", "followed", false},
+		{"almostprivate", "stranger", false},
+		{"private", "author", true},
+		{"private", "picked", true},
+		{"private", "follower", false},
+		{"private", "stranger", false},
+		{"group", "author", true},
+		{"group", "member", true},
+		{"group", "pending-member", false},
+		{"group", "follower", false},
+		{"group", "stranger", false},
+		{"hidden", "author", false},
+		{"hidden", "stranger", false},
+		{"missing", "author", false},
+	}
+	for _, tt := range tests {
+		visible, err := repository.PostVisibleTo(db, tt.post, tt.viewer)
+		if err != nil {
+			t.Fatalf("PostVisibleTo(%s, %s) failed: %v", tt.post, tt.viewer, err)
+		}
+		if visible != tt.visible {
+			t.Errorf("PostVisibleTo(%s, %s) = %v, want %v", tt.post, tt.viewer, visible, tt.visible)
+		}
+
+		// the home feed leaves out group posts, the group page lists only them
+		var listed []model.Post
+		if tt.post == "group" {
+			listed, err = repository.GetGroupPosts("1", tt.viewer, db)
+		} else {
+			var feed *[]model.Post
+			feed, err = repository.GetPosts(tt.viewer, db, nil, 20)
+			if feed != nil {
+				listed = *feed
+			}
+		}
+		if err != nil {
+			t.Fatalf("listing posts for %s failed: %v", tt.viewer, err)
+		}
+		found := slices.ContainsFunc(listed, func(p model.Post) bool { return p.Id == tt.post })
+		if found != tt.visible {
+			t.Errorf("%s listed for %s = %v, want %v", tt.post, tt.viewer, found, tt.visible)
+		}
+	}
+}
+
+func TestPostGallery(t *testing.T) {
+	s := newPostTestService(t)
+	db := s.Repo.DB
+	write := func(name string) string {
+		file := uploadFile(s.Uploads, name)
+		os.MkdirAll(filepath.Dir(file), 0o755)
+		os.WriteFile(file, []byte("image"), 0o644)
+		return file
+	}
+	first, second, third := write("first.png"), write("second.png"), write("third.png")
+	firstThumb, secondFeed := write("first_thumb.png"), write("second_feed.png")
+
+	post := &model.Post{Id: "post-3", UserId: "author", Title: "Gallery", Content: "Three pictures", Visibility: "public", CreatedAt: time.Now(),
+		ImageUrl: sql.NullString{String: "/uploads/posts/first.png", Valid: true},
+		Media: []model.PostMedia{
+			{ID: "media-1", URL: "/uploads/posts/first.png", ThumbnailURL: "/uploads/posts/first_thumb.png", AltText: "A lake", Width: 800, Height: 600},
+			{ID: "media-2", URL: "/uploads/posts/second.png", FeedURL: "/uploads/posts/second_feed.png", AltText: "A boat", Width: 600, Height: 800,
+				Blurhash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj", DominantColor: "#336699"},
+		}}
+	if err := s.Repo.Create(post); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	feed, err := repository.GetPosts("stranger", db, nil, 20)
+	if err != nil {
+		t.Fatalf("GetPosts() failed: %v", err)
+	}
+	for _, p := range *feed {
+		if p.Media == nil {
+			t.Errorf("expected an empty gallery rather than none on %s", p.Id)
+		}
+		if p.Id == "post-3" && (len(p.Media) != 2 || p.Media[1].AltText != "A boat" || p.Media[1].Position != 1 || p.Media[0].Width != 800 ||
+			p.Media[1].FeedURL != "/uploads/posts/second_feed.png" || p.Media[1].DominantColor != "#336699") {
+			t.Errorf("unexpected gallery in the feed %+v", p.Media)
+		}
+	}
+
+	author := &model.User{ID: "author"}
+	before, err := s.Manageable(author, "post-3")
+	if err != nil {
+		t.Fatalf("Manageable() failed: %v", err)
+	}
+	after := *before
+	after.Media = []model.PostMedia{before.Media[1], {ID: "media-3", URL: "/uploads/posts/third.png"}}
+	after.ImageUrl = sql.NullString{String: "/uploads/posts/second.png", Valid: true}
+	if err := s.Update(author.ID, before, &after); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	for _, file := range []string{first, firstThumb, second} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected %s to stay for the post or its edit history, got %v", file, err)
+		}
+	}
+	for viewer, want := range map[string]bool{"author": true, "stranger": false} {
+		if visible, _, err := repository.MediaAccess(db, "/uploads/posts/first_thumb.png", viewer); err != nil || visible != want {
+			t.Errorf("MediaAccess() of an image only in the history for %s = %v, %v, want %v", viewer, visible, err, want)
+		}
+	}
+
+	updated, _ := s.Manageable(author, "post-3")
+	if len(updated.Media) != 2 || updated.Media[0].ID != "media-2" || updated.Media[0].Position != 0 || updated.Media[1].URL != "/uploads/posts/third.png" {
+		t.Errorf("expected the gallery to be reordered, got %+v", updated.Media)
+	}
+	edits, _ := s.Edits(author, "post-3")
+	if len(edits) != 1 || len(edits[0].Media) != 2 || edits[0].Media[0].AltText != "A lake" {
+		t.Errorf("expected the history to keep the previous gallery, got %+v", edits)
+	}
+
+	if err := s.Delete(author, "post-3"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	for _, file := range []string{first, firstThumb, second, secondFeed, third} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted with the post, got %v", file, err)
+		}
+	}
+}
+
+func TestPostMediaMigrationKeepsExistingImages(t *testing.T) {
+	db, migrate := dbtest.NewBefore(t, "000024_create_post_media_table")
+	insertTestUser(t, db, "author", "author@example.com")
+	mustExec(t, db, `INSERT INTO posts (id, user_id, title, content, visibility, post_image) VALUES ('with-image', 'author', 'T', 'C', 'public', '/uploads/posts/lake.png'), ('without-image', 'author', 'T', 'C', 'public', NULL)`)
+	migrate()
+
+	post, err := repository.NewPostRepository(db).FindByID("with-image")
+	if err != nil {
+		t.Fatalf("FindByID() failed: %v", err)
+	}
+	if len(post.Media) != 1 || post.Media[0].URL != "/uploads/posts/lake.png" || post.Media[0].Position != 0 ||
+		post.Media[0].ThumbnailURL != "" || post.Media[0].Blurhash != "" {
+		t.Errorf("expected the post's image to become its gallery, got %+v", post.Media)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM post_media WHERE post_id = 'without-image'`); n != 0 {
+		t.Errorf("expected no media for a post without an image, got %d", n)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/internal/utils"
+)
+
+// Errors returned by RepostService and PostService.Update so handlers can map them to status codes
+var (
+	ErrAlreadyReposted = errors.New("post already reposted")
+	ErrNotReposted     = errors.New("post not reposted")
+	ErrReshareTooWide  = errors.New("a post cannot be reshared to a wider audience than its own")
+	ErrInvalidAudience = errors.New("visibility must be public, almostprivate or private, with at least one follower for private")
+)
+
+// audienceWidths orders the visibilities of posts outside groups from the narrowest audience to the widest
+var audienceWidths = map[string]int{"private": 1, "almostprivate": 2, "public": 3}
+
+// RepostService reshares posts as reposts and checks the audience of quotes
+type RepostService struct {
+	Repo *repository.PostRepository
+	Now  func() time.Time // Clock used for created_at, defaults to time.Now
+}
+
+// NewRepostService creates and returns a new instance of RepostService.
+func NewRepostService(repo *repository.PostRepository) *RepostService {
+	return &RepostService{Repo: repo, Now: time.Now}
+}
+
+func (s *RepostService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Repost shares a post the user can see with visibility, or with the post's own visibility when it is empty.
+// Reposting a repost shares the post it reposts. Group posts can only be reposted within their group.
+func (s *RepostService) Repost(userID, postID, visibility string, allowedFollowers []string) (*model.Post, error) {
+	original, err := s.original(userID, postID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.Repo.FindRepost(userID, original.Id)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return nil, ErrAlreadyReposted
+	}
+
+	repost := &model.Post{
+		Id:         utils.GenerateUUID(),
+		UserId:     userID,
+		Visibility: strings.ToLower(visibility),
+		GroupId:    original.GroupId,
+		CreatedAt:  s.now(),
+		RepostOf:   original.Id,
+		Media:      []model.PostMedia{},
+	}
+	if repost.Visibility == "" {
+		repost.Visibility = original.Visibility
+	}
+	if !repost.GroupId.Valid {
+		if _, ok := audienceWidths[repost.Visibility]; !ok {
+			return nil, ErrInvalidAudience
+		}
+		if repost.Visibility == "private" {
+			repost.AllowedFollowers = slices.Clone(allowedFollowers)
+			slices.Sort(repost.AllowedFollowers)
+			repost.AllowedFollowers = slices.Compact(repost.AllowedFollowers)
+			if len(repost.AllowedFollowers) == 0 {
+				return nil, ErrInvalidAudience
+			}
+		}
+	}
+	if err := checkReshareAudience(original, repost); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(repost); err != nil {
+		return nil, err
+	}
+	return repost, nil
+}
+
+// Unrepost deletes the user's repost of a post, or of the post a repost shares.
+func (s *RepostService) Unrepost(userID, postID string) error {
+	post, err := s.Repo.FindByID(postID)
+	if err != nil {
+		return err
+	}
+	if post != nil && post.RepostOf != "" {
+		postID = post.RepostOf
+	}
+	repostID, err := s.Repo.FindRepost(userID, postID)
+	if err != nil {
+		return err
+	}
+	if repostID == "" {
+		return ErrNotReposted
+	}
+	_, _, err = s.Repo.Delete(repostID)
+	return err
+}
+
+// Quote checks that the user may quote the post quote.QuoteOf with the quote's audience, and points
+// quotes of a repost at the post it shares.
+func (s *RepostService) Quote(userID string, quote *model.Post) error {
+	original, err := s.original(userID, quote.QuoteOf)
+	if err != nil {
+		return err
+	}
+	quote.QuoteOf = original.Id
+	return checkReshareAudience(original, quote)
+}
+
+// original returns the post a user wants to reshare, or the post it reposts, if the user may see it.
+func (s *RepostService) original(userID, postID string) (*model.Post, error) {
+	post, err := s.Repo.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post != nil && post.RepostOf != "" {
+		post, err = s.Repo.FindByID(post.RepostOf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if post == nil {
+		return nil, ErrPostNotFound
+	}
+	visible, err := repository.PostVisibleTo(s.Repo.DB, post.Id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrPostNotFound
+	}
+	return post, nil
+}
+
+// checkReshareAudience returns ErrReshareTooWide if a repost or quote would be shown to a wider audience than
+// the post it shares: posts of a group stay within the group, only public posts are reshared to a group, and
+// otherwise private posts are only reshared privately and almostprivate ones to followers or privately.
+//
+// The audiences of two users' followers still differ, so feeds also only show reposts to users who may see
+// the post they share, and quotes without it.
+func checkReshareAudience(original, reshare *model.Post) error {
+	if original.GroupId.Valid {
+		if reshare.GroupId != original.GroupId {
+			return ErrReshareTooWide
+		}
+		return nil
+	}
+	originalWidth := audienceWidths[strings.ToLower(original.Visibility)]
+	if reshare.GroupId.Valid {
+		if originalWidth < audienceWidths["public"] {
+			return ErrReshareTooWide
+		}
+		return nil
+	}
+	if audienceWidths[strings.ToLower(reshare.Visibility)] > originalWidth {
+		return ErrReshareTooWide
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newRepostTestService(t *testing.T) (*RepostService, *sql.DB) {
+	t.Helper()
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "resharer", "follower", "fan", "stranger"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+	// follower only follows the resharer, fan follows both
+	db.Exec(`INSERT INTO followers (follower_id, followed_id, status) VALUES
+		('resharer', 'author', 'accepted'), ('fan', 'author', 'accepted'),
+		('fan', 'resharer', 'accepted'), ('follower', 'resharer', 'accepted')`)
+
+	s := NewRepostService(repository.NewPostRepository(db))
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { now = now.Add(time.Minute); return now }
+	for _, post := range []*model.Post{
+		{Id: "public-post", Visibility: "public"},
+		{Id: "friends-post", Visibility: "almostprivate"},
+		{Id: "private-post", Visibility: "private", AllowedFollowers: []string{"fan"}},
+	} {
+		post.UserId, post.Title, post.Content, post.CreatedAt = "author", "Weekend", "Going to the lake", s.now()
+		if err := s.Repo.Create(post); err != nil {
+			t.Fatalf("failed to create post %s: %v", post.Id, err)
+		}
+	}
+	return s, db
+}
+
+// feed returns the viewer's home feed keyed by post ID
+func feed(t *testing.T, db *sql.DB, viewerID string) map[string]model.Post {
+	t.Helper()
+	posts, err := repository.GetPosts(viewerID, db, nil, 50)
+	if err != nil {
+		t.Fatalf("GetPosts(%s) failed: %v", viewerID, err)
+	}
+	byID := map[string]model.Post{}
+	for _, post := range *posts {
+		byID[post.Id] = post
+	}
+	return byID
+}
+
+func TestReposts(t *testing.T) {
+	s, db := newRepostTestService(t)
+
+	repost, err := s.Repost("resharer", "public-post", "", nil)
+	if err != nil {
+		t.Fatalf("Repost() failed: %v", err)
+	}
+	if repost.RepostOf != "public-post" || repost.Visibility != "public" {
+		t.Errorf("expected a public repost of the post, got %+v", repost)
+	}
+	if _, err := s.Repost("resharer", repost.Id, "", nil); err != ErrAlreadyReposted {
+		t.Errorf("expected ErrAlreadyReposted, got %v", err)
+	}
+	// reposting a repost shares the original
+	second, err := s.Repost("follower", repost.Id, "", nil)
+	if err != nil || second.RepostOf != "public-post" {
+		t.Fatalf("expected the original to be reposted, got %+v, %v", second, err)
+	}
+
+	posts := feed(t, db, "stranger")
+	shared := posts[repost.Id]
+	if shared.Original == nil || shared.Original.Id != "public-post" || shared.UserId != "resharer" {
+		t.Fatalf("expected the feed to show who reposted the post, got %+v", shared)
+	}
+	if shared.Original.RepostCount != 2 || posts["public-post"].RepostCount != 2 || posts["public-post"].Reposted {
+		t.Errorf("expected two reposts counted on the original, got %+v", posts["public-post"])
+	}
+	if original := feed(t, db, "resharer")["public-post"]; !original.Reposted {
+		t.Errorf("expected the resharer to see they reposted the post")
+	}
+
+	if err := s.Unrepost("follower", "public-post"); err != nil {
+		t.Fatalf("Unrepost() failed: %v", err)
+	}
+	if err := s.Unrepost("follower", "public-post"); err != ErrNotReposted {
+		t.Errorf("expected ErrNotReposted, got %v", err)
+	}
+	if count := feed(t, db, "stranger")["public-post"].RepostCount; count != 1 {
+		t.Errorf("expected one repost left, got %d", count)
+	}
+}
+
+func TestRepostAudience(t *testing.T) {
+	s, db := newRepostTestService(t)
+
+	if _, err := s.Repost("resharer", "friends-post", "public", nil); err != ErrReshareTooWide {
+		t.Errorf("expected ErrReshareTooWide, got %v", err)
+	}
+	repost, err := s.Repost("resharer", "friends-post", "", nil)
+	if err != nil {
+		t.Fatalf("Repost() failed: %v", err)
+	}
+	// the repost goes to the resharer's followers, but only those who also follow the author see it
+	if _, ok := feed(t, db, "fan")[repost.Id]; !ok {
+		t.Errorf("expected a follower of both to see the repost")
+	}
+	if _, ok := feed(t, db, "follower")[repost.Id]; ok {
+		t.Errorf("expected the repost to be left out for users who may not see the original")
+	}
+	if visible, _ := repository.PostVisibleTo(db, repost.Id, "follower"); visible {
+		t.Errorf("expected the repost not to be visible to users who may not see the original")
+	}
+
+	if _, err := s.Repost("resharer", "private-post", "private", []string{"follower"}); err != ErrPostNotFound {
+		t.Errorf("expected posts the user may not see to be ErrPostNotFound, got %v", err)
+	}
+	if _, err := s.Repost("fan", "private-post", "", nil); err != ErrInvalidAudience {
+		t.Errorf("expected private reposts to need followers, got %v", err)
+	}
+	if _, err := s.Repost("fan", "private-post", "private", []string{"resharer"}); err != nil {
+		t.Errorf("expected a private repost of a private post, got %v", err)
+	}
+
+	quote := &model.Post{QuoteOf: "friends-post", Visibility: "public"}
+	if err := s.Quote("resharer", quote); err != ErrReshareTooWide {
+		t.Errorf("expected ErrReshareTooWide, got %v", err)
+	}
+	quote = &model.Post{QuoteOf: repost.Id, Visibility: "private"}
+	if err := s.Quote("fan", quote); err != nil || quote.QuoteOf != "friends-post" {
+		t.Errorf("expected a quote of the reposted post, got %q, %v", quote.QuoteOf, err)
+	}
+}
+
+func TestCheckReshareAudience(t *testing.T) {
+	group := sql.NullString{String: "1", Valid: true}
+	otherGroup := sql.NullString{String: "2", Valid: true}
+	tests := []struct {
+		original, reshare model.Post
+		allowed           bool
+	}{
+		{model.Post{Visibility: "public"}, model.Post{Visibility: "public"}, true},
+		{model.Post{Visibility: "public"}, model.Post{Visibility: "group", GroupId: group}, true},
+		{model.Post{Visibility: "almostprivate"}, model.Post{Visibility: "private"}, true},
+		{model.Post{Visibility: "almostprivate"}, model.Post{Visibility: "public"}, false},
+		{model.Post{Visibility: "almostprivate"}, model.Post{Visibility: "group", GroupId: group}, false},
+		{model.Post{Visibility: "private"}, model.Post{Visibility: "almostprivate"}, false},
+		{model.Post{Visibility: "public", GroupId: group}, model.Post{Visibility: "public", GroupId: group}, true},
+		{model.Post{Visibility: "public", GroupId: group}, model.Post{Visibility: "public"}, false},
+		{model.Post{Visibility: "public", GroupId: group}, model.Post{Visibility: "public", GroupId: otherGroup}, false},
+	}
+	for _, tt := range tests {
+		err := checkReshareAudience(&tt.original, &tt.reshare)
+		if tt.allowed != (err == nil) {
+			t.Errorf("resharing %+v as %+v: got %v", tt.original, tt.reshare, err)
+		}
+	}
+}
+
+func TestQuoteTombstones(t *testing.T) {
+	s, db := newRepostTestService(t)
+
+	create := func(post *model.Post) {
+		post.Id, post.UserId, post.Title, post.Content, post.CreatedAt = post.QuoteOf+"-quote", "resharer", "So true", "Everyone should go", s.now()
+		if err := s.Quote("resharer", post); err != nil {
+			t.Fatalf("Quote() failed: %v", err)
+		}
+		if err := s.Repo.Create(post); err != nil {
+			t.Fatalf("failed to create quote: %v", err)
+		}
+	}
+	create(&model.Post{QuoteOf: "public-post", Visibility: "public"})
+	create(&model.Post{QuoteOf: "friends-post", Visibility: "almostprivate"})
+	if _, err := s.Repost("fan", "public-post", "", nil); err != nil {
+		t.Fatalf("Repost() failed: %v", err)
+	}
+
+	posts := feed(t, db, "follower")
+	if quote := posts["public-post-quote"]; quote.Original == nil || quote.Original.Id != "public-post" || quote.Original.QuoteCount != 1 {
+		t.Errorf("expected the quoted post to be attached, got %+v", quote)
+	}
+	// the quote reaches the resharer's followers, the quoted post does not
+	if quote, ok := posts["friends-post-quote"]; !ok || quote.Original != nil || quote.OriginalStatus != model.OriginalUnavailable {
+		t.Errorf("expected the quoted post to be withheld, got %+v", quote)
+	}
+
+	if _, found, err := s.Repo.Delete("public-post"); err != nil || !found {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM posts WHERE repost_of = 'public-post'`); n != 0 {
+		t.Errorf("expected reposts to be deleted with the original, got %d", n)
+	}
+	quote := feed(t, db, "stranger")["public-post-quote"]
+	if quote.QuoteOf != "public-post" || quote.Original != nil || quote.OriginalStatus != model.OriginalDeleted {
+		t.Errorf("expected a tombstone for the deleted post, got %+v", quote)
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/hashtag"
+)
+
+// Constants controlling trending tags
+const (
+	TrendingWindow   = 48 * time.Hour   // Only tags used this recently can trend
+	TrendingHalfLife = 6 * time.Hour    // Age at which a use counts half as much as a new one
+	TrendingPeriod   = 10 * time.Minute // How often the trending tags are rebuilt
+	MaxTrendingTags  = 50               // How many trending tags are kept
+	TagBackfillBatch = 100              // How many older posts Backfill indexes per transaction
+)
+
+// ErrInvalidTag is returned for tags that hashtag.Parse would never find
+var ErrInvalidTag = errors.New("invalid tag")
+
+// TagService reads the posts using a hashtag and keeps the trending tags
+type TagService struct {
+	Repo *repository.TagRepository
+	Now  func() time.Time // Clock used for refreshes, defaults to time.Now
+}
+
+// NewTagService creates and returns a new instance of TagService.
+func NewTagService(repo *repository.TagRepository) *TagService {
+	return &TagService{Repo: repo, Now: time.Now}
+}
+
+func (s *TagService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// Posts returns a page of the posts the viewer can see that use the tag, and the cursor of the next page,
+// nil after the last one. The tag may start with a # and is matched regardless of case.
+func (s *TagService) Posts(viewerID, tag string, after *repository.FeedCursor, limit int) ([]model.Post, *repository.FeedCursor, error) {
+	tag, ok := hashtag.Normalize(tag)
+	if !ok {
+		return nil, nil, ErrInvalidTag
+	}
+	// one extra post tells whether there is another page
+	posts, err := repository.GetTaggedPosts(tag, viewerID, s.Repo.DB, after, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(posts) <= limit {
+		return posts, nil, nil
+	}
+	page := posts[:limit]
+	last := page[limit-1]
+	return page, &repository.FeedCursor{CreatedAt: last.CreatedAt, ID: last.Id}, nil
+}
+
+// Backfill indexes the hashtags of the posts and comments written before tags were, so that they show up in
+// tag feeds. Their tags are dated like them, so they only trend if they are recent. It returns how many
+// posts it went through, none once it has run.
+func (s *TagService) Backfill() (int, error) {
+	total := 0
+	for {
+		n, err := s.Repo.BackfillTags(TagBackfillBatch)
+		total += n
+		if err != nil || n < TagBackfillBatch {
+			return total, err
+		}
+	}
+}
+
+// Refresh rebuilds the trending tags from the public uses within TrendingWindow. Each person counts once
+// per tag, with their latest use, so that one account repeating a tag cannot make it trend. A use counts
+// for 1 when new and halves every TrendingHalfLife.
+func (s *TagService) Refresh() error {
+	now := s.now()
+	uses, err := s.Repo.FindUses(now.Add(-TrendingWindow))
+	if err != nil {
+		return err
+	}
+
+	type tagUser struct{ tag, userID string }
+	latest := map[tagUser]time.Time{}
+	for _, use := range uses {
+		key := tagUser{use.Tag, use.UserID}
+		if use.CreatedAt.After(latest[key]) {
+			latest[key] = use.CreatedAt
+		}
+	}
+	byTag := map[string]*model.TrendingTag{}
+	for key, at := range latest {
+		tag := byTag[key.tag]
+		if tag == nil {
+			tag = &model.TrendingTag{Tag: key.tag, ComputedAt: now}
+			byTag[key.tag] = tag
+		}
+		age := max(now.Sub(at), 0)
+		tag.Users++
+		tag.Score += math.Exp2(-age.Hours() / TrendingHalfLife.Hours())
+	}
+
+	trending := make([]model.TrendingTag, 0, len(byTag))
+	for _, tag := range byTag {
+		trending = append(trending, *tag)
+	}
+	slices.SortFunc(trending, func(a, b model.TrendingTag) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Tag, b.Tag))
+	})
+	if len(trending) > MaxTrendingTags {
+		trending = trending[:MaxTrendingTags]
+	}
+	return s.Repo.ReplaceTrending(trending)
+}
+
+// Trending returns up to limit tags as of the last refresh, highest score first.
+func (s *TagService) Trending(limit int) ([]model.TrendingTag, error) {
+	return s.Repo.FindTrending(min(limit, MaxTrendingTags))
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+)
+
+func newTagTestService(t *testing.T) (*TagService, *sql.DB) {
+	t.Helper()
+	db := dbtest.New(t)
+	for _, id := range []string{"author", "reader", "spammer", "member"} {
+		insertTestUser(t, db, id, id+"@example.com")
+	}
+	return NewTagService(repository.NewTagRepository(db)), db
+}
+
+func TestTaggedPosts(t *testing.T) {
+	s, db := newTagTestService(t)
+	posts := repository.NewPostRepository(db)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	create := func(id, visibility, content string, age time.Duration) {
+		post := &model.Post{Id: id, UserId: "author", Title: "Weekend", Content: content, Visibility: visibility, CreatedAt: now.Add(-age)}
+		if err := posts.Create(post); err != nil {
+			t.Fatalf("failed to create post %s: %v", id, err)
+		}
+	}
+	create("old", "public", "At the #Lake", 3*time.Hour)
+	create("new", "public", "Back at the #lake, #lake again", time.Hour)
+	create("hidden-audience", "almostprivate", "Secret #lake", 2*time.Hour)
+	create("commented", "public", "No tags here", 4*time.Hour)
+	create("other", "public", "#mountains", 5*time.Hour)
+
+	tx, _ := db.Begin()
+	tx.Exec(`INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at) VALUES ('comment-1', 'commented', 'reader', 'Take me to the #lake', ?, ?)`, now, now)
+	if err := repository.InsertCommentTags(tx, "commented", "comment-1", "Take me to the #lake", now); err != nil {
+		t.Fatalf("InsertCommentTags() failed: %v", err)
+	}
+	tx.Commit()
+
+	var got []string
+	var after *repository.FeedCursor
+	for {
+		page, next, err := s.Posts("reader", "#LAKE", after, 2)
+		if err != nil {
+			t.Fatalf("Posts() failed: %v", err)
+		}
+		for _, post := range page {
+			got = append(got, post.Id)
+		}
+		if next == nil {
+			break
+		}
+		after = next
+	}
+	want := []string{"new", "old", "commented"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// editing the post takes its tags out, and deleting the comment takes the commented post out
+	edited := now
+	post, _ := posts.FindByID("old")
+	post.Content, post.UpdatedAt = "At the #beach", &edited
+	if err := posts.Update(post, &model.PostEdit{ID: "edit-1", PostID: "old", EditedAt: now}); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	db.Exec(`DELETE FROM comments WHERE id = 'comment-1'`)
+	if page, _, _ := s.Posts("reader", "lake", nil, 10); len(page) != 1 || page[0].Id != "new" {
+		t.Errorf("expected only the new post to use the tag, got %v", page)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM post_tags WHERE post_id = 'old' AND tag = 'beach'`); n != 1 {
+		t.Errorf("expected the edit to add the new tag, got %d rows", n)
+	}
+
+	if _, _, err := s.Posts("reader", "#", nil, 10); err != ErrInvalidTag {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+}
+
+func TestTrendingTags(t *testing.T) {
+	s, db := newTagTestService(t)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.Now = func() time.Time { return now }
+
+	post := func(id, author, visibility string, age time.Duration, tags ...string) {
+		db.Exec(`INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES (?, ?, 'T', 'C', ?, ?)`, id, author, visibility, now.Add(-age))
+		for _, tag := range tags {
+			db.Exec(`INSERT INTO post_tags (post_id, tag, created_at) VALUES (?, ?, ?)`, id, tag, now.Add(-age))
+		}
+	}
+	post("fresh-1", "author", "public", time.Hour, "fresh")
+	post("fresh-2", "reader", "public", 2*time.Hour, "fresh")
+	post("stale-1", "author", "public", 30*time.Hour, "stale")
+	post("stale-2", "reader", "public", 30*time.Hour, "stale")
+	post("stale-3", "member", "public", 30*time.Hour, "stale")
+	for i, id := range []string{"spam-1", "spam-2", "spam-3", "spam-4"} {
+		post(id, "spammer", "public", time.Duration(i)*time.Minute, "spam")
+	}
+	post("private", "author", "almostprivate", time.Hour, "private", "fresh")
+	post("expired", "author", "public", 3*24*time.Hour, "expired")
+
+	for i := 0; i < 2; i++ {
+		if err := s.Refresh(); err != nil {
+			t.Fatalf("Refresh() failed: %v", err)
+		}
+	}
+	trending, err := s.Trending(10)
+	if err != nil {
+		t.Fatalf("Trending() failed: %v", err)
+	}
+
+	var got []string
+	for _, tag := range trending {
+		got = append(got, tag.Tag)
+	}
+	// two recent users beat three from more than a day ago, and repeated use by one account counts once
+	want := []string{"fresh", "spam", "stale"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if trending[0].Users != 2 || trending[1].Users != 1 || trending[2].Users != 3 {
+		t.Errorf("expected distinct users to be counted, got %+v", trending)
+	}
+	if trending[1].Score > 1 || trending[2].Score >= trending[0].Score {
+		t.Errorf("expected scores to decay with age, got %+v", trending)
+	}
+}
+
+func TestTagBackfill(t *testing.T) {
+	db, migrate := dbtest.NewBefore(t, "000033_queue_tag_backfill")
+	insertTestUser(t, db, "author", "author@example.com")
+	insertTestUser(t, db, "reader", "reader@example.com")
+	written := time.Now().Add(-30 * 24 * time.Hour)
+	for i := 0; i < TagBackfillBatch+1; i++ {
+		id := fmt.Sprintf("post-%03d", i)
+		if _, err := db.Exec(`INSERT INTO posts (id, user_id, title, content, visibility, created_at) VALUES (?, 'author', 'Weekend', 'At the #Lake', 'public', ?)`, id, written); err != nil {
+			t.Fatalf("failed to insert post: %v", err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO comments (id, post_id, user_id, content, created_at, updated_at) VALUES ('comment-1', 'post-000', 'reader', 'Take me to the #mountains', ?, ?)`, written, written); err != nil {
+		t.Fatalf("failed to insert comment: %v", err)
+	}
+	migrate()
+
+	s := NewTagService(repository.NewTagRepository(db))
+	if n, err := s.Backfill(); err != nil || n != TagBackfillBatch+1 {
+		t.Fatalf("Backfill() = %d, %v", n, err)
+	}
+	if n, err := s.Backfill(); err != nil || n != 0 {
+		t.Errorf("expected nothing left to index, got %d, %v", n, err)
+	}
+
+	for tag, want := range map[string]int{"lake": TagBackfillBatch + 1, "mountains": 1} {
+		posts, _, err := s.Posts("reader", tag, nil, 200)
+		if err != nil || len(posts) != want {
+			t.Errorf("expected %d older posts tagged %s, got %d, %v", want, tag, len(posts), err)
+		}
+	}
+	// older tags keep the date of their post, so they do not trend now
+	if err := s.Refresh(); err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+	if trending, _ := s.Trending(10); len(trending) != 0 {
+		t.Errorf("expected older tags not to trend, got %+v", trending)
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/totp"
+)
+
+// Constants controlling TOTP enrollment and the second login step
+const (
+	TOTPIssuer              = "Social Network" // Issuer shown in authenticator apps
+	RecoveryCodeCount       = 10               // Number of one-time recovery codes issued on confirmation
+	LoginChallengeTTL       = 5 * time.Minute  // How long a challenge token stays valid
+	MaxLoginChallengeTries  = 5                // Failed codes allowed before a challenge is discarded
+	loginChallengeTokenSize = 32
+)
+
+// Errors returned by TwoFactorService so handlers can map them to status codes
+var (
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication is not set up")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid authentication code")
+	ErrLoginChallengeNotFound  = errors.New("login challenge not found")
+	ErrLoginChallengeExpired   = errors.New("login challenge expired, please log in again")
+	ErrLoginChallengeExhausted = errors.New("too many invalid codes, please log in again")
+)
+
+// TwoFactorService provides TOTP enrollment and the two-step login state machine.
+//
+// A login for a user with 2FA enabled moves through these states:
+//
+//	password accepted -> challenge pending -> code accepted (session created)
+//	                                       -> expired or too many attempts (challenge discarded)
+type TwoFactorService struct {
+	Repo *repository.TwoFactorRepository
+	Now  func() time.Time // Clock used for codes and expiry, defaults to time.Now
+}
+
+// NewTwoFactorService creates and returns a new instance of TwoFactorService.
+func NewTwoFactorService(repo *repository.TwoFactorRepository) *TwoFactorService {
+	return &TwoFactorService{Repo: repo, Now: time.Now}
+}
+
+func (s *TwoFactorService) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// IsEnabled reports whether a user has confirmed 2FA enrollment.
+func (s *TwoFactorService) IsEnabled(userID string) (bool, error) {
+	tf, err := s.Repo.FindByUserID(userID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// BeginEnrollment generates a new secret for the user and returns it with its provisioning URI.
+// The secret only takes effect once ConfirmEnrollment accepts a code generated from it.
+func (s *TwoFactorService) BeginEnrollment(user *model.User) (string, string, error) {
+	enabled, err := s.IsEnabled(user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.Repo.SavePending(user.ID, secret); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.ProvisioningURI(secret, TOTPIssuer, user.Email), nil
+}
+
+// ConfirmEnrollment enables 2FA once the user proves their authenticator works,
+// and returns the plaintext recovery codes. They are only ever shown this once.
+func (s *TwoFactorService) ConfirmEnrollment(userID, code string) ([]string, error) {
+	tf, err := s.Repo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, s.now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.Repo.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = s.Repo.Enable(tx, userID, step, s.now()); err != nil {
+		return nil, err
+	}
+	if err = s.Repo.ReplaceRecoveryCodes(tx, userID, hashes); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns 2FA off after checking a current code or an unused recovery code.
+func (s *TwoFactorService) Disable(userID, code string) error {
+	if err := s.verifyCode(userID, code); err != nil {
+		return err
+	}
+	return s.Repo.Delete(userID)
+}
+
+// RemainingRecoveryCodes returns how many unused recovery codes a user has.
+func (s *TwoFactorService) RemainingRecoveryCodes(userID string) (int, error) {
+	return s.Repo.CountUnusedRecoveryCodes(userID)
+}
+
+// CreateLoginChallenge starts the second login step for a user whose password was accepted.
+// The returned token is only stored hashed.
+func (s *TwoFactorService) CreateLoginChallenge(userID string) (string, time.Time, error) {
+	buf := make([]byte, loginChallengeTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+
+	now := s.now()
+	challenge := &model.LoginChallenge{
+		ID:        hashToken(token),
+		UserID:    userID,
+		ExpiresAt: now.Add(LoginChallengeTTL),
+		CreatedAt: now,
+	}
+	if err := s.Repo.CreateChallenge(challenge); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, challenge.ExpiresAt, nil
+}
+
+// VerifyLoginChallenge completes the second login step and returns the ID of the user to log in.
+// A challenge is discarded once it succeeds, expires or runs out of attempts. When a wrong code is
+// submitted the user ID is still returned along with the error so the failure can be recorded.
+func (s *TwoFactorService) VerifyLoginChallenge(token, code string) (string, error) {
+	id := hashToken(token)
+	challenge, err := s.Repo.FindChallenge(id)
+	if err != nil {
+		return "", err
+	}
+	if challenge == nil {
+		return "", ErrLoginChallengeNotFound
+	}
+
+	if !s.now().Before(challenge.ExpiresAt) {
+		_ = s.Repo.DeleteChallenge(id)
+		return "", ErrLoginChallengeExpired
+	}
+	if challenge.Attempts >= MaxLoginChallengeTries {
+		_ = s.Repo.DeleteChallenge(id)
+		return "", ErrLoginChallengeExhausted
+	}
+
+	if err := s.verifyCode(challenge.UserID, code); err != nil {
+		if err != ErrInvalidTwoFactorCode {
+			return "", err
+		}
+		attempts, incErr := s.Repo.IncrementChallengeAttempts(id)
+		if incErr != nil {
+			return "", incErr
+		}
+		if attempts >= MaxLoginChallengeTries {
+			_ = s.Repo.DeleteChallenge(id)
+			return challenge.UserID, ErrLoginChallengeExhausted
+		}
+		return challenge.UserID, ErrInvalidTwoFactorCode
+	}
+
+	if err := s.Repo.DeleteChallenge(id); err != nil {
+		return "", err
+	}
+	return challenge.UserID, nil
+}
+
+// verifyCode accepts either a TOTP code or an unused recovery code for an enabled user.
+func (s *TwoFactorService) verifyCode(userID, code string) error {
+	tf, err := s.Repo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || !tf.Enabled {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	if step, ok := totp.Validate(tf.Secret, code, s.now()); ok {
+		fresh, err := s.Repo.AdvanceLastUsedStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.Repo.UseRecoveryCode(userID, hashRecoveryCode(code), s.now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns n random codes formatted as xxxxx-xxxxx and their hashes
+func generateRecoveryCodes(n int) ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalizes user input so codes are accepted with or without the dash or casing
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return hashToken(normalized)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
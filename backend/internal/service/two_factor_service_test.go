@@ -0,0 +1,210 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/totp"
+)
+
+func insertTestUser(t *testing.T, db *sql.DB, id, email string) *model.User {
+	t.Helper()
+	_, err := db.Exec(`INSERT INTO users (id, email, fname, lname, dob, password) VALUES (?, ?, 'Test', 'User', '2000-01-01', 'hash')`, id, email)
+	if err != nil {
+		t.Fatalf("failed to insert test user: %v", err)
+	}
+	return &model.User{ID: id, Email: email}
+}
+
+// enrolledService returns a service with a user that has 2FA enabled, its secret and recovery codes
+func enrolledService(t *testing.T, clock *time.Time) (*TwoFactorService, *model.User, string, []string) {
+	t.Helper()
+	db := dbtest.New(t)
+	user := insertTestUser(t, db, "user-1", "jane@example.com")
+
+	s := NewTwoFactorService(repository.NewTwoFactorRepository(db))
+	s.Now = func() time.Time { return *clock }
+
+	secret, _, err := s.BeginEnrollment(user)
+	if err != nil {
+		t.Fatalf("BeginEnrollment() failed: %v", err)
+	}
+	code, _ := totp.GenerateCode(secret, *clock)
+	recoveryCodes, err := s.ConfirmEnrollment(user.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmEnrollment() failed: %v", err)
+	}
+
+	// move past the step used for confirmation so the next code is fresh
+	*clock = clock.Add(totp.Period * time.Second)
+	return s, user, secret, recoveryCodes
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	db := dbtest.New(t)
+	user := insertTestUser(t, db, "user-1", "jane@example.com")
+
+	s := NewTwoFactorService(repository.NewTwoFactorRepository(db))
+	s.Now = func() time.Time { return clock }
+
+	secret, uri, err := s.BeginEnrollment(user)
+	if err != nil {
+		t.Fatalf("BeginEnrollment() failed: %v", err)
+	}
+	if secret == "" || uri == "" {
+		t.Fatal("expected a secret and provisioning URI")
+	}
+
+	if enabled, _ := s.IsEnabled(user.ID); enabled {
+		t.Error("2FA should not be enabled before confirmation")
+	}
+
+	if _, err := s.ConfirmEnrollment(user.ID, "000000"); err != ErrInvalidTwoFactorCode {
+		t.Errorf("expected ErrInvalidTwoFactorCode for a wrong code, got %v", err)
+	}
+
+	code, _ := totp.GenerateCode(secret, clock)
+	codes, err := s.ConfirmEnrollment(user.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmEnrollment() failed: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", RecoveryCodeCount, len(codes))
+	}
+
+	if enabled, _ := s.IsEnabled(user.ID); !enabled {
+		t.Error("2FA should be enabled after confirmation")
+	}
+
+	if _, _, err := s.BeginEnrollment(user); err != ErrTwoFactorAlreadyEnabled {
+		t.Errorf("expected ErrTwoFactorAlreadyEnabled when enrolling twice, got %v", err)
+	}
+}
+
+func TestLoginChallengeStateMachine(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s *TwoFactorService, secret string, recovery []string, clock *time.Time)
+	}{
+		{
+			name: "valid code completes login once",
+			run: func(t *testing.T, s *TwoFactorService, secret string, _ []string, clock *time.Time) {
+				token, _, _ := s.CreateLoginChallenge("user-1")
+				code, _ := totp.GenerateCode(secret, *clock)
+
+				userID, err := s.VerifyLoginChallenge(token, code)
+				if err != nil || userID != "user-1" {
+					t.Fatalf("expected user-1 and no error, got %q and %v", userID, err)
+				}
+				if _, err := s.VerifyLoginChallenge(token, code); err != ErrLoginChallengeNotFound {
+					t.Errorf("expected challenge to be consumed, got %v", err)
+				}
+			},
+		},
+		{
+			name: "replayed code is rejected",
+			run: func(t *testing.T, s *TwoFactorService, secret string, _ []string, clock *time.Time) {
+				code, _ := totp.GenerateCode(secret, *clock)
+				first, _, _ := s.CreateLoginChallenge("user-1")
+				if _, err := s.VerifyLoginChallenge(first, code); err != nil {
+					t.Fatalf("first use failed: %v", err)
+				}
+
+				second, _, _ := s.CreateLoginChallenge("user-1")
+				if _, err := s.VerifyLoginChallenge(second, code); err != ErrInvalidTwoFactorCode {
+					t.Errorf("expected ErrInvalidTwoFactorCode on replay, got %v", err)
+				}
+			},
+		},
+		{
+			name: "recovery code works only once",
+			run: func(t *testing.T, s *TwoFactorService, _ string, recovery []string, _ *time.Time) {
+				first, _, _ := s.CreateLoginChallenge("user-1")
+				if _, err := s.VerifyLoginChallenge(first, recovery[0]); err != nil {
+					t.Fatalf("recovery code rejected: %v", err)
+				}
+
+				second, _, _ := s.CreateLoginChallenge("user-1")
+				if _, err := s.VerifyLoginChallenge(second, recovery[0]); err != ErrInvalidTwoFactorCode {
+					t.Errorf("expected used recovery code to be rejected, got %v", err)
+				}
+				if remaining, _ := s.RemainingRecoveryCodes("user-1"); remaining != RecoveryCodeCount-1 {
+					t.Errorf("expected %d recovery codes left, got %d", RecoveryCodeCount-1, remaining)
+				}
+			},
+		},
+		{
+			name: "expired challenge is discarded",
+			run: func(t *testing.T, s *TwoFactorService, secret string, _ []string, clock *time.Time) {
+				token, _, _ := s.CreateLoginChallenge("user-1")
+				*clock = clock.Add(LoginChallengeTTL)
+				code, _ := totp.GenerateCode(secret, *clock)
+
+				if _, err := s.VerifyLoginChallenge(token, code); err != ErrLoginChallengeExpired {
+					t.Fatalf("expected ErrLoginChallengeExpired, got %v", err)
+				}
+				if _, err := s.VerifyLoginChallenge(token, code); err != ErrLoginChallengeNotFound {
+					t.Errorf("expected expired challenge to be deleted, got %v", err)
+				}
+			},
+		},
+		{
+			name: "too many wrong codes discard the challenge",
+			run: func(t *testing.T, s *TwoFactorService, secret string, _ []string, clock *time.Time) {
+				token, _, _ := s.CreateLoginChallenge("user-1")
+				for i := 1; i < MaxLoginChallengeTries; i++ {
+					if _, err := s.VerifyLoginChallenge(token, "000000"); err != ErrInvalidTwoFactorCode {
+						t.Fatalf("attempt %d: expected ErrInvalidTwoFactorCode, got %v", i, err)
+					}
+				}
+				if _, err := s.VerifyLoginChallenge(token, "000000"); err != ErrLoginChallengeExhausted {
+					t.Fatalf("expected ErrLoginChallengeExhausted, got %v", err)
+				}
+
+				code, _ := totp.GenerateCode(secret, *clock)
+				if _, err := s.VerifyLoginChallenge(token, code); err != ErrLoginChallengeNotFound {
+					t.Errorf("expected exhausted challenge to be deleted, got %v", err)
+				}
+			},
+		},
+		{
+			name: "unknown token is rejected",
+			run: func(t *testing.T, s *TwoFactorService, secret string, _ []string, clock *time.Time) {
+				code, _ := totp.GenerateCode(secret, *clock)
+				if _, err := s.VerifyLoginChallenge("not-a-token", code); err != ErrLoginChallengeNotFound {
+					t.Errorf("expected ErrLoginChallengeNotFound, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Unix(1700000000, 0)
+			s, _, secret, recovery := enrolledService(t, &clock)
+			tt.run(t, s, secret, recovery, &clock)
+		})
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	s, user, secret, _ := enrolledService(t, &clock)
+
+	if err := s.Disable(user.ID, "000000"); err != ErrInvalidTwoFactorCode {
+		t.Fatalf("expected ErrInvalidTwoFactorCode, got %v", err)
+	}
+
+	code, _ := totp.GenerateCode(secret, clock)
+	if err := s.Disable(user.ID, code); err != nil {
+		t.Fatalf("Disable() failed: %v", err)
+	}
+	if enabled, _ := s.IsEnabled(user.ID); enabled {
+		t.Error("2FA should be disabled")
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend/internal/repository"
+	"backend/pkg/blobstore"
+)
+
+// discardUploads deletes the uploaded files at webPaths that nothing refers to anymore, so it must run
+// after the rows that referred to them are gone. Paths that are not uploads are skipped.
+func discardUploads(db *sql.DB, uploads *blobstore.Server, webPaths []string) error {
+	var errs []error
+	for _, webPath := range webPaths {
+		key, ok := uploads.KeyOf(webPath)
+		if !ok {
+			continue
+		}
+		used, err := repository.UploadInUse(db, webPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !used {
+			errs = append(errs, uploads.Store.Delete(context.Background(), key))
+		}
+	}
+	return errors.Join(errs...)
+}
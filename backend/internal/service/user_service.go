@@ -4,6 +4,8 @@ import (
 	"backend/internal/model"
 	"backend/internal/repository"
 	"backend/internal/utils"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"net/mail"
@@ -80,6 +82,42 @@ func (s *UserService) RegisterUser(user *model.User) (*RegistrationErrors, error
 	return nil, s.Repo.CreateUser(user)
 }
 
+// RegisterExternalUser creates an account for a user signing in through an identity provider.
+// It runs the same validation as RegisterUser except for the password: the account gets a random
+// one the user never learns, so it can only be used through its linked identity.
+func (s *UserService) RegisterExternalUser(user *model.User) (*RegistrationErrors, error) {
+	errors := &RegistrationErrors{}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	user.Password = hex.EncodeToString(buf)
+
+	s.validateRequiredFields(user, errors)
+	s.validateEmail(user.Email, errors)
+	s.validateAge(user.DOB, errors)
+	s.validateOptionalFields(user, errors)
+	s.sanitizeInput(user)
+	s.checkDuplicates(user, errors)
+
+	if errors.HasErrors() {
+		return errors, nil
+	}
+
+	user.ID = utils.GenerateUUID()
+	if user.ProfileVisibility == "" {
+		user.ProfileVisibility = "public"
+	}
+
+	hashed, err := utils.HashPassword(user.Password)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hashed
+	return nil, s.Repo.CreateUser(user)
+}
+
 // validateRequiredFields checks all mandatory registration fields
 func (s *UserService) validateRequiredFields(user *model.User, errors *RegistrationErrors) {
 	// Check email is provided and not empty
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+)
+
+// SessionCookieName is the name of the cookie holding the session ID
+const SessionCookieName = "social-network"
+
+// SetSessionCookie sets the session cookie, used on login and whenever a session is renewed
+func SetSessionCookie(w http.ResponseWriter, sessionID string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Expires:  expiresAt,
+	})
+}
@@ -1,64 +1,163 @@
 package utils
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 
-	"github.com/google/uuid"
+	"backend/pkg/blobstore"
+	"backend/pkg/imaging"
 )
 
+// UploadsPath is the one path uploads are served under, by Uploads. The paths stored for
+// uploaded images are UploadsPath followed by the key of their blob.
+const UploadsPath = "/uploads/"
+
+// LegacyUploadsDir is where uploads were written before they had a store, under the frontend's public files.
+// main moves them into Uploads, where their paths, UploadsPath followed by "posts/<uuid>.ext", still point.
+const LegacyUploadsDir = "../frontend/public/uploads"
+
+// Uploads stores and serves uploaded files. main replaces it with the store configured in the environment.
+var Uploads = blobstore.NewServer(blobstore.NewLocal(blobstore.DefaultDir), UploadsPath, nil)
+
+// HandlePostImageUpload saves the single image sent under formName at its full size, see savePostImage.
 func HandlePostImageUpload(r *http.Request, maxUploadSize int64, formName string) (sql.NullString, error) {
-	fmt.Println("weiss")
-	file, header, err := r.FormFile(formName)
+	_, header, err := r.FormFile(formName)
 	if err != nil {
-		fmt.Println(err.Error())
 		return sql.NullString{}, nil
 	}
-	defer file.Close()
 
-	if header.Size > maxUploadSize {
-		return sql.NullString{}, errors.New("File too large (max 20MB)")
+	image, err := savePostImage(r.Context(), header, maxUploadSize, imaging.Full)
+	if err != nil {
+		return sql.NullString{}, err
 	}
+	return sql.NullString{String: image.URL, Valid: true}, nil
+}
 
-	buff := make([]byte, 512)
-	if _, err := file.Read(buff); err != nil {
-		return sql.NullString{}, errors.New("Invalid file")
+// UploadedImage is an image saved by HandlePostImageUploads. URL is the full size image.
+type UploadedImage struct {
+	URL           string
+	ThumbnailURL  string
+	FeedURL       string
+	Width         int
+	Height        int
+	Blurhash      string
+	DominantColor string
+
+	created []string // keys of the blobs this upload added, as opposed to ones an identical file already stored
+}
+
+// Files returns the web paths of every file saved for the image
+func (u UploadedImage) Files() []string {
+	var files []string
+	for _, url := range []string{u.URL, u.FeedURL, u.ThumbnailURL} {
+		if url != "" {
+			files = append(files, url)
+		}
 	}
+	return files
+}
 
-	if _, err := file.Seek(0, 0); err != nil {
-		return sql.NullString{}, errors.New("File error")
+// HandlePostImageUploads saves every file sent under formName, at most max of them, in the order they were sent,
+// each with its thumbnail and feed sized variants. If one of them is rejected, the ones already saved are removed again.
+func HandlePostImageUploads(r *http.Request, maxUploadSize int64, formName string, max int) ([]UploadedImage, error) {
+	if r.MultipartForm == nil {
+		if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+			return nil, nil
+		}
+	}
+	headers := r.MultipartForm.File[formName]
+	if len(headers) > max {
+		return nil, fmt.Errorf("At most %d images are allowed", max)
 	}
 
-	filetype := http.DetectContentType(buff)
-	if filetype != "image/jpeg" && filetype != "image/png" && filetype != "image/gif" {
-		return sql.NullString{}, errors.New("Only JPEG, PNG and GIF images are allowed")
+	var saved []UploadedImage
+	for _, header := range headers {
+		image, err := savePostImage(r.Context(), header, maxUploadSize, imaging.Thumbnail, imaging.Feed, imaging.Full)
+		if err != nil {
+			for _, s := range saved {
+				removeBlobs(r.Context(), s.created)
+			}
+			return nil, err
+		}
+		saved = append(saved, image)
+	}
+	return saved, nil
+}
+
+// imageProcessor re-encodes every upload, so that what is served carries no EXIF or other metadata
+var imageProcessor = imaging.NewProcessor()
+
+// savePostImage processes an uploaded image into the given sizes, one of which must be imaging.Full,
+// and stores each of them in Uploads under the hash of its content. The original file is never stored.
+func savePostImage(ctx context.Context, header *multipart.FileHeader, maxUploadSize int64, sizes ...imaging.Size) (UploadedImage, error) {
+	if header.Size > maxUploadSize {
+		return UploadedImage{}, errors.New("File too large (max 20MB)")
 	}
 
-	ext := filepath.Ext(header.Filename)
-	filename := uuid.New().String() + ext
-	filePath := filepath.Join("../frontend/public/uploads", "posts", filename)
+	file, err := header.Open()
+	if err != nil {
+		return UploadedImage{}, errors.New("Invalid file")
+	}
+	defer file.Close()
 
-	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-		return sql.NullString{}, errors.New("Unable to create upload directory")
+	data, err := io.ReadAll(io.LimitReader(file, maxUploadSize+1))
+	if err != nil || len(data) == 0 {
+		return UploadedImage{}, errors.New("Invalid file")
+	}
+	if int64(len(data)) > maxUploadSize {
+		return UploadedImage{}, errors.New("File too large (max 20MB)")
+	}
+	filetype := http.DetectContentType(data)
+	if filetype != "image/jpeg" && filetype != "image/png" && filetype != "image/gif" {
+		return UploadedImage{}, errors.New("Only JPEG, PNG and GIF images are allowed")
 	}
 
-	dst, err := os.Create(filePath)
+	result, err := imageProcessor.Process(data, sizes...)
+	if errors.Is(err, imaging.ErrTooLarge) {
+		return UploadedImage{}, errors.New("Image dimensions too large")
+	}
 	if err != nil {
-		return sql.NullString{}, errors.New("Failed to create file")
+		return UploadedImage{}, errors.New("Invalid image")
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		return sql.NullString{}, errors.New("Failed to save file")
+	image := UploadedImage{Blurhash: result.Blurhash, DominantColor: result.DominantColor}
+	for _, variant := range result.Variants {
+		key := blobstore.ContentKey("posts", variant.Data, variant.Ext)
+		exists, err := Uploads.Store.Exists(ctx, key)
+		if err == nil && !exists {
+			err = Uploads.Store.Put(ctx, key, variant.Data, mime.TypeByExtension(variant.Ext))
+			image.created = append(image.created, key)
+		}
+		if err != nil {
+			log.Printf("Failed to store upload %s: %v", key, err)
+			removeBlobs(ctx, image.created)
+			return UploadedImage{}, errors.New("Failed to save file")
+		}
+
+		url := Uploads.URL(key)
+		switch variant.Size {
+		case imaging.Thumbnail:
+			image.ThumbnailURL = url
+		case imaging.Feed:
+			image.FeedURL = url
+		default:
+			image.URL, image.Width, image.Height = url, variant.Width, variant.Height
+		}
 	}
+	return image, nil
+}
 
-	// Always return a web-accessible URL under Next.js public/ dir
-	webPath := "/uploads/posts/" + filename
-	fmt.Println("saved image web path:", webPath)
-	return sql.NullString{String: webPath, Valid: true}, nil
+func removeBlobs(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := Uploads.Store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to remove upload %s: %v", key, err)
+		}
+	}
 }
@@ -0,0 +1,56 @@
+// Package blobstore keeps uploaded files in a local directory or an S3 compatible bucket, and
+// serves them under a single URL path with signed, time-limited URLs for private files.
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+)
+
+// BlobStore holds blobs under slash separated keys such as "posts/3f2a....jpg"
+type BlobStore interface {
+	// Put stores data under key, replacing any blob already there
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the blob under key, or returns ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether there is a blob under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the blob under key. Deleting a blob that is not there is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Errors returned by every BlobStore
+var (
+	ErrNotFound   = errors.New("blobstore: blob not found")
+	ErrInvalidKey = errors.New("blobstore: invalid key")
+)
+
+// ContentKey names a blob after the SHA-256 of its content, under prefix. The same file uploaded
+// twice gets the same key, so callers must check nothing else refers to a blob before deleting it.
+func ContentKey(prefix string, data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	return prefix + "/" + hex.EncodeToString(sum[:]) + ext
+}
+
+// ValidKey reports whether key is a relative path made of letters, digits and "-_.", with no
+// empty, "." or ".." segments, so that it is safe both as a file path and in a URL.
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, c := range segment {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+				return false
+			}
+		}
+	}
+	return true
+}
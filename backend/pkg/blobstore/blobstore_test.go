@@ -0,0 +1,205 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/pkg/blobstore/blobstoretest"
+)
+
+func TestStores(t *testing.T) {
+	minio := blobstoretest.NewS3()
+	defer minio.Close()
+
+	stores := map[string]BlobStore{
+		"local": NewLocal(t.TempDir()),
+		"s3": NewS3(S3Config{
+			Endpoint:        minio.URL,
+			Region:          minio.Region,
+			Bucket:          minio.Bucket,
+			AccessKeyID:     minio.AccessKeyID,
+			SecretAccessKey: minio.SecretAccessKey,
+		}),
+	}
+	ctx := context.Background()
+	for name, store := range stores {
+		key := ContentKey("posts", []byte("image"), ".png")
+		if err := store.Put(ctx, key, []byte("image"), "image/png"); err != nil {
+			t.Fatalf("%s: Put() failed: %v", name, err)
+		}
+		if ok, err := store.Exists(ctx, key); err != nil || !ok {
+			t.Errorf("%s: expected the blob to exist, got %v, %v", name, ok, err)
+		}
+		blob, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("%s: Get() failed: %v", name, err)
+		}
+		data, _ := io.ReadAll(blob)
+		blob.Close()
+		if string(data) != "image" {
+			t.Errorf("%s: expected the stored content back, got %q", name, data)
+		}
+
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatalf("%s: Delete() failed: %v", name, err)
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			t.Errorf("%s: expected deleting a missing blob to succeed, got %v", name, err)
+		}
+		if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound after Delete(), got %v", name, err)
+		}
+		if ok, err := store.Exists(ctx, key); err != nil || ok {
+			t.Errorf("%s: expected the blob to be gone, got %v, %v", name, ok, err)
+		}
+		if err := store.Put(ctx, "../escape.png", []byte("x"), "image/png"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: expected ErrInvalidKey, got %v", name, err)
+		}
+	}
+
+	if _, ok := minio.Object(ContentKey("posts", []byte("image"), ".png")); ok {
+		t.Error("expected the object to be deleted from the bucket")
+	}
+	wrongSecret := NewS3(S3Config{Endpoint: minio.URL, Region: minio.Region, Bucket: minio.Bucket,
+		AccessKeyID: minio.AccessKeyID, SecretAccessKey: "wrong"})
+	if err := wrongSecret.Put(ctx, "posts/a.png", []byte("x"), "image/png"); err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("expected the signature to be rejected, got %v", err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	legacy := filepath.Join(t.TempDir(), "uploads")
+	os.MkdirAll(filepath.Join(legacy, "posts"), 0o755)
+	os.WriteFile(filepath.Join(legacy, "posts", "0b7e.jpg"), []byte("old"), 0o644)
+	os.WriteFile(filepath.Join(legacy, "posts", "a b.jpg"), []byte("odd"), 0o644)
+	store := NewLocal(t.TempDir())
+	ctx := context.Background()
+	store.Put(ctx, "posts/ffff.png", []byte("new"), "image/png")
+
+	imported, err := Import(ctx, store, legacy)
+	if err != nil || imported != 1 {
+		t.Fatalf("Import() = %d, %v", imported, err)
+	}
+	blob, err := store.Get(ctx, "posts/0b7e.jpg")
+	if err != nil {
+		t.Fatalf("expected the file under its old path, got %v", err)
+	}
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(data) != "old" {
+		t.Errorf("expected the file's content, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "posts", "0b7e.jpg")); !os.IsNotExist(err) {
+		t.Errorf("expected the imported file to be moved, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "posts", "a b.jpg")); err != nil {
+		t.Errorf("expected files that are not valid keys to stay, got %v", err)
+	}
+	if imported, err := Import(ctx, store, legacy); err != nil || imported != 0 {
+		t.Errorf("expected nothing left to import, got %d, %v", imported, err)
+	}
+	if imported, err := Import(ctx, store, filepath.Join(legacy, "missing")); err != nil || imported != 0 {
+		t.Errorf("expected a missing directory to import nothing, got %d, %v", imported, err)
+	}
+}
+
+func TestContentKey(t *testing.T) {
+	a, b := ContentKey("posts", []byte("one"), ".jpg"), ContentKey("posts", []byte("one"), ".jpg")
+	if a != b || !strings.HasPrefix(a, "posts/") || !strings.HasSuffix(a, ".jpg") || !ValidKey(a) {
+		t.Errorf("expected a stable valid key, got %q and %q", a, b)
+	}
+	if a == ContentKey("posts", []byte("two"), ".jpg") {
+		t.Error("expected different content to get a different key")
+	}
+	for _, key := range []string{"", "/posts/a.png", "posts//a.png", "posts/../a.png", "posts/a b.png", `posts\a.png`} {
+		if ValidKey(key) {
+			t.Errorf("expected %q to be rejected", key)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := NewServer(NewLocal(t.TempDir()), "/uploads/", []byte("secret"))
+	s.Now = func() time.Time { return now }
+	ctx := context.Background()
+	s.Store.Put(ctx, "posts/public.png", []byte("public"), "image/png")
+	s.Store.Put(ctx, "private/exports/archive.zip", []byte("private"), "application/zip")
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	rec := get(s.URL("posts/public.png"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "public" || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("expected the public blob, got %d %q %q", rec.Code, rec.Body.String(), rec.Header().Get("Content-Type"))
+	}
+	if cc := rec.Header().Get("Cache-Control"); strings.Contains(cc, "immutable") || !strings.Contains(cc, "no-cache") {
+		t.Errorf("expected a public blob to be revalidated, got %q", cc)
+	}
+	etag := rec.Header().Get("ETag")
+	req := httptest.NewRequest(http.MethodGet, s.URL("posts/public.png"), nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if etag == "" || rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected a 304 for the ETag %q, got %d", etag, rec.Code)
+	}
+	s.Store.Put(ctx, "posts/gone.png", []byte("gone"), "image/png")
+	s.Store.Delete(ctx, "posts/gone.png")
+	req = httptest.NewRequest(http.MethodGet, s.URL("posts/gone.png"), nil)
+	req.Header.Set("If-None-Match", `"posts/gone.png"`)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected a deleted blob not to be revalidated, got %d", rec.Code)
+	}
+
+	signed := s.SignedURL("private/exports/archive.zip", time.Hour)
+	if key, ok := s.KeyOf(signed); !ok || key != "private/exports/archive.zip" {
+		t.Errorf("expected the key back from the signed URL, got %q", key)
+	}
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"unsigned private", s.URL("private/exports/archive.zip"), http.StatusNotFound},
+		{"signed private", signed, http.StatusOK},
+		{"tampered", strings.Replace(signed, "archive.zip", "other.zip", 1), http.StatusNotFound},
+		{"bad signature", signed[:len(signed)-1] + "0", http.StatusNotFound},
+		{"missing", s.URL("posts/missing.png"), http.StatusNotFound},
+		{"escape", "/uploads/../secrets.txt", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if rec := get(tt.url); rec.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.code, rec.Code)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	if rec := get(signed); rec.Code != http.StatusNotFound {
+		t.Errorf("expected an expired URL to be refused, got %d", rec.Code)
+	}
+
+	for url, want := range map[string]string{
+		s.URL("posts/public.png"):          "posts/public.png",
+		s.URL("posts/public.png") + "?v=1": "posts/public.png",
+		"/uploads/../secrets.txt":          "",
+		"/avatars/a.png":                   "",
+	} {
+		if key, ok := s.KeyOf(url); key != want || ok != (want != "") {
+			t.Errorf("KeyOf(%q) = %q, %v, want %q", url, key, ok, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package blobstore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServerFromEnv builds the Server for basePath from BLOB_STORE, "local" (the default) or "s3".
+// A local store keeps its files in UPLOAD_DIR, ./uploads by default. An s3 store needs S3_ENDPOINT,
+// S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY, and takes S3_REGION, us-east-1 by default.
+// UPLOAD_SIGNING_KEY signs the URLs of private blobs, it must be set for them to outlive a restart.
+// getenv is usually os.Getenv.
+func ServerFromEnv(getenv func(string) string, basePath string) (*Server, error) {
+	var store BlobStore
+	switch kind := strings.ToLower(strings.TrimSpace(getenv("BLOB_STORE"))); kind {
+	case "", "local":
+		dir := getenv("UPLOAD_DIR")
+		if dir == "" {
+			dir = DefaultDir
+		}
+		store = NewLocal(dir)
+	case "s3":
+		cfg := S3Config{
+			Endpoint:        getenv("S3_ENDPOINT"),
+			Region:          getenv("S3_REGION"),
+			Bucket:          getenv("S3_BUCKET"),
+			AccessKeyID:     getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: getenv("S3_SECRET_ACCESS_KEY"),
+		}
+		if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, fmt.Errorf("blobstore: s3 needs S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY")
+		}
+		if cfg.Region == "" {
+			cfg.Region = "us-east-1"
+		}
+		store = NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("blobstore: unknown BLOB_STORE %q", kind)
+	}
+	return NewServer(store, basePath, []byte(getenv("UPLOAD_SIGNING_KEY"))), nil
+}
@@ -0,0 +1,72 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Import moves the files under dir into store, each under its path relative to dir, so that
+// "posts/<uuid>.jpg" in dir becomes the blob "posts/<uuid>.jpg". A file is removed from dir once
+// its blob is stored, which makes Import safe to run again after it stopped halfway. Files whose
+// path is not a valid key are left where they are. A missing dir imports nothing.
+func Import(ctx context.Context, store BlobStore, dir string) (int, error) {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	imported := 0
+	var emptied []string
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if name != dir {
+				emptied = append(emptied, name)
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !ValidKey(key) {
+			return nil
+		}
+
+		exists, err := store.Exists(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			if err := store.Put(ctx, key, data, mime.TypeByExtension(path.Ext(key))); err != nil {
+				return err
+			}
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+		imported++
+		return nil
+	})
+	if err != nil {
+		return imported, err
+	}
+
+	// directories go deepest first, the ones still holding files stay
+	for i := len(emptied) - 1; i >= 0; i-- {
+		os.Remove(emptied[i])
+	}
+	os.Remove(dir)
+	return imported, nil
+}
@@ -0,0 +1,172 @@
+package blobstore
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrivatePrefix starts the keys of blobs that are only served through a signed URL
+const PrivatePrefix = "private/"
+
+// Server serves the blobs of a store under BasePath, the one URL path uploads are reachable at
+// whichever store keeps them. A blob's URL is BasePath followed by its key.
+type Server struct {
+	Store      BlobStore
+	BasePath   string           // e.g. "/uploads/", with the trailing slash
+	SigningKey []byte           // Signs the URLs of private blobs
+	Now        func() time.Time // Defaults to time.Now
+}
+
+// NewServer creates and returns a new instance of Server. Without a signing key a random one is
+// made, so signed URLs stop working when the process restarts.
+func NewServer(store BlobStore, basePath string, signingKey []byte) *Server {
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			panic(err)
+		}
+	}
+	return &Server{Store: store, BasePath: basePath, SigningKey: signingKey, Now: time.Now}
+}
+
+func (s *Server) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
+
+// URL returns the path the blob under key is served at.
+func (s *Server) URL(key string) string {
+	return s.BasePath + key
+}
+
+// KeyOf returns the key of the blob a URL made by URL or SignedURL points to.
+// It reports false for anything else, including paths trying to leave BasePath.
+func (s *Server) KeyOf(url string) (string, bool) {
+	url, _, _ = strings.Cut(url, "?")
+	key, ok := strings.CutPrefix(url, s.BasePath)
+	if !ok || !ValidKey(key) {
+		return "", false
+	}
+	return key, true
+}
+
+// SignedURL returns a URL for the blob under key that works until ttl has passed.
+func (s *Server) SignedURL(key string, ttl time.Duration) string {
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	return s.URL(key) + "?expires=" + expires + "&signature=" + s.sign(key, expires)
+}
+
+func (s *Server) sign(key, expires string) string {
+	return hex.EncodeToString(hmacSHA256(s.SigningKey, []byte(key+"\n"+expires)))
+}
+
+// validSignature checks the expires and signature parameters of a request for key
+func (s *Server) validSignature(key string, r *http.Request) bool {
+	expires, signature := r.URL.Query().Get("expires"), r.URL.Query().Get("signature")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.sign(key, expires)))
+}
+
+// ServeHTTP serves GET and HEAD requests for blobs. Private blobs, and requests carrying a signature,
+// need a valid unexpired signature. Missing blobs and bad signatures both get a 404, so that
+// the existence of a private blob is not given away.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	key, ok := s.KeyOf(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	signed := r.URL.Query().Has("signature")
+	if (signed || strings.HasPrefix(key, PrivatePrefix)) && !s.validSignature(key, r) {
+		http.NotFound(w, r)
+		return
+	}
+	if signed {
+		s.ServeKey(w, r, key, "private, no-store")
+	} else {
+		s.ServeKey(w, r, key, RevalidateCacheControl)
+	}
+}
+
+// RevalidateCacheControl lets caches keep a blob but check with the server before every use, which only
+// costs a 304 thanks to the ETag set by ServeKey. Content addressed keys never change what they point to,
+// but a blob that is public now can be deleted or become private under the same URL.
+const RevalidateCacheControl = "public, no-cache"
+
+// ServeKey sends the blob under key with the given Cache-Control, for callers that have already
+// decided the request may see it. The key is the blob's ETag, so a request already holding it gets
+// a 304 as long as the blob is still there.
+func (s *Server) ServeKey(w http.ResponseWriter, r *http.Request, key, cacheControl string) {
+	etag := `"` + key + `"`
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		exists, err := s.Store.Exists(r.Context(), key)
+		if err != nil {
+			log.Printf("Failed to read blob %s: %v", key, err)
+			http.Error(w, "Failed to read file", http.StatusInternalServerError)
+			return
+		}
+		if exists {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", cacheControl)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	blob, err := s.Store.Get(r.Context(), key)
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to read blob %s: %v", key, err)
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", etag)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("Failed to send blob %s: %v", key, err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, compared weakly as RFC 9110 asks.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
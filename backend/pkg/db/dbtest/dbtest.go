@@ -0,0 +1,69 @@
+// Package dbtest opens in-memory SQLite databases with the application's schema, for tests.
+package dbtest
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// MigrationsDir returns the directory of the migrations, wherever the test using it runs from.
+func MigrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "migrations")
+}
+
+// New returns an in-memory database with every up migration applied in order, with foreign keys
+// enforced. It is closed when the test ends.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+	db, rest := NewBefore(t, "")
+	rest()
+	return db
+}
+
+// NewBefore is New for tests of migrations that change existing rows: it applies the migrations
+// before the one called name, and returns a function applying it and the ones after it.
+func NewBefore(t testing.TB, name string) (*sql.DB, func()) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:?_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	// every connection to :memory: is a separate database, so keep a single one
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob(filepath.Join(MigrationsDir(), "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("failed to find migrations in %s: %v", MigrationsDir(), err)
+	}
+	sort.Strings(files)
+	split := 0
+	if name != "" {
+		split = sort.SearchStrings(files, filepath.Join(MigrationsDir(), name+".up.sql"))
+		if split == len(files) || filepath.Base(files[split]) != name+".up.sql" {
+			t.Fatalf("migration %s not found", name)
+		}
+	}
+
+	apply := func(files []string) {
+		t.Helper()
+		for _, file := range files {
+			schema, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("failed to read migration %s: %v", filepath.Base(file), err)
+			}
+			if _, err := db.Exec(string(schema)); err != nil {
+				t.Fatalf("failed to apply migration %s: %v", filepath.Base(file), err)
+			}
+		}
+	}
+	apply(files[:split])
+	return db, func() { apply(files[split:]) }
+}
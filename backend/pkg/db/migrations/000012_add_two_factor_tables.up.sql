@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id VARCHAR(40) PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    enabled INTEGER NOT NULL DEFAULT 0,
+    last_used_step INTEGER NOT NULL DEFAULT 0, -- last accepted time step, rejects code replays
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    confirmed_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(40) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Short-lived tokens issued after a correct password when 2FA is enabled
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_totp_recovery_codes_user_id ON totp_recovery_codes(user_id);
+CREATE INDEX idx_login_challenges_user_id ON login_challenges(user_id);
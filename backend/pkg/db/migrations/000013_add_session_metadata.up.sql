@@ -0,0 +1,9 @@
+-- SQLite cannot add a column with a CURRENT_TIMESTAMP default, so backfill last_used_at instead
+ALTER TABLE sessions ADD COLUMN user_agent VARCHAR(255);
+ALTER TABLE sessions ADD COLUMN ip_address VARCHAR(45);
+ALTER TABLE sessions ADD COLUMN last_used_at TIMESTAMP;
+
+UPDATE sessions SET last_used_at = created_at WHERE last_used_at IS NULL;
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
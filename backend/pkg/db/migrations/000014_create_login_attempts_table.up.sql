@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email VARCHAR(254) NOT NULL,
+    user_id VARCHAR(40) NULL, -- set when the email belongs to an account
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(255),
+    success INTEGER NOT NULL DEFAULT 0,
+    reason TEXT NOT NULL DEFAULT '', -- 'invalid_credentials', 'invalid_2fa_code', 'locked' or '' on success
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_login_attempts_email ON login_attempts(email, id);
+CREATE INDEX idx_login_attempts_ip_address ON login_attempts(ip_address, id);
+CREATE INDEX idx_login_attempts_user_id ON login_attempts(user_id, id);
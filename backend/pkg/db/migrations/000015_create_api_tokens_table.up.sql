@@ -0,0 +1,15 @@
+-- Personal access tokens used with "Authorization: Bearer" by scripts and bots
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE, -- SHA-256 of the token, the token itself is never stored
+    prefix VARCHAR(16) NOT NULL,            -- first characters of the token, shown to help users tell tokens apart
+    scopes TEXT NOT NULL,                   -- space separated list of granted scopes
+    last_used_at TIMESTAMP NULL,
+    expires_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_api_tokens_user_id ON api_tokens(user_id);
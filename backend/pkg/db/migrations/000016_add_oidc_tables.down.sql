@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS oidc_pending_signups;
+DROP TABLE IF EXISTS oidc_login_states;
+DROP TABLE IF EXISTS user_identities;
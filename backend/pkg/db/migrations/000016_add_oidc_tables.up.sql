@@ -0,0 +1,37 @@
+-- External identities linked to local accounts, one row per provider account
+CREATE TABLE IF NOT EXISTS user_identities (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(40) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL, -- the provider's "sub" claim
+    email VARCHAR(254) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP NULL,
+    UNIQUE (provider, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Authorization requests in flight, keyed by the SHA-256 of the state parameter
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    id VARCHAR(64) PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(128) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Verified external identities waiting for the user to fill in fields the provider did not supply
+CREATE TABLE IF NOT EXISTS oidc_pending_signups (
+    id VARCHAR(64) PRIMARY KEY, -- SHA-256 of the signup token
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(254) NOT NULL,
+    first_name VARCHAR(30) NOT NULL DEFAULT '',
+    last_name VARCHAR(30) NOT NULL DEFAULT '',
+    picture VARCHAR(255) NOT NULL DEFAULT '',
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
@@ -0,0 +1,25 @@
+-- Accounts scheduled for deletion, purged once purge_after has passed unless cancelled first
+CREATE TABLE IF NOT EXISTS account_deletions (
+    user_id VARCHAR(40) PRIMARY KEY,
+    requested_at TIMESTAMP NOT NULL,
+    purge_after TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Data export jobs, the archive itself is written outside the public uploads directory
+CREATE TABLE IF NOT EXISTS data_exports (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'running', 'ready', 'failed')),
+    file_path TEXT,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP NULL,
+    completed_at TIMESTAMP NULL,
+    expires_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_account_deletions_purge_after ON account_deletions(purge_after);
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id);
+CREATE INDEX idx_data_exports_status ON data_exports(status);
@@ -0,0 +1,10 @@
+-- Site-wide roles, separate from the per-group roles in group_members
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK(role IN ('user', 'moderator', 'admin'));
+
+-- A suspension without an end date lasts until a moderator reinstates the user
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN suspended_until TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN suspension_reason TEXT NULL;
+ALTER TABLE users ADD COLUMN suspended_by VARCHAR(40) NULL;
+
+CREATE INDEX idx_users_role ON users(role);
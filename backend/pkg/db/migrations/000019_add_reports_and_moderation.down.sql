@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_warnings;
+DROP TABLE IF EXISTS reports;
+ALTER TABLE groups DROP COLUMN hidden_at;
+ALTER TABLE messages DROP COLUMN hidden_at;
+ALTER TABLE comments DROP COLUMN hidden_at;
+ALTER TABLE posts DROP COLUMN hidden_at;
@@ -0,0 +1,46 @@
+-- Content hidden by a moderator stays in the database for appeals but is no longer shown
+ALTER TABLE posts ADD COLUMN hidden_at TIMESTAMP NULL;
+ALTER TABLE comments ADD COLUMN hidden_at TIMESTAMP NULL;
+ALTER TABLE messages ADD COLUMN hidden_at TIMESTAMP NULL;
+ALTER TABLE groups ADD COLUMN hidden_at TIMESTAMP NULL;
+
+CREATE TABLE IF NOT EXISTS reports (
+    id VARCHAR(40) PRIMARY KEY,
+    reporter_id VARCHAR(40) NOT NULL,
+    target_type TEXT NOT NULL CHECK(target_type IN ('post', 'comment', 'message', 'group', 'user')),
+    target_id VARCHAR(40) NOT NULL,
+    target_user_id VARCHAR(40) NULL, -- author of the reported content, or the reported user
+    reason TEXT NOT NULL CHECK(reason IN ('spam', 'harassment', 'hate', 'violence', 'nudity', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK(status IN ('open', 'claimed', 'resolved')),
+    claimed_by VARCHAR(40) NULL,
+    claimed_at TIMESTAMP NULL,
+    resolution TEXT NULL CHECK(resolution IN ('hide', 'warn', 'suspend', 'dismiss')),
+    resolution_note TEXT NULL,
+    resolved_by VARCHAR(40) NULL,
+    resolved_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (claimed_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Warnings issued to users when a report against them is resolved with 'warn'
+CREATE TABLE IF NOT EXISTS user_warnings (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL,
+    report_id VARCHAR(40) NULL,
+    moderator_id VARCHAR(40) NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE SET NULL,
+    FOREIGN KEY (moderator_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- A user can have one unresolved report per target, and report it again after a resolution
+CREATE UNIQUE INDEX idx_reports_open_per_reporter ON reports(reporter_id, target_type, target_id) WHERE status != 'resolved';
+CREATE INDEX idx_reports_status_created_at ON reports(status, created_at);
+CREATE INDEX idx_reports_target ON reports(target_type, target_id);
+CREATE INDEX idx_user_warnings_user_id ON user_warnings(user_id);
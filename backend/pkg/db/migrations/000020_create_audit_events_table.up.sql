@@ -0,0 +1,27 @@
+-- Security and moderation events. Rows outlive the users they mention, so there are no foreign keys.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL,
+    actor_id VARCHAR(40) NULL, -- NULL for actions taken by the system
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id VARCHAR(40) NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    diff TEXT NOT NULL DEFAULT '{}', -- JSON object of changed fields: {"field": {"from": ..., "to": ...}}
+    created_at TIMESTAMP NOT NULL
+);
+
+-- The log is append-only
+CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit events cannot be changed');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit events cannot be deleted');
+END;
+
+CREATE INDEX idx_audit_events_action ON audit_events(action, created_at);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id, created_at);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id);
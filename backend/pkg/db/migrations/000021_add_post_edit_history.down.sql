@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS post_edits;
+ALTER TABLE posts DROP COLUMN updated_at;
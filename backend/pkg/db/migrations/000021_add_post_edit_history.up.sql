@@ -0,0 +1,18 @@
+ALTER TABLE posts ADD COLUMN updated_at TIMESTAMP NULL; -- NULL until the post is first edited
+
+-- The version of a post before each edit
+CREATE TABLE IF NOT EXISTS post_edits (
+    id VARCHAR(40) PRIMARY KEY,
+    post_id VARCHAR(40) NOT NULL,
+    editor_id VARCHAR(40) NULL,
+    title VARCHAR(77) NOT NULL,
+    content TEXT NOT NULL,
+    visibility VARCHAR(14) NOT NULL,
+    post_image VARCHAR(255) NULL,
+    allowed_followers TEXT NOT NULL DEFAULT '[]', -- JSON array of the user IDs in private_posts
+    edited_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (editor_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_post_edits_post_id ON post_edits(post_id, edited_at);
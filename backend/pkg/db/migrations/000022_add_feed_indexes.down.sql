@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_private_posts_user_id;
+DROP INDEX IF EXISTS idx_followers_followed_id;
+DROP INDEX IF EXISTS idx_posts_group_id;
+DROP INDEX IF EXISTS idx_posts_user_id;
+DROP INDEX IF EXISTS idx_posts_feed;
@@ -0,0 +1,8 @@
+-- The home feed walks visible non-group posts newest first. created_at is compared through
+-- julianday() because rows hold both CURRENT_TIMESTAMP and Go formatted timestamps.
+CREATE INDEX IF NOT EXISTS idx_posts_feed ON posts(julianday(created_at) DESC, id DESC)
+    WHERE group_id IS NULL AND hidden_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
+CREATE INDEX IF NOT EXISTS idx_posts_group_id ON posts(group_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_followers_followed_id ON followers(followed_id, status);
+CREATE INDEX IF NOT EXISTS idx_private_posts_user_id ON private_posts(user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS author_affinities;
+DROP TABLE IF EXISTS post_scores;
@@ -0,0 +1,22 @@
+-- Engagement of recent posts, rebuilt periodically for the ranked feed
+CREATE TABLE IF NOT EXISTS post_scores (
+    post_id VARCHAR(40) PRIMARY KEY,
+    likes INTEGER NOT NULL DEFAULT 0,
+    dislikes INTEGER NOT NULL DEFAULT 0,
+    comments INTEGER NOT NULL DEFAULT 0,
+    engagement REAL NOT NULL DEFAULT 0, -- from 0 to 1, see service.FeedRankingService
+    computed_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- How much each user interacts with each author, rebuilt together with post_scores
+CREATE TABLE IF NOT EXISTS author_affinities (
+    user_id VARCHAR(40) NOT NULL,
+    author_id VARCHAR(40) NOT NULL,
+    interactions INTEGER NOT NULL,
+    affinity REAL NOT NULL, -- from 0 to 1
+    computed_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, author_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+);
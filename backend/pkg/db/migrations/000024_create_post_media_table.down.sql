@@ -0,0 +1,2 @@
+ALTER TABLE post_edits DROP COLUMN media;
+DROP TABLE IF EXISTS post_media;
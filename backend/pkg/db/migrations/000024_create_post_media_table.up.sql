@@ -0,0 +1,20 @@
+-- The images of a post in display order. posts.post_image keeps the first one for older clients.
+CREATE TABLE IF NOT EXISTS post_media (
+    id VARCHAR(40) PRIMARY KEY,
+    post_id VARCHAR(40) NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT '',
+    width INTEGER NOT NULL DEFAULT 0, -- 0 when unknown, as for images uploaded before galleries
+    height INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    UNIQUE (post_id, position)
+);
+
+INSERT INTO post_media (id, post_id, url, position, created_at)
+SELECT lower(hex(randomblob(16))), id, post_image, 0, COALESCE(created_at, CURRENT_TIMESTAMP)
+FROM posts
+WHERE post_image IS NOT NULL AND post_image != '';
+
+ALTER TABLE post_edits ADD COLUMN media TEXT NOT NULL DEFAULT '[]'; -- JSON array of the post's post_media rows
@@ -0,0 +1,4 @@
+ALTER TABLE post_media DROP COLUMN dominant_color;
+ALTER TABLE post_media DROP COLUMN blurhash;
+ALTER TABLE post_media DROP COLUMN feed_url;
+ALTER TABLE post_media DROP COLUMN thumbnail_url;
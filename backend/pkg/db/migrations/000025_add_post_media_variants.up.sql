@@ -0,0 +1,6 @@
+-- Smaller copies of each image and a placeholder to show while they load.
+-- Images uploaded before they were generated keep '' and are served from url.
+ALTER TABLE post_media ADD COLUMN thumbnail_url VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE post_media ADD COLUMN feed_url VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE post_media ADD COLUMN blurhash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE post_media ADD COLUMN dominant_color VARCHAR(7) NOT NULL DEFAULT ''; -- as #rrggbb
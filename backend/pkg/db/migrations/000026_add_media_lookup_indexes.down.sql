@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_post_media_thumbnail_url;
+DROP INDEX IF EXISTS idx_post_media_feed_url;
+DROP INDEX IF EXISTS idx_post_media_url;
+DROP INDEX IF EXISTS idx_posts_post_image;
+DROP INDEX IF EXISTS idx_users_imgurl;
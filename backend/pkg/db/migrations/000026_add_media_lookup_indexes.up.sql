@@ -0,0 +1,6 @@
+-- Uploads are looked up by path on every request for them, see repository.MediaAccess
+CREATE INDEX IF NOT EXISTS idx_users_imgurl ON users(imgurl);
+CREATE INDEX IF NOT EXISTS idx_posts_post_image ON posts(post_image);
+CREATE INDEX IF NOT EXISTS idx_post_media_url ON post_media(url);
+CREATE INDEX IF NOT EXISTS idx_post_media_feed_url ON post_media(feed_url);
+CREATE INDEX IF NOT EXISTS idx_post_media_thumbnail_url ON post_media(thumbnail_url);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS trending_tags;
+DROP INDEX IF EXISTS idx_post_tags_comment_id;
+DROP INDEX IF EXISTS idx_post_tags_tag;
+DROP INDEX IF EXISTS idx_post_tags_source;
+DROP TABLE IF EXISTS post_tags;
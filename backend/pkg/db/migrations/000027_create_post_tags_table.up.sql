@@ -0,0 +1,21 @@
+-- Hashtags used in posts and their comments. comment_id is NULL for tags in the post's own title and content.
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id VARCHAR(40) NOT NULL,
+    comment_id TEXT NULL,
+    tag VARCHAR(50) NOT NULL, -- lowercased, without the #
+    created_at TIMESTAMP NOT NULL, -- when the tag was added, which edits can make later than the post
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_tags_source ON post_tags(post_id, COALESCE(comment_id, ''), tag);
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON post_tags(tag, created_at);
+CREATE INDEX IF NOT EXISTS idx_post_tags_comment_id ON post_tags(comment_id);
+
+-- The most used tags of late, rebuilt periodically
+CREATE TABLE IF NOT EXISTS trending_tags (
+    tag VARCHAR(50) PRIMARY KEY,
+    users INTEGER NOT NULL, -- people who used it within the window
+    score REAL NOT NULL, -- users weighted by how recent they are, see service.TagService
+    computed_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_users_nickname;
+DROP INDEX IF EXISTS idx_notifications_mention_id;
+DROP INDEX IF EXISTS idx_notifications_user_id;
+DROP TABLE IF EXISTS notifications;
+DROP INDEX IF EXISTS idx_mentions_user_id;
+DROP INDEX IF EXISTS idx_mentions_message_id;
+DROP INDEX IF EXISTS idx_mentions_comment_id;
+DROP INDEX IF EXISTS idx_mentions_post_id;
+DROP TABLE IF EXISTS mentions;
@@ -0,0 +1,46 @@
+-- Users mentioned by @nickname. A mention is in a post's own text when only post_id is set,
+-- in a comment when comment_id is set too, and in a chat message when message_id is set.
+CREATE TABLE IF NOT EXISTS mentions (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL, -- the mentioned user
+    nickname VARCHAR(30) NOT NULL, -- as written, without the @
+    author_id VARCHAR(40) NOT NULL,
+    post_id VARCHAR(40) NULL,
+    comment_id TEXT NULL,
+    message_id VARCHAR(40) NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_post_id ON mentions(post_id);
+CREATE INDEX IF NOT EXISTS idx_mentions_comment_id ON mentions(comment_id);
+CREATE INDEX IF NOT EXISTS idx_mentions_message_id ON mentions(message_id);
+CREATE INDEX IF NOT EXISTS idx_mentions_user_id ON mentions(user_id);
+
+-- Things a user is told about, such as being mentioned
+CREATE TABLE IF NOT EXISTS notifications (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL, -- who is notified
+    type VARCHAR(30) NOT NULL, -- see model.Notification
+    actor_id VARCHAR(40) NULL, -- who caused it
+    mention_id VARCHAR(40) NULL,
+    post_id VARCHAR(40) NULL,
+    comment_id TEXT NULL,
+    message_id VARCHAR(40) NULL,
+    created_at TIMESTAMP NOT NULL,
+    read_at TIMESTAMP NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (mention_id) REFERENCES mentions(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_mention_id ON notifications(mention_id);
+CREATE INDEX IF NOT EXISTS idx_users_nickname ON users(nickname COLLATE NOCASE);
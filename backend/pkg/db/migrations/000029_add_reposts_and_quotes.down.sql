@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS trg_posts_delete_reposts;
+DROP INDEX IF EXISTS idx_posts_quote_of;
+DROP INDEX IF EXISTS idx_posts_repost_of;
+ALTER TABLE posts DROP COLUMN quote_of;
+ALTER TABLE posts DROP COLUMN repost_of;
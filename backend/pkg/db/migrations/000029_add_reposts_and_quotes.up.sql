@@ -0,0 +1,14 @@
+-- A repost shares another post as it is and has no title or content of its own. A quote shares it below
+-- the quoting user's own title and content. Both point at the original post, never at another repost.
+ALTER TABLE posts ADD COLUMN repost_of VARCHAR(40) NULL;
+ALTER TABLE posts ADD COLUMN quote_of VARCHAR(40) NULL; -- kept after the quoted post is deleted, so quotes show a tombstone
+
+-- A user reposts a post at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_repost_of ON posts(repost_of, user_id) WHERE repost_of IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_quote_of ON posts(quote_of) WHERE quote_of IS NOT NULL;
+
+-- Reposts go away with the post they share, however it is deleted, including with its author's account
+CREATE TRIGGER IF NOT EXISTS trg_posts_delete_reposts AFTER DELETE ON posts
+BEGIN
+    DELETE FROM posts WHERE repost_of = OLD.id;
+END;
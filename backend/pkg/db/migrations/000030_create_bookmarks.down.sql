@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_bookmark_collection_posts_post_id;
+DROP INDEX IF EXISTS idx_bookmark_collection_posts_created;
+DROP TABLE IF EXISTS bookmark_collection_posts;
+DROP INDEX IF EXISTS idx_bookmark_collections_name;
+DROP TABLE IF EXISTS bookmark_collections;
+DROP INDEX IF EXISTS idx_bookmarks_post_id;
+DROP INDEX IF EXISTS idx_bookmarks_user_created;
+DROP TABLE IF EXISTS bookmarks;
@@ -0,0 +1,37 @@
+-- Posts users saved for later. Bookmarks are only ever seen by the user who saved them.
+CREATE TABLE IF NOT EXISTS bookmarks (
+    user_id VARCHAR(40) NOT NULL,
+    post_id VARCHAR(40) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created ON bookmarks(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_post_id ON bookmarks(post_id);
+
+-- Named groups of a user's bookmarks, which other users can browse unless private
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    private BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmark_collections_name ON bookmark_collections(user_id, name COLLATE NOCASE);
+
+-- The bookmarks in each collection. A post is in a collection only while its owner has it bookmarked.
+CREATE TABLE IF NOT EXISTS bookmark_collection_posts (
+    collection_id VARCHAR(40) NOT NULL,
+    post_id VARCHAR(40) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (collection_id, post_id),
+    FOREIGN KEY (collection_id) REFERENCES bookmark_collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmark_collection_posts_created ON bookmark_collection_posts(collection_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_bookmark_collection_posts_post_id ON bookmark_collection_posts(post_id);
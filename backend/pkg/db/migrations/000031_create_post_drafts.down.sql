@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_post_drafts_publish_at;
+DROP INDEX IF EXISTS idx_post_drafts_user_id;
+DROP TABLE IF EXISTS post_drafts;
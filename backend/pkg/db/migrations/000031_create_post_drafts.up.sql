@@ -0,0 +1,21 @@
+-- Posts that are not published yet: drafts, and scheduled posts once publish_at is set.
+-- Publishing one moves it to posts, so nothing else ever sees it before then.
+CREATE TABLE IF NOT EXISTS post_drafts (
+    id VARCHAR(40) PRIMARY KEY,
+    user_id VARCHAR(40) NOT NULL,
+    title VARCHAR(77) NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    visibility VARCHAR(14) NOT NULL DEFAULT 'public',
+    group_id VARCHAR(40) NULL,
+    quote_of VARCHAR(40) NULL,
+    allowed_followers TEXT NOT NULL DEFAULT '[]', -- JSON array of user IDs, as in post_edits
+    media TEXT NOT NULL DEFAULT '[]', -- JSON array of the gallery, as in post_edits
+    publish_at TIMESTAMP NULL, -- NULL for drafts that are not scheduled
+    publish_error TEXT NOT NULL DEFAULT '', -- why the scheduled post could not be published, if it failed
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_drafts_user_id ON post_drafts(user_id, updated_at);
+CREATE INDEX IF NOT EXISTS idx_post_drafts_publish_at ON post_drafts(publish_at) WHERE publish_at IS NOT NULL;
@@ -0,0 +1,9 @@
+ALTER TABLE post_drafts DROP COLUMN poll;
+DROP INDEX IF EXISTS idx_poll_votes_post_id;
+DROP INDEX IF EXISTS idx_poll_votes_single_choice;
+DROP TABLE IF EXISTS poll_votes;
+DROP INDEX IF EXISTS idx_poll_options_poll;
+DROP INDEX IF EXISTS idx_poll_options_position;
+DROP TABLE IF EXISTS poll_options;
+DROP INDEX IF EXISTS idx_polls_kind;
+DROP TABLE IF EXISTS polls;
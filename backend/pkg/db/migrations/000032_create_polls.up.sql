@@ -0,0 +1,43 @@
+-- Polls carried by posts, at most one per post
+CREATE TABLE IF NOT EXISTS polls (
+    post_id VARCHAR(40) PRIMARY KEY,
+    multiple_choice BOOLEAN NOT NULL DEFAULT 0,
+    hide_results BOOLEAN NOT NULL DEFAULT 0, -- results are hidden from users until they vote or the poll closes
+    closes_at TIMESTAMP NULL, -- NULL for polls that stay open
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+-- lets votes refer to a poll together with its kind
+CREATE UNIQUE INDEX IF NOT EXISTS idx_polls_kind ON polls(post_id, multiple_choice);
+
+CREATE TABLE IF NOT EXISTS poll_options (
+    id VARCHAR(40) PRIMARY KEY,
+    post_id VARCHAR(40) NOT NULL,
+    text VARCHAR(100) NOT NULL,
+    position INTEGER NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES polls(post_id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_poll_options_position ON poll_options(post_id, position);
+-- lets votes check that the option belongs to the poll
+CREATE UNIQUE INDEX IF NOT EXISTS idx_poll_options_poll ON poll_options(id, post_id);
+
+-- A user votes once for each option they choose, and only for one option of a single choice poll.
+-- multiple_choice copies the poll's, which the foreign key keeps in line.
+CREATE TABLE IF NOT EXISTS poll_votes (
+    post_id VARCHAR(40) NOT NULL,
+    option_id VARCHAR(40) NOT NULL,
+    user_id VARCHAR(40) NOT NULL,
+    multiple_choice BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (option_id, user_id),
+    FOREIGN KEY (post_id, multiple_choice) REFERENCES polls(post_id, multiple_choice) ON DELETE CASCADE,
+    FOREIGN KEY (option_id, post_id) REFERENCES poll_options(id, post_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_poll_votes_single_choice ON poll_votes(post_id, user_id) WHERE multiple_choice = 0;
+CREATE INDEX IF NOT EXISTS idx_poll_votes_post_id ON poll_votes(post_id, user_id);
+
+-- The poll of a draft, as JSON, until the draft is published
+ALTER TABLE post_drafts ADD COLUMN poll TEXT NULL;
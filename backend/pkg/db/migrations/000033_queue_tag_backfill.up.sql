@@ -0,0 +1,8 @@
+-- Posts written before post_tags whose hashtags, and those of their comments, still have to be indexed.
+-- RunTrendingTags works through it once, see TagService.Backfill. Posts indexed since are harmless to redo.
+CREATE TABLE IF NOT EXISTS tag_backfill (
+    post_id VARCHAR(40) PRIMARY KEY,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+INSERT OR IGNORE INTO tag_backfill (post_id) SELECT id FROM posts;
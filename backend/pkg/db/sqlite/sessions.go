@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Session lifetime rules shared by login and the auth middleware
+const (
+	SessionLifetime      = 24 * time.Hour      // Idle time after which a session expires
+	SessionRenewAfter    = 12 * time.Hour      // Remaining lifetime below which an active session is extended
+	SessionMaxLifetime   = 30 * 24 * time.Hour // Hard cap from creation, however active the session is
+	SessionTouchInterval = time.Minute         // Minimum gap between last_used_at writes
+	SessionCleanupPeriod = time.Hour           // How often expired sessions are purged
+	sessionColumns       = "id, user_id, created_at, expires_at, user_agent, ip_address, last_used_at"
+)
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (*Session, error) {
+	var s Session
+	var userAgent, ipAddress sql.NullString
+	var lastUsedAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.UserID, &s.CreatedAt, &s.ExpiresAt, &userAgent, &ipAddress, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	s.UserAgent = userAgent.String
+	s.IPAddress = ipAddress.String
+	s.LastUsedAt = s.CreatedAt
+	if lastUsedAt.Valid {
+		s.LastUsedAt = lastUsedAt.Time
+	}
+	return &s, nil
+}
+
+// GetUserSessions returns the sessions of a user that have not expired yet, most recently used first.
+func GetUserSessions(db *sql.DB, userID string, now time.Time) ([]Session, error) {
+	// julianday compares the instants, stored timestamps may carry different zone offsets
+	rows, err := db.Query(
+		"SELECT "+sessionColumns+" FROM sessions WHERE user_id = ? AND julianday(expires_at) > julianday(?) ORDER BY last_used_at DESC",
+		userID, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+// TouchSession records activity on a session and moves its expiry.
+func TouchSession(db *sql.DB, sessionID string, lastUsedAt, expiresAt time.Time) error {
+	_, err := db.Exec("UPDATE sessions SET last_used_at = ?, expires_at = ? WHERE id = ?", lastUsedAt, expiresAt, sessionID)
+	return err
+}
+
+// DeleteUserSession deletes one session of a user. It returns false if the user has no such session.
+func DeleteUserSession(db *sql.DB, userID, sessionID string) (bool, error) {
+	result, err := db.Exec("DELETE FROM sessions WHERE id = ? AND user_id = ?", sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// DeleteOtherSessions deletes every session of a user except keepSessionID and returns how many were removed.
+func DeleteOtherSessions(db *sql.DB, userID, keepSessionID string) (int64, error) {
+	result, err := db.Exec("DELETE FROM sessions WHERE user_id = ? AND id != ?", userID, keepSessionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PurgeExpiredSessions deletes all sessions that expired before now and returns how many were removed.
+func PurgeExpiredSessions(db *sql.DB, now time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM sessions WHERE julianday(expires_at) <= julianday(?)", now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"backend/pkg/db/dbtest"
+)
+
+func openSessionsTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db := dbtest.New(t)
+
+	for _, id := range []string{"user-1", "user-2"} {
+		_, err := db.Exec(`INSERT INTO users (id, email, fname, lname, dob, password) VALUES (?, ?, 'Test', 'User', '2000-01-01', 'hash')`, id, id+"@example.com")
+		if err != nil {
+			t.Fatalf("Failed to insert user: %v", err)
+		}
+	}
+	return db
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	db := openSessionsTestDB(t)
+	eat := time.FixedZone("EAT", 3*60*60)
+	now := time.Now()
+
+	// expiry stored in a different zone offset than the one used for comparison
+	active, err := InsertSession(db, "user-1", now.In(eat).Add(time.Hour), "Firefox", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("InsertSession() failed: %v", err)
+	}
+	other, _ := InsertSession(db, "user-1", now.UTC().Add(2*time.Hour), "Chrome", "10.0.0.2")
+	expired, _ := InsertSession(db, "user-1", now.In(eat).Add(-time.Minute), "Safari", "10.0.0.3")
+	foreign, _ := InsertSession(db, "user-2", now.Add(time.Hour), "Edge", "10.0.0.4")
+
+	session, err := GetSession(db, active)
+	if err != nil {
+		t.Fatalf("GetSession() failed: %v", err)
+	}
+	if session.UserAgent != "Firefox" || session.IPAddress != "10.0.0.1" || session.LastUsedAt.IsZero() {
+		t.Errorf("Session metadata not stored: %+v", session)
+	}
+
+	sessions, err := GetUserSessions(db, "user-1", now.UTC())
+	if err != nil {
+		t.Fatalf("GetUserSessions() failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 active sessions, got %d", len(sessions))
+	}
+
+	if ok, _ := DeleteUserSession(db, "user-1", foreign); ok {
+		t.Error("A user must not be able to delete another user's session")
+	}
+
+	purged, err := PurgeExpiredSessions(db, now)
+	if err != nil {
+		t.Fatalf("PurgeExpiredSessions() failed: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged session, got %d", purged)
+	}
+	if _, err := GetSession(db, expired); err != sql.ErrNoRows {
+		t.Errorf("Expected expired session to be purged, got %v", err)
+	}
+
+	revoked, err := DeleteOtherSessions(db, "user-1", active)
+	if err != nil {
+		t.Fatalf("DeleteOtherSessions() failed: %v", err)
+	}
+	if revoked != 1 {
+		t.Errorf("Expected 1 revoked session, got %d", revoked)
+	}
+	if _, err := GetSession(db, other); err != sql.ErrNoRows {
+		t.Errorf("Expected other session to be revoked, got %v", err)
+	}
+	if _, err := GetSession(db, active); err != nil {
+		t.Errorf("Expected current session to survive, got %v", err)
+	}
+	if _, err := GetSession(db, foreign); err != nil {
+		t.Errorf("Expected another user's session to survive, got %v", err)
+	}
+}
@@ -25,10 +25,13 @@ const MigrationsPath = "file://pkg/db/migrations"
 
 // Session represents a user session.
 type Session struct {
-	ID        string // UUID v4
-	UserID    string
-	CreatedAt time.Time
-	ExpiresAt time.Time
+	ID         string // UUID v4
+	UserID     string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	UserAgent  string
+	IPAddress  string
+	LastUsedAt time.Time
 }
 
 func CreateMigrationFile() {
@@ -71,12 +74,12 @@ func CreateMigrationFile() {
 	os.Exit(1)
 }
 
-// InsertSession inserts a new session into the sessions table.
-func InsertSession(db *sql.DB, userID string, expiresAt time.Time) (string, error) {
+// InsertSession inserts a new session into the sessions table along with the client it was created from.
+func InsertSession(db *sql.DB, userID string, expiresAt time.Time, userAgent, ipAddress string) (string, error) {
 	sessionID := uuid.NewString()
 	_, err := db.Exec(
-		"INSERT INTO sessions (id, user_id, expires_at) VALUES (?, ?, ?)",
-		sessionID, userID, expiresAt,
+		"INSERT INTO sessions (id, user_id, expires_at, user_agent, ip_address, last_used_at) VALUES (?, ?, ?, ?, ?, ?)",
+		sessionID, userID, expiresAt, userAgent, ipAddress, time.Now(),
 	)
 	if err != nil {
 		return "", err
@@ -86,16 +89,11 @@ func InsertSession(db *sql.DB, userID string, expiresAt time.Time) (string, erro
 
 // GetSession retrieves a session by its ID.
 func GetSession(db *sql.DB, sessionID string) (*Session, error) {
-	var s Session
 	row := db.QueryRow(
-		"SELECT id, user_id, created_at, expires_at FROM sessions WHERE id = ?",
+		"SELECT id, user_id, created_at, expires_at, user_agent, ip_address, last_used_at FROM sessions WHERE id = ?",
 		sessionID,
 	)
-	err := row.Scan(&s.ID, &s.UserID, &s.CreatedAt, &s.ExpiresAt)
-	if err != nil {
-		return nil, err
-	}
-	return &s, nil
+	return scanSession(row)
 }
 
 // DeleteSession deletes a session by its ID.
@@ -0,0 +1,72 @@
+// Package hashtag finds #hashtags in free text.
+package hashtag
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest tag, in characters and without the #. Longer runs are not tags at all.
+const MaxLength = 50
+
+// Parse returns the distinct tags in text, lowercased and without the #, in order of first use.
+// A tag starts with a # that does not follow a word, so that URL fragments and HTML entities
+// are left alone, and runs over letters, digits and underscores. It needs at least one letter.
+func Parse(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	prev := ' '
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '#' || !boundary(prev) {
+			prev = r
+			i += size
+			continue
+		}
+		end := i + size
+		for end < len(text) {
+			next, n := utf8.DecodeRuneInString(text[end:])
+			if !wordRune(next) {
+				break
+			}
+			end += n
+		}
+		if tag, ok := Normalize(text[i+size : end]); ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		prev = '#'
+		if end > i+size {
+			prev, _ = utf8.DecodeLastRuneInString(text[:end])
+		}
+		i = end
+	}
+	return tags
+}
+
+// Normalize returns tag lowercased and without a leading #, and whether it is a valid tag.
+func Normalize(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	n := utf8.RuneCountInString(tag)
+	if n == 0 || n > MaxLength {
+		return "", false
+	}
+	hasLetter := false
+	for _, r := range tag {
+		if !wordRune(r) {
+			return "", false
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+	}
+	return tag, hasLetter
+}
+
+func wordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '_'
+}
+
+// boundary reports whether a # after r may start a tag
+func boundary(r rune) bool {
+	return !wordRune(r) && r != '&' && r != '#' && r != '/'
+}
@@ -0,0 +1,40 @@
+package hashtag
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hiking today #Outdoors #hiking_2024!", []string{"outdoors", "hiking_2024"}},
+		{"#go, #Go and #GO again", []string{"go"}},
+		{"(#café) and #東京", []string{"café", "東京"}},
+		{"see example.com/page#section or /#/route", nil},
+		{"an entity &#39; and a#b", nil},
+		{"##double and #1 and #", nil},
+		{"#" + strings.Repeat("a", MaxLength), []string{strings.Repeat("a", MaxLength)}},
+		{"#" + strings.Repeat("a", MaxLength+1), nil},
+		{"#2024goals\n#last", []string{"2024goals", "last"}},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Parse(%q) = %q, expected %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if tag, ok := Normalize("#Travel"); !ok || tag != "travel" {
+		t.Errorf("expected travel, got %q, %v", tag, ok)
+	}
+	for _, tag := range []string{"", "#", "123", "two words", "a-b"} {
+		if _, ok := Normalize(tag); ok {
+			t.Errorf("expected %q to be rejected", tag)
+		}
+	}
+}
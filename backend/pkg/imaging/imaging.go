@@ -0,0 +1,176 @@
+// Package imaging turns uploaded images into the files that are served: decoded and re-encoded so
+// no metadata survives, scaled down to a few sizes, with a small placeholder to show while they load.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// Size is a variant to produce, the image scaled down to fit in a MaxSide x MaxSide square
+type Size struct {
+	Name    string
+	MaxSide int
+}
+
+// Sizes of post images
+var (
+	Thumbnail = Size{Name: "thumb", MaxSide: 320}
+	Feed      = Size{Name: "feed", MaxSide: 1080}
+	Full      = Size{Name: "full", MaxSide: 2048}
+)
+
+// Errors returned by Process
+var (
+	ErrUnsupported = errors.New("only JPEG, PNG and GIF images are supported")
+	ErrTooLarge    = errors.New("image dimensions are too large")
+)
+
+// Variant is one encoded size of an image
+type Variant struct {
+	Size   Size
+	Data   []byte
+	Ext    string // ".jpg", ".png" or ".gif"
+	Width  int
+	Height int
+}
+
+// Result is a processed image
+type Result struct {
+	Variants      []Variant // in the order the sizes were asked for
+	Blurhash      string
+	DominantColor string // as #rrggbb
+}
+
+// Processor decodes and re-encodes images within limits on their size
+type Processor struct {
+	MaxPixels          int64 // width * height of a still image or of one frame of an animation
+	MaxAnimationPixels int64 // width * height * frames of an animated GIF
+	JPEGQuality        int
+}
+
+// NewProcessor creates and returns a new instance of Processor.
+func NewProcessor() *Processor {
+	return &Processor{
+		MaxPixels:          50_000_000,
+		MaxAnimationPixels: 250_000_000,
+		JPEGQuality:        82,
+	}
+}
+
+// Process decodes a JPEG, PNG or GIF image and encodes it once per size. The dimensions are checked
+// against the limits before anything is decoded, so a small file cannot expand into gigabytes of pixels.
+//
+// JPEGs stay JPEGs and are turned upright from their EXIF orientation, since the EXIF data is dropped.
+// PNGs and still GIFs become PNGs. An animated GIF keeps its animation in every size it already fits,
+// smaller sizes get a PNG of its first frame.
+func (p *Processor) Process(data []byte, sizes ...Size) (*Result, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, ErrUnsupported
+		}
+		return nil, err
+	}
+	if format != "jpeg" && format != "png" && format != "gif" {
+		return nil, ErrUnsupported
+	}
+	pixels := int64(config.Width) * int64(config.Height)
+	if config.Width <= 0 || config.Height <= 0 || pixels > p.MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var img *image.RGBA
+	var animation *gif.GIF
+	switch format {
+	case "gif":
+		frames, err := gifFrameCount(data)
+		if err != nil {
+			return nil, err
+		}
+		if int64(frames)*pixels > p.MaxAnimationPixels {
+			return nil, ErrTooLarge
+		}
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		// frames may cover part of the canvas only
+		img = image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+		draw.Draw(img, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Over)
+		if len(g.Image) > 1 {
+			animation = g
+		}
+	default:
+		decoded, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		img = toRGBA(decoded)
+		if format == "jpeg" {
+			img = orient(img, jpegOrientation(data))
+		}
+	}
+
+	result := &Result{}
+	for _, size := range sizes {
+		v, err := p.encode(img, animation, format, size)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, v)
+	}
+
+	sample := onWhite(fit(img, 32))
+	result.Blurhash = blurhash(sample, 4, 3)
+	result.DominantColor = dominantColor(sample)
+	return result, nil
+}
+
+func (p *Processor) encode(img *image.RGBA, animation *gif.GIF, format string, size Size) (Variant, error) {
+	var buf bytes.Buffer
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if animation != nil && max(w, h) <= size.MaxSide {
+		if err := gif.EncodeAll(&buf, animation); err != nil {
+			return Variant{}, err
+		}
+		return Variant{Size: size, Data: buf.Bytes(), Ext: ".gif", Width: w, Height: h}, nil
+	}
+
+	scaled := fit(img, size.MaxSide)
+	v := Variant{Size: size, Width: scaled.Bounds().Dx(), Height: scaled.Bounds().Dy()}
+	if format == "jpeg" {
+		if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: p.JPEGQuality}); err != nil {
+			return Variant{}, err
+		}
+		v.Ext = ".jpg"
+	} else {
+		if err := png.Encode(&buf, scaled); err != nil {
+			return Variant{}, err
+		}
+		v.Ext = ".png"
+	}
+	v.Data = buf.Bytes()
+	return v, nil
+}
+
+// toRGBA copies img into an RGBA image whose bounds start at 0,0
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// onWhite flattens img onto a white background
+func onWhite(img *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, image.Point{}, draw.Over)
+	return dst
+}
@@ -0,0 +1,189 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+// exifSegment builds an APP1 segment with an orientation tag and a stand-in for GPS data
+func exifSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("GPS 51.5007 N 0.1246 W")
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func testJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// the top left quarter is red so the orientation can be checked
+			c := color.RGBA{0, 0, 255, 255}
+			if x < w/2 && y < h/2 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	data := buf.Bytes()
+	return append(append([]byte{0xFF, 0xD8}, exifSegment(orientation)...), data[2:]...)
+}
+
+func TestProcessStripsMetadataAndResizes(t *testing.T) {
+	data := testJPEG(t, 3000, 1500, 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatal("expected the test image to carry an orientation")
+	}
+
+	result, err := NewProcessor().Process(data, Thumbnail, Feed, Full)
+	if err != nil {
+		t.Fatalf("Process() failed: %v", err)
+	}
+	// turned a quarter clockwise, the image stands 1500 wide and 3000 high
+	want := map[string][2]int{"thumb": {160, 320}, "feed": {540, 1080}, "full": {1024, 2048}}
+	if len(result.Variants) != 3 {
+		t.Fatalf("expected 3 variants, got %d", len(result.Variants))
+	}
+	for _, v := range result.Variants {
+		if v.Ext != ".jpg" {
+			t.Errorf("expected the %s variant to stay a JPEG, got %s", v.Size.Name, v.Ext)
+		}
+		if bytes.Contains(v.Data, []byte("Exif")) || bytes.Contains(v.Data, []byte("GPS")) {
+			t.Errorf("expected the %s variant to have no metadata", v.Size.Name)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(v.Data))
+		if err != nil {
+			t.Fatalf("failed to decode the %s variant: %v", v.Size.Name, err)
+		}
+		size := [2]int{img.Bounds().Dx(), img.Bounds().Dy()}
+		if size != want[v.Size.Name] || size != [2]int{v.Width, v.Height} {
+			t.Errorf("expected the %s variant to be %v, got %v (reported %dx%d)", v.Size.Name, want[v.Size.Name], size, v.Width, v.Height)
+		}
+		// the red quarter moves from the top left to the top right
+		r, _, b, _ := img.At(size[0]*3/4, size[1]/4).RGBA()
+		if r < b {
+			t.Errorf("expected the %s variant to be turned upright", v.Size.Name)
+		}
+	}
+	if len(result.Blurhash) != 28 || !strings.HasPrefix(result.DominantColor, "#") {
+		t.Errorf("unexpected placeholder %q %q", result.Blurhash, result.DominantColor)
+	}
+}
+
+func TestProcessRejectsDecompressionBombs(t *testing.T) {
+	// a PNG header claiming 100000x100000 pixels, with no pixels after it
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], 100000)
+	binary.BigEndian.PutUint32(ihdr[8:], 100000)
+	ihdr[12], ihdr[13] = 8, 6 // 8 bit RGBA
+	var bomb bytes.Buffer
+	bomb.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&bomb, binary.BigEndian, uint32(13))
+	bomb.Write(ihdr)
+	binary.Write(&bomb, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+
+	if _, err := NewProcessor().Process(bomb.Bytes(), Full); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge for a huge PNG, got %v", err)
+	}
+
+	animation := testGIF(t, 100, 100, 30)
+	p := NewProcessor()
+	p.MaxAnimationPixels = 100 * 100 * 20
+	if _, err := p.Process(animation, Full); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge for a long animation, got %v", err)
+	}
+
+	if _, err := NewProcessor().Process([]byte("<svg></svg>"), Full); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func testGIF(t *testing.T, w, h, frames int) []byte {
+	t.Helper()
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, w, h), palette.Plan9)
+		for p := range frame.Pix {
+			frame.Pix[p] = uint8(i)
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("failed to encode test gif: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessAnimatedGIF(t *testing.T) {
+	data := testGIF(t, 40, 20, 3)
+	if n, err := gifFrameCount(data); err != nil || n != 3 {
+		t.Fatalf("expected 3 frames, got %d, %v", n, err)
+	}
+
+	result, err := NewProcessor().Process(data, Size{Name: "small", MaxSide: 10}, Full)
+	if err != nil {
+		t.Fatalf("Process() failed: %v", err)
+	}
+	small, full := result.Variants[0], result.Variants[1]
+	if small.Ext != ".png" || small.Width != 10 || small.Height != 5 {
+		t.Errorf("expected a 10x5 still of the first frame, got %s %dx%d", small.Ext, small.Width, small.Height)
+	}
+	if full.Ext != ".gif" {
+		t.Fatalf("expected the full variant to stay animated, got %s", full.Ext)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(full.Data))
+	if err != nil || len(g.Image) != 3 {
+		t.Errorf("expected the animation to keep its 3 frames, got %v", err)
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for p := 0; p < len(img.Pix); p += 4 {
+		copy(img.Pix[p:], []uint8{0x33, 0x66, 0x99, 0xff})
+	}
+	if got := dominantColor(img); got != "#336699" {
+		t.Errorf("expected the dominant colour #336699, got %s", got)
+	}
+
+	hash := blurhash(img, 4, 3)
+	if len(hash) != 28 {
+		t.Fatalf("expected a 28 character blurhash, got %q", hash)
+	}
+	// after the size and maximum characters, four characters hold the average colour
+	dc := 0
+	for _, c := range hash[2:6] {
+		dc = dc*83 + strings.IndexRune(base83, c)
+	}
+	if dc != 0x336699 {
+		t.Errorf("expected the blurhash to hold the average colour, got %06x", dc)
+	}
+}
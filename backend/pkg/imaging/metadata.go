@@ -0,0 +1,126 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errInvalidGIF = errors.New("gif: invalid format")
+
+// jpegOrientation reads the EXIF orientation of a JPEG, 1 (upright) when it has none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF: // fill byte
+			i++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // markers without a length
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9: // the image data starts, metadata comes before it
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(segment) >= 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// tiffOrientation finds the orientation tag in the first IFD of EXIF data
+func tiffOrientation(t []byte) int {
+	if len(t) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(t[4:]))
+	if ifd < 8 || ifd+2 > len(t) {
+		return 1
+	}
+	entries := int(order.Uint16(t[ifd:]))
+	for k := 0; k < entries; k++ {
+		e := ifd + 2 + k*12
+		if e+12 > len(t) {
+			return 1
+		}
+		if order.Uint16(t[e:]) == 0x0112 {
+			if v := int(order.Uint16(t[e+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// gifFrameCount counts the frames of a GIF by walking its blocks, without decompressing any of them.
+// Frames compress very well, so their number has to be known before gif.DecodeAll allocates them all.
+func gifFrameCount(data []byte) (int, error) {
+	if len(data) < 13 {
+		return 0, errInvalidGIF
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&7 + 1) // global colour table
+	}
+	frames := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // extension: label, then sub-blocks
+			i = skipSubBlocks(data, i+2)
+		case 0x2C: // image descriptor, optional local colour table, LZW code size, then sub-blocks
+			if i+10 > len(data) {
+				return 0, errInvalidGIF
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&7 + 1)
+			}
+			i = skipSubBlocks(data, i+1)
+			frames++
+		case 0x3B: // trailer
+			return frames, nil
+		default:
+			return 0, errInvalidGIF
+		}
+		if i < 0 {
+			return 0, errInvalidGIF
+		}
+	}
+	return frames, nil
+}
+
+// skipSubBlocks returns the index after the sub-blocks starting at i, or -1 if they run past the data
+func skipSubBlocks(data []byte, i int) int {
+	for i < len(data) {
+		n := int(data[i])
+		i++
+		if n == 0 {
+			return i
+		}
+		i += n
+	}
+	return -1
+}
@@ -0,0 +1,125 @@
+package imaging
+
+import (
+	"image"
+	"math"
+)
+
+// fit scales img down to fit in a maxSide x maxSide square, keeping its aspect ratio.
+// Images that already fit are returned as they are.
+func fit(img *image.RGBA, maxSide int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+	if w >= h {
+		h = max(1, int(math.Round(float64(h)*float64(maxSide)/float64(w))))
+		w = maxSide
+	} else {
+		w = max(1, int(math.Round(float64(w)*float64(maxSide)/float64(h))))
+		h = maxSide
+	}
+	return resize(img, w, h)
+}
+
+// contribution is the run of source pixels that make up one destination pixel along an axis
+type contribution struct {
+	start   int
+	weights []float32
+}
+
+// boxWeights averages the source pixels each destination pixel covers, weighted by how much of them it covers
+func boxWeights(srcLen, dstLen int) []contribution {
+	scale := float64(srcLen) / float64(dstLen)
+	contribs := make([]contribution, dstLen)
+	for i := range contribs {
+		lo, hi := float64(i)*scale, float64(i+1)*scale
+		start, end := int(lo), min(srcLen, int(math.Ceil(hi)))
+		weights := make([]float32, end-start)
+		for j := start; j < end; j++ {
+			weights[j-start] = float32((math.Min(hi, float64(j+1)) - math.Max(lo, float64(j))) / scale)
+		}
+		contribs[i] = contribution{start: start, weights: weights}
+	}
+	return contribs
+}
+
+// resize scales src, whose bounds start at 0,0, down to w x h with a box filter. Averaging the
+// premultiplied colours of RGBA keeps transparent pixels from darkening the edges around them.
+// Only one row of the source is held as floats at a time.
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	xs, ys := boxWeights(sw, w), boxWeights(sh, h)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	row := make([]float32, sw*4)
+
+	for y, cy := range ys {
+		clear(row)
+		for k, wy := range cy.weights {
+			srcRow := src.Pix[(cy.start+k)*src.Stride:]
+			for i := range row {
+				row[i] += float32(srcRow[i]) * wy
+			}
+		}
+		dstRow := dst.Pix[y*dst.Stride:]
+		for x, cx := range xs {
+			var r, g, b, a float32
+			for k, wx := range cx.weights {
+				p := (cx.start + k) * 4
+				r += row[p] * wx
+				g += row[p+1] * wx
+				b += row[p+2] * wx
+				a += row[p+3] * wx
+			}
+			d := dstRow[x*4:]
+			d[0], d[1], d[2], d[3] = toByte(r), toByte(g), toByte(b), toByte(a)
+		}
+	}
+	return dst
+}
+
+func toByte(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// orient applies an EXIF orientation to img, so that it is stored the way it is meant to be seen
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // upside down
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored upside down
+				dx, dy = x, h-1-y
+			case 5: // mirrored, on its side
+				dx, dy = y, x
+			case 6: // needs a quarter turn clockwise
+				dx, dy = h-1-y, x
+			case 7: // mirrored, on its other side
+				dx, dy = h-1-y, w-1-x
+			case 8: // needs a quarter turn anticlockwise
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], img.Pix[y*img.Stride+x*4:])
+		}
+	}
+	return dst
+}
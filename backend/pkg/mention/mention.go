@@ -0,0 +1,83 @@
+// Package mention finds @nickname mentions in free text.
+package mention
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Nickname lengths, matching what registration accepts
+const (
+	MinLength = 3
+	MaxLength = 30
+)
+
+// Parse returns the distinct nicknames mentioned in text, without the @, in order of first use.
+// Nicknames keep the case they were written in and are told apart regardless of it.
+// A mention starts with an @ that does not follow a word, so that email addresses are left alone,
+// and runs over ASCII letters, digits and underscores. Runs of the wrong length are not mentions.
+func Parse(text string) []string {
+	var nicknames []string
+	seen := map[string]bool{}
+	prev := ' '
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '@' || !boundary(prev) {
+			prev = r
+			i += size
+			continue
+		}
+		end := i + 1
+		for end < len(text) && nicknameByte(text[end]) {
+			end++
+		}
+		nickname := text[i+1 : end]
+		next, _ := utf8.DecodeRuneInString(text[end:])
+		if Valid(nickname) && !wordRune(next) && !seen[strings.ToLower(nickname)] {
+			seen[strings.ToLower(nickname)] = true
+			nicknames = append(nicknames, nickname)
+		}
+		prev = '@'
+		if end > i+1 {
+			prev = rune(text[end-1])
+		}
+		i = end
+	}
+	return nicknames
+}
+
+// Valid reports whether nickname, without the @, could be mentioned.
+func Valid(nickname string) bool {
+	if len(nickname) < MinLength || len(nickname) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(nickname); i++ {
+		if !nicknameByte(nickname[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidPrefix reports whether prefix, without the @, could start a nickname, as while one is typed.
+func ValidPrefix(prefix string) bool {
+	return prefix != "" && Valid(prefix+strings.Repeat("_", max(MinLength-len(prefix), 0)))
+}
+
+func nicknameByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || b == '_'
+}
+
+// wordRune reports whether r continues a word, so that an @ before it is not a mention
+// and a nickname running into it is not one either
+func wordRune(r rune) bool {
+	if r < utf8.RuneSelf {
+		return nicknameByte(byte(r))
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func boundary(r rune) bool {
+	return !wordRune(r) && r != '@' && r != '/' && r != '.'
+}
@@ -0,0 +1,45 @@
+package mention
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Thanks @alice and @Bob_99!", []string{"alice", "Bob_99"}},
+		{"@alice, @ALICE and @Alice", []string{"alice"}},
+		{"(@carol) said hi to @dave's friend", []string{"carol", "dave"}},
+		{"mail bob@example.com or see example.com/@page", nil},
+		{"@ab is too short and @@double is not one", nil},
+		{"@" + strings.Repeat("a", MaxLength), []string{strings.Repeat("a", MaxLength)}},
+		{"@" + strings.Repeat("a", MaxLength+1), nil},
+		{"@josé is not @jose", []string{"jose"}},
+		{"line one\n@eve", []string{"eve"}},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Parse(%q) = %q, expected %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, nickname := range []string{"bob", "Bob_99", strings.Repeat("x", MaxLength)} {
+		if !Valid(nickname) {
+			t.Errorf("expected %q to be valid", nickname)
+		}
+	}
+	for _, nickname := range []string{"", "ab", "bo b", "bob!", "josé", strings.Repeat("x", MaxLength+1)} {
+		if Valid(nickname) {
+			t.Errorf("expected %q to be invalid", nickname)
+		}
+	}
+	if !ValidPrefix("b") || !ValidPrefix("Bo") || ValidPrefix("") || ValidPrefix("b!") {
+		t.Error("expected prefixes to be checked like nicknames, whatever their length")
+	}
+}
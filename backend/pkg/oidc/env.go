@@ -0,0 +1,35 @@
+package oidc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProvidersFromEnv builds a Client for every provider named in OIDC_PROVIDERS (comma separated).
+// Each provider NAME is configured through OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL and the optional OIDC_<NAME>_SCOPES.
+// getenv is usually os.Getenv.
+func ProvidersFromEnv(getenv func(string) string) ([]Provider, error) {
+	var providers []Provider
+	for _, name := range strings.Split(getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := Config{
+			Name:         name,
+			Issuer:       getenv(prefix + "ISSUER"),
+			ClientID:     getenv(prefix + "CLIENT_ID"),
+			ClientSecret: getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(getenv(prefix + "SCOPES")),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, fmt.Errorf("oidc: provider %q needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+		providers = append(providers, NewClient(cfg))
+	}
+	return providers, nil
+}
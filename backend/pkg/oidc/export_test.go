@@ -0,0 +1,8 @@
+package oidc
+
+import "context"
+
+// VerifyIDToken exposes ID token verification to the external tests
+func VerifyIDToken(c *Client, rawToken, nonce string) (*Identity, error) {
+	return c.verifyIDToken(context.Background(), rawToken, nonce)
+}
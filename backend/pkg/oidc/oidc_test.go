@@ -0,0 +1,162 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"backend/pkg/oidc"
+	"backend/pkg/oidc/oidctest"
+)
+
+func newTestClient(issuer *oidctest.Issuer) *oidc.Client {
+	return oidc.NewClient(oidc.Config{
+		Name:        "mock",
+		Issuer:      issuer.URL,
+		ClientID:    issuer.ClientID,
+		RedirectURL: "http://localhost:8080/api/auth/oidc/mock/callback",
+		HTTPClient:  issuer.Client(),
+	})
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	issuer := oidctest.NewIssuer("social-network")
+	defer issuer.Close()
+	client := newTestClient(issuer)
+
+	verifier, _ := oidc.RandomToken()
+	authURL, err := client.AuthCodeURL("state-1", "nonce-1", oidc.CodeChallenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() failed: %v", err)
+	}
+
+	want := oidc.Identity{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true, GivenName: "Jane", FamilyName: "Doe"}
+	callback, err := issuer.Authorize(authURL, want)
+	if err != nil {
+		t.Fatalf("Authorize() failed: %v", err)
+	}
+	u, _ := url.Parse(callback)
+	if u.Query().Get("state") != "state-1" {
+		t.Errorf("expected state to be echoed, got %q", u.Query().Get("state"))
+	}
+
+	got, err := client.Exchange(context.Background(), u.Query().Get("code"), verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() failed: %v", err)
+	}
+	if *got != want {
+		t.Errorf("expected identity %+v, got %+v", want, *got)
+	}
+
+	// codes are single use
+	if _, err := client.Exchange(context.Background(), u.Query().Get("code"), verifier, "nonce-1"); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Errorf("expected a reused code to fail, got %v", err)
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	issuer := oidctest.NewIssuer("social-network")
+	defer issuer.Close()
+	client := newTestClient(issuer)
+
+	authorize := func(verifier, nonce string) string {
+		authURL, err := client.AuthCodeURL("state", nonce, oidc.CodeChallenge(verifier))
+		if err != nil {
+			t.Fatalf("AuthCodeURL() failed: %v", err)
+		}
+		callback, err := issuer.Authorize(authURL, oidc.Identity{Subject: "sub-1"})
+		if err != nil {
+			t.Fatalf("Authorize() failed: %v", err)
+		}
+		u, _ := url.Parse(callback)
+		return u.Query().Get("code")
+	}
+
+	code := authorize("right-verifier", "nonce")
+	if _, err := client.Exchange(context.Background(), code, "wrong-verifier", "nonce"); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Errorf("expected PKCE mismatch to fail the exchange, got %v", err)
+	}
+
+	code = authorize("verifier", "nonce")
+	if _, err := client.Exchange(context.Background(), code, "verifier", "other-nonce"); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Errorf("expected ErrNonceMismatch, got %v", err)
+	}
+}
+
+func TestVerifyIDTokenClaims(t *testing.T) {
+	issuer := oidctest.NewIssuer("social-network")
+	defer issuer.Close()
+	now := time.Now()
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"iss":   issuer.URL,
+			"sub":   "sub-1",
+			"aud":   "social-network",
+			"exp":   now.Add(time.Hour).Unix(),
+			"iat":   now.Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(claims map[string]any)
+		want   error
+	}{
+		{"valid", func(map[string]any) {}, nil},
+		{"audience list", func(c map[string]any) { c["aud"] = []string{"social-network", "other"}; c["azp"] = "social-network" }, nil},
+		{"string email_verified", func(c map[string]any) { c["email_verified"] = "true" }, nil},
+		{"wrong issuer", func(c map[string]any) { c["iss"] = "https://evil.example.com" }, oidc.ErrInvalidIDToken},
+		{"wrong audience", func(c map[string]any) { c["aud"] = "other" }, oidc.ErrInvalidIDToken},
+		{"audience list without azp", func(c map[string]any) { c["aud"] = []string{"social-network", "other"} }, oidc.ErrInvalidIDToken},
+		{"expired", func(c map[string]any) { c["exp"] = now.Add(-2 * oidc.ClockSkew).Unix() }, oidc.ErrInvalidIDToken},
+		{"issued in the future", func(c map[string]any) { c["iat"] = now.Add(time.Hour).Unix() }, oidc.ErrInvalidIDToken},
+		{"missing subject", func(c map[string]any) { delete(c, "sub") }, oidc.ErrInvalidIDToken},
+		{"wrong nonce", func(c map[string]any) { c["nonce"] = "replayed" }, oidc.ErrNonceMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+			_, err := oidc.VerifyIDToken(newTestClient(issuer), issuer.SignIDToken(claims), "nonce")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	token := issuer.SignIDToken(valid())
+	tampered := token[:len(token)-4] + "AAAA"
+	if _, err := oidc.VerifyIDToken(newTestClient(issuer), tampered, "nonce"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("expected a tampered signature to be rejected, got %v", err)
+	}
+}
+
+func TestProvidersFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OIDC_PROVIDERS":           "Google, my-idp",
+		"OIDC_GOOGLE_ISSUER":       "https://accounts.google.com",
+		"OIDC_GOOGLE_CLIENT_ID":    "client",
+		"OIDC_GOOGLE_REDIRECT_URL": "http://localhost:8080/api/auth/oidc/google/callback",
+		"OIDC_MY_IDP_ISSUER":       "https://idp.example.com",
+		"OIDC_MY_IDP_CLIENT_ID":    "client",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	if _, err := oidc.ProvidersFromEnv(getenv); err == nil {
+		t.Error("expected an error for a provider without a redirect URL")
+	}
+
+	env["OIDC_MY_IDP_REDIRECT_URL"] = "http://localhost:8080/api/auth/oidc/my-idp/callback"
+	providers, err := oidc.ProvidersFromEnv(getenv)
+	if err != nil {
+		t.Fatalf("ProvidersFromEnv() failed: %v", err)
+	}
+	if len(providers) != 2 || providers[0].Name() != "google" || providers[1].Name() != "my-idp" {
+		t.Errorf("unexpected providers: %v", providers)
+	}
+}
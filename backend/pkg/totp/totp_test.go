@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 Appendix B test vectors for HMAC-SHA1
+func TestHOTPMatchesRFC6238Vectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		got := hotp(key, uint64(tt.unix/Period), 8)
+		if got != tt.want {
+			t.Errorf("T=%d: expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() failed: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	code, err := GenerateCode(secret, now)
+	if err != nil {
+		t.Fatalf("GenerateCode() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		ok   bool
+	}{
+		{"current step", code, now, true},
+		{"one step behind", code, now.Add(Period * time.Second), true},
+		{"one step ahead", code, now.Add(-Period * time.Second), true},
+		{"outside skew window", code, now.Add(3 * Period * time.Second), false},
+		{"spaces are ignored", code[:3] + " " + code[3:], now, true},
+		{"wrong length", code + "1", now, false},
+		{"empty", "", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(secret, tt.code, tt.at)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if ok && step != Step(now) {
+				t.Errorf("expected matched step %d, got %d", Step(now), step)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsInvalidSecret(t *testing.T) {
+	if _, ok := Validate("not base32!", "123456", time.Now()); ok {
+		t.Error("expected invalid secret to be rejected")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("JBSWY3DPEHPK3PXP", "Social Network", "jane@example.com")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/Social%20Network:jane@example.com?") {
+		t.Errorf("unexpected URI label: %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Social+Network", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("expected URI to contain %q, got %s", part, uri)
+		}
+	}
+}
@@ -4,12 +4,22 @@ const nextConfig = {
     // Use environment variable for API URL, fallback to localhost for development
     const apiUrl = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 
-    return [
-      {
-        source: '/api/:path*',
-        destination: `${apiUrl}/api/:path*`,
-      },
-    ];
+    return {
+      beforeFiles: [
+        {
+          // uploads are served by the backend from its configured store, which checks who may see them.
+          // This runs before public/ is looked at, so files left in public/uploads are never served as is.
+          source: '/uploads/:path*',
+          destination: `${apiUrl}/uploads/:path*`,
+        },
+      ],
+      afterFiles: [
+        {
+          source: '/api/:path*',
+          destination: `${apiUrl}/api/:path*`,
+        },
+      ],
+    };
   },
 };
 
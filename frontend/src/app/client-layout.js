@@ -6,6 +6,9 @@ import { usePathname } from "next/navigation";
 import connectWebsocket from "@/components/ws";
 import { UserContext } from "@/context/user-context";
 import Loading from "@/components/loading";
+import { installCsrfFetch } from "@/lib/csrf";
+
+installCsrfFetch();
 
 export default function ClientLayout({ children }) {
   const [data, setData] = useState(null);
@@ -0,0 +1,47 @@
+const API_URL = "http://localhost:8080"
+const SAFE_METHODS = ["GET", "HEAD", "OPTIONS"]
+
+let tokenPromise = null
+
+// readCookie returns the csrf_token cookie when the API shares our host (it ignores the port)
+const readCookie = () => {
+    const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]+)/)
+    return match ? decodeURIComponent(match[1]) : null
+}
+
+const getCsrfToken = async (originalFetch) => {
+    const fromCookie = readCookie()
+    if (fromCookie) return fromCookie
+
+    if (!tokenPromise) {
+        tokenPromise = originalFetch(`${API_URL}/api/csrf`, { credentials: "include" })
+            .then((res) => res.json())
+            .then((data) => data.csrf_token)
+            .catch((error) => {
+                tokenPromise = null
+                throw error
+            })
+    }
+    return tokenPromise
+}
+
+// installCsrfFetch makes every state-changing request to the API send the X-CSRF-Token header
+// the backend requires, so components can keep calling fetch directly.
+export const installCsrfFetch = () => {
+    if (typeof window === "undefined" || window.fetch.csrfInstalled) return
+
+    const originalFetch = window.fetch.bind(window)
+    const csrfFetch = async (input, init = {}) => {
+        const url = typeof input === "string" ? input : input.url
+        const method = (init.method || (typeof input === "string" ? "GET" : input.method) || "GET").toUpperCase()
+
+        if (url.startsWith(API_URL) && !SAFE_METHODS.includes(method)) {
+            const headers = new Headers(init.headers || (typeof input === "string" ? undefined : input.headers))
+            headers.set("X-CSRF-Token", await getCsrfToken(originalFetch))
+            init = { ...init, headers }
+        }
+        return originalFetch(input, init)
+    }
+    csrfFetch.csrfInstalled = true
+    window.fetch = csrfFetch
+}